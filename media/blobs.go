@@ -0,0 +1,127 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobStore stores media files by key, keys use "/" as separator whatever
+// the backend is.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Config selects the blob store, only the local filesystem is available by
+// default, other backends implement BlobStore.
+type Config struct {
+	Dir     string
+	BaseURL string
+	MaxSize int64
+}
+
+// RegisterFlags binds the media flags, their defaults come from MEDIA_DIR,
+// MEDIA_BASE_URL and MEDIA_MAX_SIZE.
+func (c *Config) RegisterFlags() {
+	flag.StringVar(&c.Dir, "media_dir", envString("MEDIA_DIR", "uploads"), "local media directory")
+	flag.StringVar(&c.BaseURL, "media_url", envString("MEDIA_BASE_URL", "/media"), "media public base url")
+	flag.Int64Var(&c.MaxSize, "media_max_size", envInt64("MEDIA_MAX_SIZE", 5<<20), "media upload max size in bytes")
+}
+
+func (c *Config) NewBlobStore() (BlobStore, error) {
+	return NewLocalStore(c.Dir, c.BaseURL)
+}
+
+type localStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStore(dir, baseURL string) (BlobStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+func (s *localStore) Put(_ context.Context, key, _ string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see partial files.
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, r)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *localStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *localStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *localStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid media key: key=%v", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// Handler serves the local media files, directory listings are disabled.
+func (c *Config) Handler() http.Handler {
+	files := http.FileServer(http.Dir(c.Dir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
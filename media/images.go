@@ -0,0 +1,144 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	ThumbnailSize = 320
+	maxPixels     = 40_000_000
+)
+
+var AllowedTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Image is a validated upload with its generated thumbnail, the thumbnail
+// is encoded as PNG for PNG and GIF sources and as JPEG otherwise.
+type Image struct {
+	Data          []byte
+	ContentType   string
+	Extension     string
+	Width         int
+	Height        int
+	Thumbnail     []byte
+	ThumbnailType string
+	ThumbnailExt  string
+}
+
+// ProcessImage checks the sniffed content type, not the declared one, and
+// decodes the image before generating the thumbnail.
+func ProcessImage(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+
+	extension, ok := AllowedTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image type: contentType=%v", contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return nil, errors.New("image dimensions too large")
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{
+		Data:        data,
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+
+	thumbnail := Resize(source, ThumbnailSize)
+
+	var buffer bytes.Buffer
+
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buffer, thumbnail, &jpeg.Options{Quality: 85})
+		img.ThumbnailType, img.ThumbnailExt = "image/jpeg", "jpg"
+	} else {
+		err = png.Encode(&buffer, thumbnail)
+		img.ThumbnailType, img.ThumbnailExt = "image/png", "png"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img.Thumbnail = buffer.Bytes()
+
+	return img, nil
+}
+
+// Resize scales the image down with a box filter so its largest side fits
+// the given size, smaller images are only copied.
+func Resize(source image.Image, size int) image.Image {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width <= size && height <= size {
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(dst, dst.Bounds(), source, bounds.Min, draw.Src)
+		return dst
+	}
+
+	targetWidth, targetHeight := size, height*size/width
+	if height > width {
+		targetWidth, targetHeight = width*size/height, size
+	}
+	if targetWidth < 1 {
+		targetWidth = 1
+	}
+	if targetHeight < 1 {
+		targetHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+
+	for y := 0; y < targetHeight; y++ {
+		y0 := bounds.Min.Y + y*height/targetHeight
+		y1 := bounds.Min.Y + (y+1)*height/targetHeight
+
+		for x := 0; x < targetWidth; x++ {
+			x0 := bounds.Min.X + x*width/targetWidth
+			x1 := bounds.Min.X + (x+1)*width/targetWidth
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := source.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					count++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return dst
+}
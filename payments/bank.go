@@ -0,0 +1,59 @@
+package payments
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bicPattern  = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// NormalizeIban removes the spaces of an IBAN and verifies its check digits.
+func NormalizeIban(iban string) (string, error) {
+	iban = strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+
+	if !ibanPattern.MatchString(iban) {
+		return "", errors.New("invalid iban format")
+	}
+
+	// the country code and check digits move to the end, letters are
+	// replaced by two digits (A=10 ... Z=35).
+	var digits strings.Builder
+	for _, char := range iban[4:] + iban[:4] {
+		if char >= 'A' && char <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(char-'A') + 10))
+		} else {
+			digits.WriteRune(char)
+		}
+	}
+
+	number, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(number, big.NewInt(97)).Int64() != 1 {
+		return "", errors.New("invalid iban check digits")
+	}
+
+	return iban, nil
+}
+
+func NormalizeBic(bic string) (string, error) {
+	bic = strings.ToUpper(strings.TrimSpace(bic))
+
+	if !bicPattern.MatchString(bic) {
+		return "", errors.New("invalid bic format")
+	}
+
+	return bic, nil
+}
+
+// Last4 is the part of an account number shown back to its owner.
+func Last4(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
@@ -0,0 +1,75 @@
+package payments
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const keySize = 32
+
+// Cipher seals the bank details of the payouts at rest.
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(sealed string) (string, error)
+}
+
+type aesCipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher returns an AES-256-GCM cipher, the random nonce is stored in
+// front of each sealed value.
+func NewCipher(key []byte) (Cipher, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid encryption key, must be %d bytes: size=%d", keySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &aesCipher{aead: aead}, nil
+}
+
+func (c *aesCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *aesCipher) Decrypt(sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < c.aead.NonceSize() {
+		return "", errors.New("invalid sealed value")
+	}
+
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,102 @@
+package payments
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher(bytes.Repeat([]byte{1}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := c.Encrypt("GB82WEST12345698765432")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(sealed, "GB82WEST12345698765432") {
+		t.Fatalf("Encrypt() = %v, contains the plaintext", sealed)
+	}
+
+	other, err := c.Encrypt("GB82WEST12345698765432")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sealed == other {
+		t.Fatal("Encrypt() sealed the same value twice, want a random nonce")
+	}
+
+	plaintext, err := c.Decrypt(sealed)
+	if err != nil || plaintext != "GB82WEST12345698765432" {
+		t.Fatalf("Decrypt() = %v, %v, want the plaintext", plaintext, err)
+	}
+}
+
+func TestCipherDecryptRejectsTamperedValues(t *testing.T) {
+	c, err := NewCipher(bytes.Repeat([]byte{1}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := NewCipher(bytes.Repeat([]byte{2}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := c.Encrypt("GB82WEST12345698765432")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := base64.StdEncoding.DecodeString(sealed)
+	data[len(data)-1] ^= 1
+	tampered := base64.StdEncoding.EncodeToString(data)
+
+	tests := []struct {
+		name   string
+		cipher Cipher
+		sealed string
+	}{
+		{"tampered", c, tampered},
+		{"other key", otherKey, sealed},
+		{"too short", c, base64.StdEncoding.EncodeToString([]byte("short"))},
+		{"not base64", c, "not base64!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.cipher.Decrypt(test.sealed); err == nil {
+				t.Fatal("Decrypt() = nil error")
+			}
+		})
+	}
+}
+
+func TestConfigNewCipher(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		ok   bool
+	}{
+		{"valid key", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, keySize)), true},
+		{"missing key", "", false},
+		{"short key", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 16)), false},
+		{"not base64", "not base64!", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &Config{PayoutKey: test.key}
+
+			_, err := config.NewCipher()
+			if (err == nil) != test.ok {
+				t.Fatalf("NewCipher() = %v, want ok %v", err, test.ok)
+			}
+		})
+	}
+}
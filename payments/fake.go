@@ -0,0 +1,121 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const ProviderFake = "fake"
+
+// fakeProvider is the local provider for development, intents live in
+// memory and succeed as soon as they are confirmed.
+type fakeProvider struct {
+	secret  string
+	mu      sync.Mutex
+	intents map[string]*Intent
+}
+
+func NewFakeProvider(secret string) PaymentProvider {
+	return &fakeProvider{secret: secret, intents: make(map[string]*Intent)}
+}
+
+func (p *fakeProvider) Name() string {
+	return ProviderFake
+}
+
+func (p *fakeProvider) CreateIntent(_ context.Context, amount float32, _ string) (*Intent, error) {
+	id, err := randomHex(12)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, err
+	}
+
+	intent := &Intent{
+		Id:           "fake_" + id,
+		Amount:       amount,
+		Status:       StatusPending,
+		ClientSecret: secret,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.intents[intent.Id] = intent
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *fakeProvider) ConfirmIntent(_ context.Context, intentId string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentId]
+	if !ok {
+		return nil, fmt.Errorf("payment intent not found: intentId=%v", intentId)
+	}
+
+	if intent.Status == StatusPending {
+		intent.Status = StatusSucceeded
+	}
+
+	copied := *intent
+	return &copied, nil
+}
+
+// ParseWebhook expects the hex HMAC-SHA256 of the payload, see Sign, and a
+// {"intent_id": "...", "status": "..."} payload.
+func (p *fakeProvider) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	if p.secret == "" {
+		return nil, errors.New("payment webhooks disabled, secret not configured")
+	}
+
+	if !hmac.Equal([]byte(Sign(p.secret, payload)), []byte(signature)) {
+		return nil, errors.New("invalid payment webhook signature")
+	}
+
+	var body struct {
+		IntentId string `json:"intent_id"`
+		Status   string `json:"status"`
+	}
+
+	err := json.Unmarshal(payload, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body.Status != StatusSucceeded && body.Status != StatusFailed {
+		return nil, fmt.Errorf("invalid payment webhook status: status=%v", body.Status)
+	}
+
+	return &Event{IntentId: body.IntentId, Status: body.Status}, nil
+}
+
+// Sign returns the signature of a fake provider webhook payload.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,75 @@
+package payments
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFakeProviderIntents(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFakeProvider("secret")
+
+	intent, err := provider.CreateIntent(ctx, 25, "topup:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(intent.Id, "fake_") || intent.Amount != 25 || intent.Status != StatusPending || intent.ClientSecret == "" {
+		t.Fatalf("CreateIntent() = %+v, want a pending intent", intent)
+	}
+
+	intent.Status = StatusFailed
+
+	confirmed, err := provider.ConfirmIntent(ctx, intent.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if confirmed.Status != StatusSucceeded {
+		t.Fatalf("ConfirmIntent() status = %v, want %v", confirmed.Status, StatusSucceeded)
+	}
+
+	_, err = provider.ConfirmIntent(ctx, "fake_unknown")
+	if err == nil {
+		t.Fatal("ConfirmIntent() confirmed an unknown intent")
+	}
+}
+
+func TestFakeProviderParseWebhook(t *testing.T) {
+	provider := NewFakeProvider("secret")
+	payload := []byte(`{"intent_id": "fake_1", "status": "succeeded"}`)
+
+	event, err := provider.ParseWebhook(payload, Sign("secret", payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event.IntentId != "fake_1" || event.Status != StatusSucceeded {
+		t.Fatalf("ParseWebhook() = %+v", event)
+	}
+
+	pending := []byte(`{"intent_id": "fake_1", "status": "pending"}`)
+
+	tests := []struct {
+		name      string
+		provider  PaymentProvider
+		payload   []byte
+		signature string
+	}{
+		{"other secret", provider, payload, Sign("other", payload)},
+		{"tampered payload", provider, []byte(`{"intent_id": "fake_2", "status": "succeeded"}`), Sign("secret", payload)},
+		{"missing signature", provider, payload, ""},
+		{"pending status", provider, pending, Sign("secret", pending)},
+		{"invalid payload", provider, []byte("{"), Sign("secret", []byte("{"))},
+		{"secret not configured", NewFakeProvider(""), payload, Sign("", payload)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.provider.ParseWebhook(test.payload, test.signature); err == nil {
+				t.Fatal("ParseWebhook() = nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package payments
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Intent statuses, a top-up is only credited once its intent succeeded.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Intent is a payment started with a provider, ClientSecret lets the client
+// complete the payment on the provider side.
+type Intent struct {
+	Id           string
+	Amount       float32
+	Status       string
+	ClientSecret string
+}
+
+// Event is a payment notification received on the provider webhook.
+type Event struct {
+	IntentId string
+	Status   string
+}
+
+// PaymentProvider charges the wallet top-ups, only the local fake provider
+// is built in, other providers implement PaymentProvider.
+type PaymentProvider interface {
+	Name() string
+	CreateIntent(ctx context.Context, amount float32, reference string) (*Intent, error)
+	// ConfirmIntent returns the intent as the provider knows it, it stays
+	// pending until the payment is completed.
+	ConfirmIntent(ctx context.Context, intentId string) (*Intent, error)
+	// ParseWebhook verifies the signature of a webhook payload before
+	// decoding its event.
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+}
+
+type Config struct {
+	Provider      string
+	WebhookSecret string
+	AllowFake     bool
+	PayoutKey     string
+	SettlementDir string
+}
+
+// RegisterFlags binds the payment flags, their defaults come from
+// PAYMENT_PROVIDER, PAYMENT_WEBHOOK_SECRET, PAYMENT_ALLOW_FAKE,
+// PAYOUT_ENCRYPTION_KEY and PAYOUT_SETTLEMENT_DIR.
+func (c *Config) RegisterFlags() {
+	flag.StringVar(&c.Provider, "payment_provider", os.Getenv("PAYMENT_PROVIDER"), "payment provider of the wallet top-ups")
+	flag.StringVar(&c.WebhookSecret, "payment_webhook_secret", os.Getenv("PAYMENT_WEBHOOK_SECRET"), "secret signing the payment webhooks")
+	flag.BoolVar(&c.AllowFake, "payment_allow_fake", os.Getenv("PAYMENT_ALLOW_FAKE") == "true", "allow the fake payment provider, for development only")
+	flag.StringVar(&c.PayoutKey, "payout_encryption_key", os.Getenv("PAYOUT_ENCRYPTION_KEY"), "base64 key encrypting the payout bank details")
+
+	settlementDir := os.Getenv("PAYOUT_SETTLEMENT_DIR")
+	if settlementDir == "" {
+		settlementDir = "settlements"
+	}
+
+	flag.StringVar(&c.SettlementDir, "payout_settlement_dir", settlementDir, "directory of the payout settlement files")
+}
+
+// NewProvider requires an explicit provider. The fake provider confirms
+// every payment, so it is refused unless it is allowed for development.
+func (c *Config) NewProvider() (PaymentProvider, error) {
+	switch c.Provider {
+	case "":
+		return nil, errors.New("payment provider is required")
+	case ProviderFake:
+		if !c.AllowFake {
+			return nil, errors.New("fake payment provider is for development only, set PAYMENT_ALLOW_FAKE to use it")
+		}
+		return NewFakeProvider(c.WebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: provider=%v", c.Provider)
+	}
+}
+
+// NewCipher returns the cipher of the payout bank details, the key is
+// required as they are never stored in clear.
+func (c *Config) NewCipher() (Cipher, error) {
+	if c.PayoutKey == "" {
+		return nil, errors.New("payout encryption key is required")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(c.PayoutKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCipher(key)
+}
@@ -0,0 +1,80 @@
+package payments
+
+import (
+	"testing"
+)
+
+func TestNormalizeIban(t *testing.T) {
+	tests := []struct {
+		iban string
+		want string
+		ok   bool
+	}{
+		{"GB82WEST12345698765432", "GB82WEST12345698765432", true},
+		{"de89 3704 0044 0532 0130 00", "DE89370400440532013000", true},
+		{"GB83WEST12345698765432", "", false},
+		{"GB82", "", false},
+		{"GB82-WEST-1234-5698-7654-32", "", false},
+	}
+
+	for _, test := range tests {
+		got, err := NormalizeIban(test.iban)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("NormalizeIban(%q) = %v, %v, want %v, ok %v", test.iban, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestNormalizeBic(t *testing.T) {
+	tests := []struct {
+		bic  string
+		want string
+		ok   bool
+	}{
+		{"DEUTDEFF", "DEUTDEFF", true},
+		{" deutdeff500 ", "DEUTDEFF500", true},
+		{"DEUTDE", "", false},
+		{"DEUTDEFF5", "", false},
+	}
+
+	for _, test := range tests {
+		got, err := NormalizeBic(test.bic)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("NormalizeBic(%q) = %v, %v, want %v, ok %v", test.bic, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestLast4(t *testing.T) {
+	if got := Last4("GB82WEST12345698765432"); got != "5432" {
+		t.Fatalf("Last4() = %v, want 5432", got)
+	}
+	if got := Last4("123"); got != "123" {
+		t.Fatalf("Last4() = %v, want 123", got)
+	}
+}
+
+func TestConfigNewProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		ok     bool
+	}{
+		{"fake allowed", Config{Provider: ProviderFake, AllowFake: true}, true},
+		{"fake not allowed", Config{Provider: ProviderFake}, false},
+		{"missing provider", Config{AllowFake: true}, false},
+		{"unknown provider", Config{Provider: "bank", AllowFake: true}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := test.config.NewProvider()
+			if (err == nil) != test.ok {
+				t.Fatalf("NewProvider() = %v, want ok %v", err, test.ok)
+			}
+			if test.ok && provider.Name() != test.config.Provider {
+				t.Fatalf("NewProvider() name = %v, want %v", provider.Name(), test.config.Provider)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package payments
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SettlementLine is a bank transfer of a payout batch.
+type SettlementLine struct {
+	PayoutId   string
+	HolderName string
+	Iban       string
+	Bic        string
+	Amount     float32
+}
+
+var settlementHeader = []string{"payout_id", "holder_name", "iban", "bic", "amount"}
+
+// WriteSettlement writes the CSV settlement file of a payout batch, to be
+// handed over to the bank. The file holds the bank details in clear, so it
+// is only readable by its owner, and it appears under its final name once
+// it is complete.
+func WriteSettlement(dir, batchId string, at time.Time, lines []SettlementLine) (string, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("settlement-%s-%s.csv", at.UTC().Format("20060102-150405"), batchId)
+	path := filepath.Join(dir, name)
+
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	writer := csv.NewWriter(file)
+
+	err = writer.Write(settlementHeader)
+	for _, line := range lines {
+		if err != nil {
+			break
+		}
+		err = writer.Write([]string{
+			line.PayoutId,
+			line.HolderName,
+			line.Iban,
+			line.Bic,
+			fmt.Sprintf("%.2f", line.Amount),
+		})
+	}
+
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return "", err
+	}
+
+	err = os.Rename(path+".tmp", path)
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return "", err
+	}
+
+	return path, nil
+}
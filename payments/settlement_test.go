@@ -0,0 +1,55 @@
+package payments
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteSettlement(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "settlements")
+	at := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	path, err := WriteSettlement(dir, "batch1", at, []SettlementLine{
+		{PayoutId: "p1", HolderName: "Jane Doe", Iban: "GB82WEST12345698765432", Bic: "DEUTDEFF", Amount: 12.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filepath.Base(path) != "settlement-20240102-030405-batch1.csv" {
+		t.Fatalf("WriteSettlement() = %v", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("settlement file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{settlementHeader, {"p1", "Jane Doe", "GB82WEST12345698765432", "DEUTDEFF", "12.50"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("settlement records = %v, want %v", records, want)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatal("WriteSettlement() left the temporary file")
+	}
+}
@@ -0,0 +1,57 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// keys look like gdk_<prefix>_<secret>, the prefix is stored in clear to
+// find the key and only the SHA-256 of the secret is persisted.
+const (
+	keyPrefix  = "gdk"
+	prefixSize = 6
+	secretSize = 32
+)
+
+var ErrInvalidKey = errors.New("invalid api key")
+
+func New() (key, prefix, hash string, err error) {
+	rawPrefix := make([]byte, prefixSize)
+	_, err = rand.Read(rawPrefix)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	secret := make([]byte, secretSize)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(rawPrefix)
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+
+	return strings.Join([]string{keyPrefix, prefix, encoded}, "_"), prefix, Hash(encoded), nil
+}
+
+func Parse(key string) (prefix, secret string, err error) {
+	parts := strings.SplitN(strings.TrimSpace(key), "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrInvalidKey
+	}
+	return parts[1], parts[2], nil
+}
+
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func OK(hash, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(Hash(secret))) == 1
+}
@@ -0,0 +1,77 @@
+package apikeys
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	key, prefix, hash, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key, keyPrefix+"_"+prefix+"_") || len(prefix) != 2*prefixSize {
+		t.Fatalf("New() key = %v, prefix = %v, want gdk_<prefix>_<secret>", key, prefix)
+	}
+
+	parsedPrefix, secret, err := Parse(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsedPrefix != prefix {
+		t.Fatalf("Parse() prefix = %v, want %v", parsedPrefix, prefix)
+	}
+
+	if strings.Contains(hash, secret) || !OK(hash, secret) {
+		t.Fatalf("New() hash = %v, want the SHA-256 of the secret", hash)
+	}
+
+	other, _, _, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other == key {
+		t.Fatal("New() returned the same key twice")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+		secret string
+		ok     bool
+	}{
+		{"gdk_a1b2c3_secret", "a1b2c3", "secret", true},
+		{" gdk_a1b2c3_secret_with_underscores\n", "a1b2c3", "secret_with_underscores", true},
+		{"abc_a1b2c3_secret", "", "", false},
+		{"gdk_a1b2c3", "", "", false},
+		{"gdk__secret", "", "", false},
+		{"gdk_a1b2c3_", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, test := range tests {
+		prefix, secret, err := Parse(test.key)
+		if (err == nil) != test.ok || prefix != test.prefix || secret != test.secret {
+			t.Errorf("Parse(%q) = %v, %v, %v, want %v, %v, ok %v", test.key, prefix, secret, err, test.prefix, test.secret, test.ok)
+		}
+	}
+}
+
+func TestOK(t *testing.T) {
+	hash := Hash("secret")
+
+	if hash != "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b" {
+		t.Fatalf("Hash() = %v, want the hex SHA-256", hash)
+	}
+
+	for _, secret := range []string{"Secret", "secret ", ""} {
+		if OK(hash, secret) {
+			t.Errorf("OK(%q) = true", secret)
+		}
+	}
+}
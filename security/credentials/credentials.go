@@ -0,0 +1,83 @@
+package credentials
+
+import (
+	"context"
+	"go-delivery/security/mtls"
+	"go-delivery/security/tokens"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	AuthorizationKey = "authorization"
+	ServiceTokenKey  = "x-service-token"
+)
+
+// Internal service names carried by service tokens.
+const (
+	ServiceAPI    = "api"
+	ServiceOrders = "orders"
+)
+
+// Caller is the identity of a gRPC request, User is set when a user token
+// was propagated and Service when another internal service made the call.
+// Peer is the identity of the client certificate when mTLS is enabled.
+type Caller struct {
+	Service string
+	Peer    string
+	User    *tokens.TokenPayload
+}
+
+type callerKey struct{}
+
+func NewContext(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+func FromContext(ctx context.Context) (*Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(*Caller)
+	return caller, ok
+}
+
+// WithUserToken propagates the user token on the outgoing gRPC calls made
+// with the returned context.
+func WithUserToken(ctx context.Context, raw string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, AuthorizationKey, raw)
+}
+
+// Authenticate verifies the credentials found in the incoming metadata, at
+// least one valid user or service token is required. Over mTLS a service
+// token must name the service of the client certificate.
+func Authenticate(ctx context.Context) (*Caller, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	caller := new(Caller)
+	caller.Peer, _ = mtls.PeerIdentity(ctx)
+
+	if values := md.Get(ServiceTokenKey); len(values) > 0 {
+		service, err := tokens.ParseService(values[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		caller.Service = service.Name
+
+		if (caller.Peer != "" || mtls.Secure(ctx)) && caller.Peer != caller.Service {
+			return nil, status.Errorf(codes.Unauthenticated, "service token does not match peer: service=%v, peer=%v", caller.Service, caller.Peer)
+		}
+	}
+
+	if values := md.Get(AuthorizationKey); len(values) > 0 {
+		user, err := tokens.Parse(values[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		caller.User = user
+	}
+
+	if caller.Service == "" && caller.User == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	return caller, nil
+}
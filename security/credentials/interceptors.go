@@ -0,0 +1,80 @@
+package credentials
+
+import (
+	"context"
+	"go-delivery/security/tokens"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"sync"
+	"time"
+)
+
+const renewBefore = time.Minute * 5
+
+// serviceToken caches the token of the calling service and renews it
+// shortly before it expires.
+type serviceToken struct {
+	name      string
+	mu        sync.Mutex
+	raw       string
+	expiresAt time.Time
+}
+
+func (t *serviceToken) get() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.raw != "" && time.Until(t.expiresAt) > renewBefore {
+		return t.raw, nil
+	}
+
+	raw, payload, err := tokens.NewService(t.name)
+	if err != nil {
+		return "", err
+	}
+
+	t.raw = raw
+	t.expiresAt = payload.ExpiresAt
+
+	return t.raw, nil
+}
+
+func (t *serviceToken) attach(ctx context.Context) (context.Context, error) {
+	raw, err := t.get()
+	if err != nil {
+		return nil, err
+	}
+	return metadata.AppendToOutgoingContext(ctx, ServiceTokenKey, raw), nil
+}
+
+func UnaryClientInterceptor(service string) grpc.UnaryClientInterceptor {
+	token := &serviceToken{name: service}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := token.attach(ctx)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func StreamClientInterceptor(service string) grpc.StreamClientInterceptor {
+	token := &serviceToken{name: service}
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := token.attach(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// DialOptions attaches the service credentials to every call of a connection.
+func DialOptions(service string) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(service)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(service)),
+	}
+}
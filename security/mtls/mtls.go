@@ -0,0 +1,130 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"google.golang.org/grpc"
+	grpccredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"os"
+)
+
+// Config holds the PEM files used for mutual TLS, it is disabled when any
+// of them is missing.
+type Config struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// RegisterFlags binds the tls flags, their defaults come from TLS_CA_FILE,
+// TLS_CERT_FILE and TLS_KEY_FILE.
+func (c *Config) RegisterFlags() {
+	flag.StringVar(&c.CAFile, "tls_ca", os.Getenv("TLS_CA_FILE"), "tls ca bundle")
+	flag.StringVar(&c.CertFile, "tls_cert", os.Getenv("TLS_CERT_FILE"), "tls certificate")
+	flag.StringVar(&c.KeyFile, "tls_key", os.Getenv("TLS_KEY_FILE"), "tls private key")
+}
+
+func (c *Config) Enabled() bool {
+	return c.CAFile != "" && c.CertFile != "" && c.KeyFile != ""
+}
+
+func (c *Config) ServerOptions() ([]grpc.ServerOption, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	r, err := newReloader(*c)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				NextProtos:   []string{"h2"},
+			}, nil
+		},
+	}
+
+	return []grpc.ServerOption{grpc.Creds(grpccredentials.NewTLS(config))}, nil
+}
+
+func (c *Config) DialOption() (grpc.DialOption, error) {
+	if !c.Enabled() {
+		return grpc.WithInsecure(), nil
+	}
+
+	r, err := newReloader(*c)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		// the server chain is verified below against the reloadable pool.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("missing server certificate")
+			}
+
+			_, pool := r.current()
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: intermediates,
+				DNSName:       state.ServerName,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			return err
+		},
+	}
+
+	return grpc.WithTransportCredentials(grpccredentials.NewTLS(config)), nil
+}
+
+// Secure reports whether the call came over TLS, servers with mTLS enabled
+// only accept TLS connections with a verified client certificate.
+func Secure(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	_, ok = p.AuthInfo.(grpccredentials.TLSInfo)
+	return ok
+}
+
+// PeerIdentity returns the common name of the verified client certificate.
+func PeerIdentity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	info, ok := p.AuthInfo.(grpccredentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	return info.State.VerifiedChains[0][0].Subject.CommonName, true
+}
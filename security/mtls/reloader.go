@@ -0,0 +1,107 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const reloadInterval = time.Second * 10
+
+// reloader keeps the key pair and CA pool in memory and loads them again
+// when any of the files changes on disk.
+type reloader struct {
+	config    Config
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newReloader(config Config) (*reloader, error) {
+	r := &reloader{config: config}
+
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.load(modTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < reloadInterval {
+		return r.cert, r.pool
+	}
+	r.checkedAt = time.Now()
+
+	modTime, err := r.lastModified()
+	if err != nil {
+		log.Println("error on tls files check: ", err)
+		return r.cert, r.pool
+	}
+
+	if modTime.After(r.modTime) {
+		err = r.load(modTime)
+		if err != nil {
+			log.Println("error on tls reload, keeping previous certificates: ", err)
+		} else {
+			log.Println("tls certificates reloaded")
+		}
+	}
+
+	return r.cert, r.pool
+}
+
+func (r *reloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	ca, err := os.ReadFile(r.config.CAFile)
+	if err != nil {
+		return err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return errors.New("invalid ca bundle: " + r.config.CAFile)
+	}
+
+	r.cert = &cert
+	r.pool = pool
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+
+	return nil
+}
+
+func (r *reloader) lastModified() (time.Time, error) {
+	var last time.Time
+
+	for _, path := range []string{r.config.CAFile, r.config.CertFile, r.config.KeyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return last, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	return last, nil
+}
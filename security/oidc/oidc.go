@@ -0,0 +1,292 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	cacheDuration = time.Hour
+)
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Identity holds the verified claims of an ID token.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// Provider implements the authorization code flow against an OpenID
+// Connect issuer, discovery and signing keys are cached for an hour.
+type Provider struct {
+	config ProviderConfig
+	client *http.Client
+
+	mu         sync.Mutex
+	discovery  *discovery
+	keys       map[string]*rsa.PublicKey
+	discovered time.Time
+}
+
+func NewProvider(config ProviderConfig) *Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	d, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the authorization code and verifies the returned ID
+// token: signature, issuer, audience, expiration, issue time and nonce.
+func (p *Provider) Exchange(ctx context.Context, code, nonce string) (*Identity, error) {
+	d, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("client_secret", p.config.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token exchange failed: status=%d", res.StatusCode)
+	}
+
+	var tokenResponse struct {
+		IdToken string `json:"id_token"`
+	}
+
+	err = json.Unmarshal(body, &tokenResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenResponse.IdToken == "" {
+		return nil, errors.New("oidc token response without id_token")
+	}
+
+	return p.verify(ctx, tokenResponse.IdToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, raw, nonce string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the parser only checks exp and iat when present, an id token must
+	// carry both.
+	now := time.Now().Unix()
+
+	if !claims.VerifyExpiresAt(now, true) {
+		return nil, errors.New("invalid id token expiration")
+	}
+
+	if !claims.VerifyIssuedAt(now, true) {
+		return nil, errors.New("invalid id token issue time")
+	}
+
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, errors.New("invalid id token issuer")
+	}
+
+	if !hasAudience(claims["aud"], p.config.ClientID) {
+		return nil, errors.New("invalid id token audience")
+	}
+
+	if value, _ := claims["nonce"].(string); value != nonce {
+		return nil, errors.New("invalid id token nonce")
+	}
+
+	identity := new(Identity)
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+
+	if identity.Subject == "" {
+		return nil, errors.New("id token without subject")
+	}
+
+	return identity, nil
+}
+
+func hasAudience(aud interface{}, clientId string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == clientId
+	case []interface{}:
+		for _, item := range value {
+			if item == clientId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	_, keys, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	// the provider may have rotated its keys since the last discovery.
+	p.mu.Lock()
+	p.discovery = nil
+	p.mu.Unlock()
+
+	_, keys, err = p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown id token key: kid=%v", kid)
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, map[string]*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discovered) < cacheDuration {
+		return p.discovery, p.keys, nil
+	}
+
+	d := new(discovery)
+
+	err := p.getJSON(ctx, strings.TrimSuffix(p.config.Issuer, "/")+discoveryPath, d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if d.Issuer != p.config.Issuer {
+		return nil, nil, fmt.Errorf("oidc issuer mismatch: expected=%v, got=%v", p.config.Issuer, d.Issuer)
+	}
+
+	var set jwks
+
+	err = p.getJSON(ctx, d.JwksURI, &set)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, err := set.rsaKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.discovery = d
+	p.keys = keys
+	p.discovered = time.Now()
+
+	return p.discovery, p.keys, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc request failed: url=%v, status=%d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
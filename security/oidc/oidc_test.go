@@ -0,0 +1,75 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/dgrijalva/jwt-go"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProvider(ProviderConfig{Name: "test", Issuer: "https://issuer.example", ClientID: "client"})
+	p.discovery = &discovery{Issuer: "https://issuer.example"}
+	p.keys = map[string]*rsa.PublicKey{"k1": &key.PublicKey}
+	p.discovered = time.Now()
+
+	now := time.Now()
+
+	claims := func(drop ...string) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"iss":   "https://issuer.example",
+			"aud":   "client",
+			"sub":   "subject",
+			"nonce": "nonce",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Minute).Unix(),
+		}
+		for _, name := range drop {
+			delete(claims, name)
+		}
+		return claims
+	}
+
+	expired := claims()
+	expired["exp"] = now.Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		ok     bool
+	}{
+		{"valid", claims(), true},
+		{"without exp", claims("exp"), false},
+		{"without iat", claims("iat"), false},
+		{"expired", expired, false},
+		{"without subject", claims("sub"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, test.claims)
+			token.Header["kid"] = "k1"
+
+			raw, err := token.SignedString(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			identity, err := p.verify(context.Background(), raw, "nonce")
+			if (err == nil) != test.ok {
+				t.Fatalf("verify() = %v, want ok %v", err, test.ok)
+			}
+
+			if test.ok && identity.Subject != "subject" {
+				t.Fatalf("verify() subject = %v, want subject", identity.Subject)
+			}
+		})
+	}
+}
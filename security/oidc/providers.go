@@ -0,0 +1,49 @@
+package oidc
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+type ProviderConfig struct {
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+}
+
+// LoadProviders reads the JSON list of providers referenced by
+// OIDC_PROVIDERS_FILE, no provider is enabled when it is not set.
+func LoadProviders() map[string]*Provider {
+	providers := make(map[string]*Provider)
+
+	path := os.Getenv("OIDC_PROVIDERS_FILE")
+	if path == "" {
+		return providers
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Println("error on load oidc providers: ", err)
+		return providers
+	}
+
+	var configs []ProviderConfig
+
+	err = json.Unmarshal(data, &configs)
+	if err != nil {
+		log.Println("error on load oidc providers: ", err)
+		return providers
+	}
+
+	for index := range configs {
+		providers[configs[index].Name] = NewProvider(configs[index])
+	}
+
+	log.Printf("oidc providers loaded: total=%d\n", len(providers))
+
+	return providers
+}
@@ -0,0 +1,68 @@
+package passwords
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type Params struct {
+	Algorithm   string
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+	BcryptCost  int
+}
+
+var DefaultParams = Params{
+	Algorithm:   Argon2id,
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+	BcryptCost:  bcrypt.DefaultCost,
+}
+
+var (
+	loadParams sync.Once
+	params     Params
+)
+
+// CurrentParams reads the PASSWORD_* variables once, unset or invalid values
+// keep their defaults.
+func CurrentParams() Params {
+	loadParams.Do(func() {
+		params = DefaultParams
+
+		if algorithm := os.Getenv("PASSWORD_ALGORITHM"); algorithm == Argon2id || algorithm == Bcrypt {
+			params.Algorithm = algorithm
+		}
+
+		params.Memory = uint32(envInt("PASSWORD_ARGON2_MEMORY", int(params.Memory)))
+		params.Iterations = uint32(envInt("PASSWORD_ARGON2_ITERATIONS", int(params.Iterations)))
+		params.Parallelism = uint8(envInt("PASSWORD_ARGON2_PARALLELISM", int(params.Parallelism)))
+		params.BcryptCost = envInt("PASSWORD_BCRYPT_COST", params.BcryptCost)
+	})
+
+	return params
+}
+
+func envInt(key string, fallback int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		log.Printf("invalid %s, using default: %v\n", key, fallback)
+		return fallback
+	}
+
+	return value
+}
@@ -1,15 +1,135 @@
 package passwords
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
 
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+var ErrMismatch = errors.New("password mismatch")
+
+var encoding = base64.RawStdEncoding
+
+// New hashes the password with the configured algorithm, argon2id hashes use
+// the PHC string format: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>.
 func New(raw string) (string, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(raw), bcrypt.DefaultCost)
+	params := CurrentParams()
+
+	if params.Algorithm == Bcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(raw), params.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, params.SaltLength)
+	_, err := rand.Read(salt)
 	if err != nil {
 		return "", err
 	}
-	return string(hashed), nil
+
+	key := argon2.IDKey([]byte(raw), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		encoding.EncodeToString(salt), encoding.EncodeToString(key),
+	), nil
 }
 
 func OK(hashed, raw string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(raw))
+	if isBcrypt(hashed) {
+		return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(raw))
+	}
+
+	params, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(raw), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+// NeedsRehash reports whether the hash was produced by another algorithm or
+// with parameters different from the configured ones.
+func NeedsRehash(hashed string) bool {
+	params := CurrentParams()
+
+	if isBcrypt(hashed) {
+		if params.Algorithm != Bcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hashed))
+		return err != nil || cost != params.BcryptCost
+	}
+
+	if params.Algorithm != Argon2id {
+		return true
+	}
+
+	current, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		return true
+	}
+
+	return current.Memory != params.Memory ||
+		current.Iterations != params.Iterations ||
+		current.Parallelism != params.Parallelism ||
+		uint32(len(salt)) != params.SaltLength ||
+		uint32(len(key)) != params.KeyLength
+}
+
+func isBcrypt(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+func decodeArgon2id(hashed string) (*Params, []byte, []byte, error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return nil, nil, nil, errors.New("unsupported password hash format")
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+
+	params := &Params{Algorithm: Argon2id}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := encoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	key, err := encoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return params, salt, key, nil
 }
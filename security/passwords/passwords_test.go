@@ -0,0 +1,118 @@
+package passwords
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	hashed, err := New("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Fatalf("New() = %v, want a PHC argon2id hash with the default params", hashed)
+	}
+
+	other, err := New("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hashed == other {
+		t.Fatal("New() returned the same hash twice, want a random salt")
+	}
+}
+
+func TestOK(t *testing.T) {
+	hashed, err := New("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse battery staple"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hashed string
+		raw    string
+		ok     bool
+	}{
+		{"argon2id", hashed, "correct horse battery staple", true},
+		{"argon2id mismatch", hashed, "correct horse battery stapler", false},
+		{"bcrypt", string(legacy), "correct horse battery staple", true},
+		{"bcrypt mismatch", string(legacy), "Correct horse battery staple", false},
+		{"unknown format", "plain text", "plain text", false},
+		{"other argon2 version", strings.Replace(hashed, "v=19", "v=16", 1), "correct horse battery staple", false},
+		{"invalid params", strings.Replace(hashed, "$m=", "$m=!", 1), "correct horse battery staple", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := OK(test.hashed, test.raw)
+			if (err == nil) != test.ok {
+				t.Fatalf("OK() = %v, want ok %v", err, test.ok)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hashed, err := New("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse battery staple"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hashed string
+		want   bool
+	}{
+		{"current params", hashed, false},
+		{"bcrypt", string(legacy), true},
+		{"other memory", strings.Replace(hashed, "m=65536", "m=32768", 1), true},
+		{"other iterations", strings.Replace(hashed, "t=3", "t=2", 1), true},
+		{"other parallelism", strings.Replace(hashed, "p=2", "p=1", 1), true},
+		{"unknown format", "plain text", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NeedsRehash(test.hashed); got != test.want {
+				t.Fatalf("NeedsRehash() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	p := &policy{minLength: 8, maxLength: 12, breached: map[string]bool{"password1": true}}
+
+	tests := []struct {
+		raw string
+		ok  bool
+	}{
+		{"s3cure-pw", true},
+		{"short", false},
+		{"much-too-long-password", false},
+		{"ñandú-ñandú", true},
+		{"Password1", false},
+	}
+
+	for _, test := range tests {
+		err := p.Validate(test.raw)
+		if (err == nil) != test.ok {
+			t.Errorf("Validate(%q) = %v, want ok %v", test.raw, err, test.ok)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package passwords
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultMinLength = 8
+	defaultMaxLength = 100
+)
+
+type Policy interface {
+	Validate(raw string) error
+}
+
+type policy struct {
+	minLength int
+	maxLength int
+	breached  map[string]bool
+}
+
+// NewPolicy builds the password policy from PASSWORD_MIN_LENGTH,
+// PASSWORD_MAX_LENGTH and BREACHED_PASSWORDS_FILE, a file with one
+// breached password per line.
+func NewPolicy() Policy {
+	p := &policy{
+		minLength: envInt("PASSWORD_MIN_LENGTH", defaultMinLength),
+		maxLength: envInt("PASSWORD_MAX_LENGTH", defaultMaxLength),
+		breached:  make(map[string]bool),
+	}
+
+	if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+		err := p.load(path)
+		if err != nil {
+			log.Println("error on load breached passwords: ", err)
+		}
+	}
+
+	return p
+}
+
+func (p *policy) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			p.breached[strings.ToLower(line)] = true
+		}
+	}
+
+	log.Printf("breached passwords loaded: total=%d\n", len(p.breached))
+
+	return scanner.Err()
+}
+
+func (p *policy) Validate(raw string) error {
+	length := utf8.RuneCountInString(raw)
+
+	if length < p.minLength {
+		return fmt.Errorf("password too short: min=%d", p.minLength)
+	}
+
+	if length > p.maxLength {
+		return fmt.Errorf("password too long: max=%d", p.maxLength)
+	}
+
+	if p.breached[strings.ToLower(raw)] {
+		return fmt.Errorf("password found in breached passwords list")
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package permissions
+
+import (
+	"context"
+	"go-delivery/security/credentials"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OwnerFunc resolves the user owning the resource addressed by a request.
+type OwnerFunc func(ctx context.Context, req interface{}) (string, error)
+
+// LimitFunc restricts the requests a permission grants, a non nil error
+// denies the request for that permission.
+type LimitFunc func(req interface{}) error
+
+// Rule is satisfied when the calling user holds any of its permissions,
+// Owner is only needed for own scoped permissions. Limits narrow down what
+// some of the permissions grant. Calls made by internal services without a
+// user token are allowed for the listed Services only. Public rules allow
+// any authenticated caller.
+type Rule struct {
+	Permissions []Permission
+	Owner       OwnerFunc
+	Limits      map[Permission]LimitFunc
+	Services    []string
+	Public      bool
+}
+
+// Rules maps full gRPC method names to their rule, methods without a rule
+// are denied.
+type Rules map[string]Rule
+
+func UnaryServerInterceptor(cfg Config, rules Rules) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller, err := credentials.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx = credentials.NewContext(ctx, caller)
+
+		err = authorize(ctx, cfg, rules, info.FullMethod, caller, req)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(cfg Config, rules Rules) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		caller, err := credentials.Authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authorizedStream{
+			ServerStream: ss,
+			ctx:          credentials.NewContext(ss.Context(), caller),
+			cfg:          cfg,
+			rules:        rules,
+			method:       info.FullMethod,
+			caller:       caller,
+		})
+	}
+}
+
+// authorizedStream checks the first received message, which for server
+// streaming RPCs is the request itself.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx        context.Context
+	cfg        Config
+	rules      Rules
+	method     string
+	caller     *credentials.Caller
+	authorized bool
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil || s.authorized {
+		return err
+	}
+
+	err = authorize(s.ctx, s.cfg, s.rules, s.method, s.caller, m)
+	if err != nil {
+		return err
+	}
+
+	s.authorized = true
+	return nil
+}
+
+func authorize(ctx context.Context, cfg Config, rules Rules, method string, caller *credentials.Caller, req interface{}) error {
+	rule, ok := rules[method]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "permission denied, no rule: method=%v", method)
+	}
+
+	if rule.Public {
+		return nil
+	}
+
+	if caller.User != nil {
+		return Authorize(ctx, cfg, rule, caller.User.Role, caller.User.Id, caller.User.Scopes, req)
+	}
+
+	for _, service := range rule.Services {
+		if service == caller.Service {
+			return nil
+		}
+	}
+
+	return status.Errorf(codes.PermissionDenied, "permission denied: service=%v", caller.Service)
+}
+
+func Authorize(ctx context.Context, cfg Config, rule Rule, role, subject string, scopes []string, req interface{}) error {
+	var owner string
+	var resolved bool
+	var limited error
+
+	for _, perm := range rule.Permissions {
+		if !InScopes(scopes, perm) {
+			continue
+		}
+
+		if _, scope := perm.split(); scope == scopeOwn && !resolved && rule.Owner != nil {
+			var err error
+			owner, err = rule.Owner(ctx, req)
+			if err != nil {
+				return status.Error(codes.PermissionDenied, err.Error())
+			}
+			resolved = true
+		}
+
+		if !cfg.Can(role, perm, subject, owner) {
+			continue
+		}
+
+		if limit := rule.Limits[perm]; limit != nil {
+			err := limit(req)
+			if err != nil {
+				limited = err
+				continue
+			}
+		}
+
+		return nil
+	}
+
+	if limited != nil {
+		return status.Error(codes.PermissionDenied, limited.Error())
+	}
+
+	return status.Errorf(codes.PermissionDenied, "permission denied: role=%v", role)
+}
@@ -0,0 +1,116 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"go-delivery/security/credentials"
+	"go-delivery/security/tokens"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+type orderRequest struct {
+	owner string
+}
+
+func orderOwner(_ context.Context, req interface{}) (string, error) {
+	owner := req.(*orderRequest).owner
+	if owner == "" {
+		return "", errors.New("order not found")
+	}
+	return owner, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	cfg := &config{roles: map[string]map[Permission]bool{
+		"Customer": {OrdersCancelOwn: true, OrdersReadOwn: true},
+		"Admin":    {OrdersCancelAny: true},
+		"Delivery": {DeliveriesReadAny: true},
+	}}
+
+	ownedOnly := func(req interface{}) error {
+		if req.(*orderRequest).owner == "" {
+			return errors.New("only owned orders")
+		}
+		return nil
+	}
+
+	rules := Rules{
+		"/Orders/Cancel": {Permissions: []Permission{OrdersCancelAny, OrdersCancelOwn}, Owner: orderOwner},
+		"/Orders/Place":  {Services: []string{"orders"}},
+		"/Orders/List":   {Public: true},
+		"/Orders/Search": {
+			Permissions: []Permission{DeliveriesReadAny, OrdersReadOwn},
+			Owner:       orderOwner,
+			Limits:      map[Permission]LimitFunc{DeliveriesReadAny: ownedOnly},
+		},
+	}
+
+	user := func(id, role string, scopes ...string) *credentials.Caller {
+		return &credentials.Caller{User: &tokens.TokenPayload{Id: id, Role: role, Scopes: scopes}}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		caller *credentials.Caller
+		req    *orderRequest
+		ok     bool
+	}{
+		{"owner", "/Orders/Cancel", user("u1", "Customer"), &orderRequest{owner: "u1"}, true},
+		{"not the owner", "/Orders/Cancel", user("u1", "Customer"), &orderRequest{owner: "u2"}, false},
+		{"owner not found", "/Orders/Cancel", user("u1", "Customer"), &orderRequest{}, false},
+		{"any scope", "/Orders/Cancel", user("a1", "Admin"), &orderRequest{owner: "u2"}, true},
+		{"api key scope", "/Orders/Cancel", user("u1", "Customer", "orders:cancel"), &orderRequest{owner: "u1"}, true},
+		{"api key without the scope", "/Orders/Cancel", user("u1", "Customer", "orders:read"), &orderRequest{owner: "u1"}, false},
+		{"unknown role", "/Orders/Cancel", user("u1", "Guest"), &orderRequest{owner: "u1"}, false},
+		{"listed service", "/Orders/Place", &credentials.Caller{Service: "orders"}, &orderRequest{}, true},
+		{"other service", "/Orders/Place", &credentials.Caller{Service: "wallets"}, &orderRequest{}, false},
+		{"service on a user rule", "/Orders/Cancel", &credentials.Caller{Service: "orders"}, &orderRequest{owner: "u1"}, false},
+		{"user on a service rule", "/Orders/Place", user("a1", "Admin"), &orderRequest{}, false},
+		{"public", "/Orders/List", &credentials.Caller{Service: "wallets"}, &orderRequest{}, true},
+		{"no rule", "/Orders/Delete", user("a1", "Admin"), &orderRequest{}, false},
+		{"within the limit", "/Orders/Search", user("d1", "Delivery"), &orderRequest{owner: "u1"}, true},
+		{"beyond the limit", "/Orders/Search", user("d1", "Delivery"), &orderRequest{}, false},
+		{"limit of another permission", "/Orders/Search", user("u1", "Customer"), &orderRequest{owner: "u1"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := authorize(context.Background(), cfg, rules, test.method, test.caller, test.req)
+			if test.ok {
+				if err != nil {
+					t.Fatalf("authorize() = %v, want nil", err)
+				}
+				return
+			}
+
+			if status.Code(err) != codes.PermissionDenied {
+				t.Fatalf("authorize() = %v, want %v", err, codes.PermissionDenied)
+			}
+		})
+	}
+}
+
+func TestAuthorizeResolvesTheOwnerOnce(t *testing.T) {
+	cfg := &config{roles: map[string]map[Permission]bool{"Customer": {OrdersReadOwn: true}}}
+
+	var calls int
+	rule := Rule{
+		Permissions: []Permission{OrdersCancelOwn, OrdersReadOwn},
+		Owner: func(context.Context, interface{}) (string, error) {
+			calls++
+			return "u1", nil
+		},
+	}
+
+	err := Authorize(context.Background(), cfg, rule, "Customer", "u1", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("Authorize() resolved the owner %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,237 @@
+package permissions
+
+import (
+	"encoding/json"
+	"go-delivery/pb"
+	"log"
+	"os"
+	"strings"
+)
+
+// Permission follows the "resource:action:scope" format, where scope is
+// either "any" or "own". Holding the "any" scope implies the "own" one.
+type Permission string
+
+const (
+	UsersReadAny     Permission = "users:read:any"
+	UsersReadOwn     Permission = "users:read:own"
+	UsersWriteOwn    Permission = "users:write:own"
+	PoliciesReadAny  Permission = "policies:read:any"
+	PoliciesWriteAny Permission = "policies:write:any"
+
+	WalletsReadAny  Permission = "wallets:read:any"
+	WalletsReadOwn  Permission = "wallets:read:own"
+	WalletsWriteAny Permission = "wallets:write:any"
+	WalletsWriteOwn Permission = "wallets:write:own"
+
+	ProductsWriteAny Permission = "products:write:any"
+	ProductsWriteOwn Permission = "products:write:own"
+
+	StoresWriteAny Permission = "stores:write:any"
+	StoresWriteOwn Permission = "stores:write:own"
+
+	PromotionsWriteAny Permission = "promotions:write:any"
+	PromotionsWriteOwn Permission = "promotions:write:own"
+
+	OrdersCreateOwn  Permission = "orders:create:own"
+	OrdersReadAny    Permission = "orders:read:any"
+	OrdersReadOwn    Permission = "orders:read:own"
+	OrdersApproveOwn Permission = "orders:approve:own"
+	OrdersConfirmOwn Permission = "orders:confirm:own"
+	OrdersCancelAny  Permission = "orders:cancel:any"
+	OrdersCancelOwn  Permission = "orders:cancel:own"
+	OrdersDeleteAny  Permission = "orders:delete:any"
+
+	ReviewsWriteOwn    Permission = "reviews:write:own"
+	ReviewsModerateAny Permission = "reviews:moderate:any"
+
+	DisputesWriteOwn   Permission = "disputes:write:own"
+	DisputesResolveAny Permission = "disputes:resolve:any"
+
+	FavoritesWriteOwn Permission = "favorites:write:own"
+
+	DeliveriesReadAny  Permission = "deliveries:read:any"
+	DeliveriesWriteOwn Permission = "deliveries:write:own"
+
+	EarningsReadAny Permission = "earnings:read:any"
+	EarningsReadOwn Permission = "earnings:read:own"
+
+	PayoutsReadAny  Permission = "payouts:read:any"
+	PayoutsReadOwn  Permission = "payouts:read:own"
+	PayoutsWriteAny Permission = "payouts:write:any"
+	PayoutsWriteOwn Permission = "payouts:write:own"
+
+	ApiKeysReadOwn  Permission = "apikeys:read:own"
+	ApiKeysWriteOwn Permission = "apikeys:write:own"
+)
+
+const (
+	scopeAny = "any"
+	scopeOwn = "own"
+)
+
+var DefaultRoles = map[string][]Permission{
+	pb.Role_Customer.String(): {
+		UsersReadOwn, UsersWriteOwn,
+		WalletsReadOwn, WalletsWriteOwn,
+		OrdersCreateOwn, OrdersConfirmOwn, OrdersCancelOwn,
+		ReviewsWriteOwn,
+		DisputesWriteOwn,
+		FavoritesWriteOwn,
+	},
+	pb.Role_Seller.String(): {
+		UsersReadOwn, UsersWriteOwn,
+		WalletsReadOwn, WalletsWriteOwn,
+		ProductsWriteOwn,
+		StoresWriteOwn,
+		PromotionsWriteOwn,
+		OrdersReadOwn, OrdersApproveOwn,
+		PayoutsReadOwn, PayoutsWriteOwn,
+		ApiKeysReadOwn, ApiKeysWriteOwn,
+	},
+	pb.Role_Delivery.String(): {
+		UsersReadOwn, UsersWriteOwn,
+		WalletsReadOwn, WalletsWriteOwn,
+		DeliveriesReadAny, DeliveriesWriteOwn,
+		EarningsReadOwn,
+		PayoutsReadOwn, PayoutsWriteOwn,
+	},
+	pb.Role_Admin.String(): {
+		UsersReadAny, UsersWriteOwn,
+		PoliciesReadAny, PoliciesWriteAny,
+		WalletsReadAny, WalletsWriteOwn,
+		OrdersReadAny, OrdersCancelAny, OrdersDeleteAny,
+		PromotionsWriteAny,
+		ReviewsModerateAny,
+		DisputesResolveAny,
+		EarningsReadAny,
+		PayoutsReadAny, PayoutsWriteAny,
+	},
+}
+
+type Config interface {
+	// Can reports whether the role grants the permission, own scoped
+	// permissions are only granted when the subject is the owner.
+	Can(role string, perm Permission, subject, owner string) bool
+	Grants(role string) []Permission
+}
+
+type config struct {
+	roles map[string]map[Permission]bool
+}
+
+// NewConfig loads the role mapping from the JSON file referenced by
+// PERMISSIONS_FILE, falling back to DefaultRoles.
+func NewConfig() Config {
+	roles := DefaultRoles
+
+	if path := os.Getenv("PERMISSIONS_FILE"); path != "" {
+		loaded, err := load(path)
+		if err != nil {
+			log.Println("invalid permissions file, using defaults: ", err)
+		} else {
+			roles = loaded
+		}
+	}
+
+	cfg := &config{roles: make(map[string]map[Permission]bool)}
+
+	for role, perms := range roles {
+		cfg.roles[role] = make(map[Permission]bool)
+		for _, perm := range perms {
+			cfg.roles[role][perm] = true
+		}
+	}
+
+	return cfg
+}
+
+func load(path string) (map[string][]Permission, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[string][]Permission)
+
+	err = json.Unmarshal(data, &roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (cfg *config) Can(role string, perm Permission, subject, owner string) bool {
+	grants := cfg.roles[role]
+
+	resource, scope := perm.split()
+
+	if grants[Permission(resource+":"+scopeAny)] {
+		return true
+	}
+
+	if scope != scopeOwn || !grants[Permission(resource+":"+scopeOwn)] {
+		return false
+	}
+
+	return IsOwner(subject, owner)
+}
+
+func (cfg *config) Grants(role string) []Permission {
+	var perms []Permission
+	for perm := range cfg.roles[role] {
+		perms = append(perms, perm)
+	}
+	return perms
+}
+
+// InScopes reports whether the permission is covered by the scopes of an
+// API key, a scope is either a full permission or its "resource:action" part.
+// Tokens without scopes are not restricted.
+func InScopes(scopes []string, perm Permission) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+
+	resource, _ := perm.split()
+
+	for _, scope := range scopes {
+		if scope == string(perm) || scope == resource {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScopeGranted reports whether the role holds at least one permission
+// covered by the scope.
+func ScopeGranted(cfg Config, role, scope string) bool {
+	for _, perm := range cfg.Grants(role) {
+		if InScopes([]string{scope}, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOwner is the resource ownership check shared by the gateway and the
+// interceptors, an empty owner never matches.
+func IsOwner(subject, owner string) bool {
+	return owner != "" && subject == owner
+}
+
+// IsAny reports whether the permission has the "any" scope.
+func (p Permission) IsAny() bool {
+	_, scope := p.split()
+	return scope == scopeAny
+}
+
+func (p Permission) split() (string, string) {
+	index := strings.LastIndex(string(p), ":")
+	if index < 0 {
+		return string(p), ""
+	}
+	return string(p)[:index], string(p)[index+1:]
+}
@@ -0,0 +1,134 @@
+package permissions
+
+import (
+	"go-delivery/pb"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCan(t *testing.T) {
+	cfg := &config{roles: map[string]map[Permission]bool{
+		"Customer": {OrdersCancelOwn: true},
+		"Admin":    {OrdersCancelAny: true},
+	}}
+
+	tests := []struct {
+		name    string
+		role    string
+		perm    Permission
+		subject string
+		owner   string
+		want    bool
+	}{
+		{"owner", "Customer", OrdersCancelOwn, "u1", "u1", true},
+		{"not the owner", "Customer", OrdersCancelOwn, "u1", "u2", false},
+		{"unknown owner", "Customer", OrdersCancelOwn, "", "", false},
+		{"any scope not granted", "Customer", OrdersCancelAny, "u1", "u1", false},
+		{"any scope implies own", "Admin", OrdersCancelOwn, "u1", "u2", true},
+		{"any scope", "Admin", OrdersCancelAny, "u1", "", true},
+		{"other permission", "Admin", OrdersDeleteAny, "u1", "", false},
+		{"unknown role", "Guest", OrdersCancelOwn, "u1", "u1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cfg.Can(test.role, test.perm, test.subject, test.owner); got != test.want {
+				t.Fatalf("Can() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	setFile := func(path string) {
+		previous, ok := os.LookupEnv("PERMISSIONS_FILE")
+		os.Setenv("PERMISSIONS_FILE", path)
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv("PERMISSIONS_FILE", previous)
+			} else {
+				os.Unsetenv("PERMISSIONS_FILE")
+			}
+		})
+	}
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "permissions.json")
+		err := os.WriteFile(path, []byte(`{"Customer": ["orders:read:any"]}`), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setFile(path)
+
+		cfg := NewConfig()
+
+		if !cfg.Can("Customer", OrdersReadAny, "u1", "") || cfg.Can("Customer", OrdersCreateOwn, "u1", "u1") {
+			t.Fatal("NewConfig() didn't load the roles of the file")
+		}
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "permissions.json")
+		err := os.WriteFile(path, []byte(`{"Customer": `), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setFile(path)
+
+		cfg := NewConfig()
+
+		if !cfg.Can(pb.Role_Customer.String(), OrdersCreateOwn, "u1", "u1") {
+			t.Fatal("NewConfig() didn't fall back to the default roles")
+		}
+	})
+}
+
+func TestInScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		perm   Permission
+		want   bool
+	}{
+		{"no scopes", nil, OrdersReadOwn, true},
+		{"full permission", []string{"orders:read:own"}, OrdersReadOwn, true},
+		{"resource and action", []string{"orders:read"}, OrdersReadAny, true},
+		{"other scope", []string{"orders:read:own"}, OrdersReadAny, false},
+		{"other action", []string{"orders:read"}, OrdersApproveOwn, false},
+		{"resource only", []string{"orders"}, OrdersReadOwn, false},
+		{"any of the scopes", []string{"products:write", "orders:approve:own"}, OrdersApproveOwn, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := InScopes(test.scopes, test.perm); got != test.want {
+				t.Fatalf("InScopes() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScopeGranted(t *testing.T) {
+	cfg := &config{roles: map[string]map[Permission]bool{
+		"Seller": {ProductsWriteOwn: true, OrdersReadOwn: true},
+	}}
+
+	tests := []struct {
+		role  string
+		scope string
+		want  bool
+	}{
+		{"Seller", "products:write", true},
+		{"Seller", "products:write:own", true},
+		{"Seller", "products:write:any", false},
+		{"Seller", "wallets:read", false},
+		{"Customer", "products:write", false},
+	}
+
+	for _, test := range tests {
+		if got := ScopeGranted(cfg, test.role, test.scope); got != test.want {
+			t.Errorf("ScopeGranted(%v, %v) = %v, want %v", test.role, test.scope, got, test.want)
+		}
+	}
+}
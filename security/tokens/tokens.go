@@ -1,6 +1,8 @@
 package tokens
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
@@ -9,43 +11,86 @@ import (
 	"time"
 )
 
-const tokenExpiration = time.Hour * 24 * 30
+const (
+	tokenExpiration     = time.Hour * 24 * 30
+	scopedExpiration    = time.Minute * 5
+	challengeExpiration = time.Minute * 5
+	challengeAudience   = "totp"
+)
+
+// minSecretLength is the minimum length of the HMAC secrets, 32 bytes
+// matches the HS256 output size.
+const minSecretLength = 32
 
-var jwtSecretKey = os.Getenv("JWT_SECRET_KEY")
+// CheckSecrets refuses empty or short JWT_SECRET_KEY and SERVICE_SECRET_KEY,
+// services check them before serving.
+func CheckSecrets() error {
+	for _, name := range []string{"JWT_SECRET_KEY", "SERVICE_SECRET_KEY"} {
+		if len(os.Getenv(name)) < minSecretLength {
+			return fmt.Errorf("invalid secret, %v must have at least %d characters", name, minSecretLength)
+		}
+	}
+	return nil
+}
 
+func jwtSecretKey() []byte {
+	return []byte(os.Getenv("JWT_SECRET_KEY"))
+}
+
+// TokenPayload.Scopes is only set for tokens issued from API keys and
+// restricts the permissions of the role to the listed ones.
 type TokenPayload struct {
 	Id        string
 	Role      string
+	Scopes    []string
 	IssuedAt  time.Time
 	ExpiresAt time.Time
 	Issuer    string
 }
 
-func New(user *pb.User) (string, error) {
+type userClaims struct {
+	jwt.StandardClaims
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+func New(user *pb.UserProfile) (string, error) {
+	return newUserToken(user, nil, tokenExpiration)
+}
+
+// NewScoped issues the short-lived token used for requests authenticated
+// with an API key.
+func NewScoped(user *pb.UserProfile, scopes []string) (string, error) {
+	return newUserToken(user, scopes, scopedExpiration)
+}
+
+func newUserToken(user *pb.UserProfile, scopes []string, expiration time.Duration) (string, error) {
 	issuedAt := time.Now()
 
-	claims := jwt.StandardClaims{
-		Audience:  user.Role.String(),
-		ExpiresAt: issuedAt.Add(tokenExpiration).Unix(),
-		IssuedAt:  issuedAt.Unix(),
-		Issuer:    os.Getenv("APP_NAME"),
-		Subject:   user.Id,
+	claims := userClaims{
+		StandardClaims: jwt.StandardClaims{
+			Audience:  user.Role.String(),
+			ExpiresAt: issuedAt.Add(expiration).Unix(),
+			IssuedAt:  issuedAt.Unix(),
+			Issuer:    os.Getenv("APP_NAME"),
+			Subject:   user.Id,
+		},
+		Scopes: scopes,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
 
-	return token.SignedString([]byte(jwtSecretKey))
+	return token.SignedString(jwtSecretKey())
 }
 
 func getKey(token *jwt.Token) (interface{}, error) {
 	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
-	return []byte(jwtSecretKey), nil
+	return jwtSecretKey(), nil
 }
 
 func Parse(raw string) (*TokenPayload, error) {
-	claims := new(jwt.StandardClaims)
+	claims := new(userClaims)
 
 	token, err := jwt.ParseWithClaims(raw, claims, getKey)
 	if err != nil {
@@ -53,14 +98,25 @@ func Parse(raw string) (*TokenPayload, error) {
 	}
 
 	var ok bool
-	claims, ok = token.Claims.(*jwt.StandardClaims)
+	claims, ok = token.Claims.(*userClaims)
 	if !token.Valid || !ok {
 		return nil, errors.New("invalid token")
 	}
 
+	if claims.Audience == challengeAudience {
+		return nil, errors.New("invalid token, second factor required")
+	}
+
+	// only user roles are accepted, challenge, oidc state and service
+	// tokens share the secret but are not user tokens.
+	if role, ok := pb.Role_value[claims.Audience]; !ok || pb.Role(role) == pb.Role_None {
+		return nil, fmt.Errorf("invalid token, unknown role: role=%v", claims.Audience)
+	}
+
 	payload := TokenPayload{
 		Id:        claims.Subject,
 		Role:      claims.Audience,
+		Scopes:    claims.Scopes,
 		IssuedAt:  time.Unix(claims.IssuedAt, 0),
 		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
 		Issuer:    claims.Issuer,
@@ -68,3 +124,150 @@ func Parse(raw string) (*TokenPayload, error) {
 
 	return &payload, nil
 }
+
+// ChallengePayload.Id identifies the challenge, the second factor attempts
+// are counted per challenge.
+type ChallengePayload struct {
+	Id        string
+	UserId    string
+	ExpiresAt time.Time
+}
+
+// NewChallenge issues a short-lived token proving the first sign-in step,
+// it can only be exchanged for a regular token after the TOTP check.
+func NewChallenge(userId string) (string, error) {
+	id := make([]byte, 16)
+
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	issuedAt := time.Now()
+
+	claims := jwt.StandardClaims{
+		Audience:  challengeAudience,
+		ExpiresAt: issuedAt.Add(challengeExpiration).Unix(),
+		Id:        hex.EncodeToString(id),
+		IssuedAt:  issuedAt.Unix(),
+		Issuer:    os.Getenv("APP_NAME"),
+		Subject:   userId,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
+
+	return token.SignedString(jwtSecretKey())
+}
+
+func ParseChallenge(raw string) (*ChallengePayload, error) {
+	claims := new(jwt.StandardClaims)
+
+	token, err := jwt.ParseWithClaims(raw, claims, getKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.Audience != challengeAudience || claims.Id == "" {
+		return nil, errors.New("invalid challenge")
+	}
+
+	return &ChallengePayload{Id: claims.Id, UserId: claims.Subject, ExpiresAt: time.Unix(claims.ExpiresAt, 0)}, nil
+}
+
+const (
+	serviceExpiration = time.Hour
+	serviceAudience   = "service"
+)
+
+type ServicePayload struct {
+	Name      string
+	ExpiresAt time.Time
+}
+
+// NewService issues the token internal services use to identify themselves
+// to each other, it is signed with SERVICE_SECRET_KEY.
+func NewService(name string) (string, *ServicePayload, error) {
+	issuedAt := time.Now()
+	expiresAt := issuedAt.Add(serviceExpiration)
+
+	claims := jwt.StandardClaims{
+		Audience:  serviceAudience,
+		ExpiresAt: expiresAt.Unix(),
+		IssuedAt:  issuedAt.Unix(),
+		Issuer:    os.Getenv("APP_NAME"),
+		Subject:   name,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
+
+	signed, err := token.SignedString(serviceSecretKey())
+	if err != nil {
+		return "", nil, err
+	}
+
+	return signed, &ServicePayload{Name: name, ExpiresAt: expiresAt}, nil
+}
+
+func ParseService(raw string) (*ServicePayload, error) {
+	claims := new(jwt.StandardClaims)
+
+	token, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return serviceSecretKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.Audience != serviceAudience || claims.Subject == "" {
+		return nil, errors.New("invalid service token")
+	}
+
+	return &ServicePayload{Name: claims.Subject, ExpiresAt: time.Unix(claims.ExpiresAt, 0)}, nil
+}
+
+func serviceSecretKey() []byte {
+	return []byte(os.Getenv("SERVICE_SECRET_KEY"))
+}
+
+const (
+	stateExpiration = time.Minute * 10
+	stateAudience   = "oidc"
+)
+
+// NewState issues the OIDC state parameter, it binds the callback to the
+// provider and carries the nonce expected in the ID token.
+func NewState(provider, nonce string) (string, error) {
+	issuedAt := time.Now()
+
+	claims := jwt.StandardClaims{
+		Audience:  stateAudience,
+		ExpiresAt: issuedAt.Add(stateExpiration).Unix(),
+		Id:        nonce,
+		IssuedAt:  issuedAt.Unix(),
+		Issuer:    os.Getenv("APP_NAME"),
+		Subject:   provider,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
+
+	return token.SignedString(jwtSecretKey())
+}
+
+// ParseState returns the provider and nonce of an OIDC state.
+func ParseState(raw string) (string, string, error) {
+	claims := new(jwt.StandardClaims)
+
+	token, err := jwt.ParseWithClaims(raw, claims, getKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !token.Valid || claims.Audience != stateAudience {
+		return "", "", errors.New("invalid state")
+	}
+
+	return claims.Subject, claims.Id, nil
+}
@@ -0,0 +1,90 @@
+package tokens
+
+import (
+	"go-delivery/pb"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setSecrets(t *testing.T, jwtSecret, serviceSecret string) {
+	t.Helper()
+
+	for name, value := range map[string]string{"JWT_SECRET_KEY": jwtSecret, "SERVICE_SECRET_KEY": serviceSecret} {
+		previous, ok := os.LookupEnv(name)
+		os.Setenv(name, value)
+
+		name := name
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+}
+
+func TestCheckSecrets(t *testing.T) {
+	long := strings.Repeat("s", minSecretLength)
+
+	tests := []struct {
+		name          string
+		jwtSecret     string
+		serviceSecret string
+		valid         bool
+	}{
+		{"both long enough", long, long, true},
+		{"empty jwt secret", "", long, false},
+		{"short service secret", long, "short", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setSecrets(t, test.jwtSecret, test.serviceSecret)
+
+			err := CheckSecrets()
+			if (err == nil) != test.valid {
+				t.Fatalf("CheckSecrets() error = %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestParseAcceptsUserRolesOnly(t *testing.T) {
+	setSecrets(t, strings.Repeat("j", minSecretLength), strings.Repeat("s", minSecretLength))
+
+	user, err := New(&pb.UserProfile{Id: "user", Role: pb.Role_Customer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := Parse(user)
+	if err != nil {
+		t.Fatalf("Parse(user token) error = %v", err)
+	}
+	if payload.Id != "user" || payload.Role != pb.Role_Customer.String() {
+		t.Fatalf("Parse(user token) = %+v", payload)
+	}
+
+	challenge, err := NewChallenge("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := NewState("google", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	none, err := New(&pb.UserProfile{Id: "user", Role: pb.Role_None})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, raw := range map[string]string{"challenge": challenge, "oidc state": state, "no role": none} {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%v token) accepted", name)
+		}
+	}
+}
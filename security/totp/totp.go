@@ -0,0 +1,129 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	period        = 30
+	digits        = 6
+	secretSize    = 20
+	allowedSkew   = 1
+	recoveryCodes = 10
+	recoverySize  = 10
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func NewSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, account))
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(period))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+	return code(key, uint64(t.Unix()/period)), nil
+}
+
+// Validate checks the code against the time steps around t, only steps
+// after lastStep are accepted so a code can't be used twice. The step of
+// the accepted code is returned to be stored as the next lastStep.
+func Validate(secret, raw string, t time.Time, lastStep int64) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return 0, false
+	}
+
+	raw = strings.TrimSpace(raw)
+	if len(raw) != digits {
+		return 0, false
+	}
+
+	counter := t.Unix() / period
+
+	for skew := int64(-allowedSkew); skew <= allowedSkew; skew++ {
+		step := counter + skew
+		if step <= lastStep {
+			continue
+		}
+
+		expected := code(key, uint64(step))
+		if hmac.Equal([]byte(expected), []byte(raw)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+func NewRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodes)
+	for index := range codes {
+		buf := make([]byte, recoverySize)
+		_, err := rand.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		codes[index] = strings.ToLower(encoding.EncodeToString(buf)[:recoverySize])
+	}
+	return codes, nil
+}
+
+// IsRecoveryCode reports whether raw has the format of the recovery codes,
+// other inputs are never checked against their slow hashes.
+func IsRecoveryCode(raw string) bool {
+	if len(raw) != recoverySize {
+		return false
+	}
+
+	for _, char := range raw {
+		if (char < 'a' || char > 'z') && (char < '2' || char > '7') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// code implements the HOTP truncation described in RFC 4226, section 5.3.
+func code(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
@@ -0,0 +1,124 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors, base32 encoded.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		at   int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, test := range tests {
+		code, err := Code(rfcSecret, time.Unix(test.at, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != test.code {
+			t.Errorf("Code(%v) = %v, want %v", test.at, code, test.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / period
+
+	codeAt := func(offset int64) string {
+		code, err := Code(rfcSecret, now.Add(time.Duration(offset*period)*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		ok       bool
+		step     int64
+	}{
+		{"current step", codeAt(0), 0, true, step},
+		{"previous step", codeAt(-1), 0, true, step - 1},
+		{"next step", codeAt(1), 0, true, step + 1},
+		{"too old", codeAt(-2), 0, false, 0},
+		{"too far ahead", codeAt(2), 0, false, 0},
+		{"surrounding spaces", " " + codeAt(0) + " ", 0, true, step},
+		{"wrong length", codeAt(0)[:5], 0, false, 0},
+		{"replayed step", codeAt(0), step, false, 0},
+		{"step before the last one", codeAt(-1), step, false, 0},
+		{"step after the last one", codeAt(1), step, true, step + 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accepted, ok := Validate(rfcSecret, test.code, now, test.lastStep)
+			if ok != test.ok || accepted != test.step {
+				t.Fatalf("Validate() = %v, %v, want %v, %v", accepted, ok, test.step, test.ok)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidSecret(t *testing.T) {
+	if _, ok := Validate("not base32!", "123456", time.Now(), 0); ok {
+		t.Fatal("Validate() accepted a code for an invalid secret")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != secretSize {
+		t.Fatalf("NewSecret() = %v, want %d base32 bytes", secret, secretSize)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("go delivery", "customer@example.com", "SECRET")
+
+	for _, part := range []string{"otpauth://totp/go%20delivery:customer@example.com?", "secret=SECRET", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("URI() = %v, missing %v", uri, part)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != recoveryCodes {
+		t.Fatalf("NewRecoveryCodes() returned %d codes, want %d", len(codes), recoveryCodes)
+	}
+
+	for _, code := range codes {
+		if !IsRecoveryCode(code) {
+			t.Errorf("IsRecoveryCode(%v) = false for a generated code", code)
+		}
+	}
+
+	for _, raw := range []string{"123456", "", "abcdefghi", "abcdefghijk", "ABCDEFGHIJ", "abcdefgh18"} {
+		if IsRecoveryCode(raw) {
+			t.Errorf("IsRecoveryCode(%q) = true", raw)
+		}
+	}
+}
@@ -7,6 +7,10 @@ import (
 	"github.com/joho/godotenv"
 	"go-delivery/db"
 	"go-delivery/pb"
+	"go-delivery/security/mtls"
+	"go-delivery/security/oidc"
+	"go-delivery/security/permissions"
+	"go-delivery/security/tokens"
 	"go-delivery/services/accounts/service"
 	"go-delivery/services/accounts/store"
 	"go-delivery/util"
@@ -16,7 +20,10 @@ import (
 	"time"
 )
 
-var port int
+var (
+	port      int
+	tlsConfig mtls.Config
+)
 
 func init() {
 	err := godotenv.Load(util.GetEnvFile())
@@ -26,10 +33,17 @@ func init() {
 
 	flag.IntVar(&port, "port", 7500, "grpc port")
 
+	tlsConfig.RegisterFlags()
+
 	flag.Parse()
 }
 
 func main() {
+	err := tokens.CheckSecrets()
+	if err != nil {
+		log.Panicln(err)
+	}
+
 	cfg := db.NewConfig()
 
 	log.Println("loading db configs: ", cfg.URI())
@@ -40,21 +54,45 @@ func main() {
 	dbConn := db.New(ctx, cfg)
 	defer dbConn.Close(ctx)
 
-	err := dbConn.Ping(ctx)
+	err = dbConn.Ping(ctx)
 	if err != nil {
 		log.Panicln(err)
 	}
 	log.Println("database connected successfully")
 
 	usersStore := store.NewUsersStore(dbConn.DB())
-	accountsService := service.NewService(usersStore)
+	policiesStore := store.NewPoliciesStore(dbConn.DB())
+	challengesStore := store.NewChallengesStore(dbConn.DB())
+
+	err = challengesStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	apiKeysStore := store.NewApiKeysStore(dbConn.DB())
+	addressesStore := store.NewAddressesStore(dbConn.DB())
+	permissionsConfig := permissions.NewConfig()
+	providers := oidc.LoadProviders()
+	accountsService := service.NewService(usersStore, policiesStore, challengesStore, apiKeysStore, addressesStore, permissionsConfig, providers)
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		log.Panicln(err)
 	}
 
-	grpcServer := grpc.NewServer()
+	rules := service.NewRules()
+
+	serverOptions, err := tlsConfig.ServerOptions()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	serverOptions = append(serverOptions,
+		grpc.UnaryInterceptor(permissions.UnaryServerInterceptor(permissionsConfig, rules)),
+		grpc.StreamInterceptor(permissions.StreamServerInterceptor(permissionsConfig, rules)),
+	)
+
+	grpcServer := grpc.NewServer(serverOptions...)
 	pb.RegisterAccountsServiceServer(grpcServer, accountsService)
 
 	defer grpcServer.Stop()
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go-delivery/pb"
+	"go-delivery/services/accounts/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+func (s *service) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UserProfile, error) {
+	user, err := s.getUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Name = strings.TrimSpace(req.Name)
+	user.Phone = strings.TrimSpace(req.Phone)
+	user.UpdatedAt = time.Now()
+
+	err = s.usersStore.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ToProfile(), nil
+}
+
+// CreateAddress saves a delivery address, the first address of a user is
+// always the default one.
+func (s *service) CreateAddress(ctx context.Context, req *pb.Address) (*pb.Address, error) {
+	user, err := s.getUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(req.Street) == "" {
+		return nil, errors.New("address street is required")
+	}
+
+	address, err := store.AddressFromProto(req)
+	if err != nil {
+		return nil, err
+	}
+	address.UserId = user.Id.Hex()
+
+	addresses, err := s.addressesStore.GetByUser(ctx, address.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addresses) == 0 {
+		address.IsDefault = true
+	}
+
+	err = s.addressesStore.Create(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.IsDefault {
+		err = s.addressesStore.ClearDefault(ctx, address.UserId, address.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return address.ToProto(), nil
+}
+
+func (s *service) GetAddress(ctx context.Context, req *pb.GetAddressRequest) (*pb.Address, error) {
+	address, err := s.getAddress(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return address.ToProto(), nil
+}
+
+func (s *service) ListAddresses(req *pb.ListAddressesRequest, stream pb.AccountsService_ListAddressesServer) error {
+	addresses, err := s.addressesStore.GetByUser(stream.Context(), req.UserId)
+	if err != nil {
+		return err
+	}
+
+	for index := range addresses {
+		err = stream.Send(addresses[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateAddress replaces the address fields, the default flag can only be
+// moved by marking another address as default.
+func (s *service) UpdateAddress(ctx context.Context, req *pb.Address) (*pb.Address, error) {
+	address, err := s.getAddress(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(req.Street) == "" {
+		return nil, errors.New("address street is required")
+	}
+
+	address.Label = req.Label
+	address.Street = req.Street
+	address.Location = store.NewGeoPoint(req.Latitude, req.Longitude)
+	address.Instructions = req.Instructions
+	address.IsDefault = address.IsDefault || req.IsDefault
+	address.UpdatedAt = time.Now()
+
+	err = s.addressesStore.Update(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.IsDefault {
+		err = s.addressesStore.ClearDefault(ctx, address.UserId, address.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return address.ToProto(), nil
+}
+
+func (s *service) DeleteAddress(ctx context.Context, req *pb.DeleteAddressRequest) (*empty.Empty, error) {
+	address, err := s.getAddress(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.addressesStore.Delete(ctx, address.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !address.IsDefault {
+		return &empty.Empty{}, nil
+	}
+
+	addresses, err := s.addressesStore.GetByUser(ctx, address.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addresses) > 0 {
+		addresses[0].IsDefault = true
+		addresses[0].UpdatedAt = time.Now()
+
+		err = s.addressesStore.Update(ctx, addresses[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) getAddress(ctx context.Context, addressId, userId string) (*store.Address, error) {
+	id, err := primitive.ObjectIDFromHex(addressId)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.addressesStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.UserId != userId {
+		return nil, fmt.Errorf("address not found: id=%v", addressId)
+	}
+
+	return address, nil
+}
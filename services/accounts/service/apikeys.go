@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go-delivery/pb"
+	"go-delivery/security/apikeys"
+	"go-delivery/security/permissions"
+	"go-delivery/security/tokens"
+	"go-delivery/services/accounts/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"strings"
+	"time"
+)
+
+const lastUsedPrecision = time.Minute
+
+func (s *service) CreateApiKey(ctx context.Context, req *pb.CreateApiKeyRequest) (*pb.CreateApiKeyResponse, error) {
+	user, err := s.getUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if pb.Role(user.Role) != pb.Role_Seller {
+		return nil, fmt.Errorf("api keys are only available for sellers: userId=%v", req.UserId)
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("api key requires at least one scope")
+	}
+
+	role := pb.Role(user.Role).String()
+
+	for _, scope := range req.Scopes {
+		if strings.HasPrefix(scope, "apikeys:") || !permissions.ScopeGranted(s.permissions, role, scope) {
+			return nil, fmt.Errorf("invalid api key scope: scope=%v", scope)
+		}
+	}
+
+	raw, prefix, hash, err := apikeys.New()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &store.ApiKey{
+		Id:        primitive.NewObjectID(),
+		UserId:    user.Id.Hex(),
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    req.Scopes,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err = s.apiKeysStore.Create(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateApiKeyResponse{ApiKey: key.ToProto(), Key: raw}, nil
+}
+
+func (s *service) ListApiKeys(req *pb.ListApiKeysRequest, stream pb.AccountsService_ListApiKeysServer) error {
+	userId, err := primitive.ObjectIDFromHex(req.UserId)
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.apiKeysStore.GetByUser(stream.Context(), userId)
+	if err != nil {
+		return err
+	}
+
+	for index := range keys {
+		err = stream.Send(keys[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) RevokeApiKey(ctx context.Context, req *pb.RevokeApiKeyRequest) (*empty.Empty, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.apiKeysStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.UserId != req.UserId {
+		return nil, fmt.Errorf("api key not found: id=%v", req.Id)
+	}
+
+	key.Revoked = true
+	key.UpdatedAt = time.Now()
+
+	err = s.apiKeysStore.Update(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) AuthenticateApiKey(ctx context.Context, req *pb.AuthenticateApiKeyRequest) (*pb.SignInResponse, error) {
+	prefix, secret, err := apikeys.Parse(req.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.apiKeysStore.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, apikeys.ErrInvalidKey
+	}
+
+	if key.Revoked || !apikeys.OK(key.Hash, secret) {
+		return nil, apikeys.ErrInvalidKey
+	}
+
+	user, err := s.getUser(ctx, key.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(key.LastUsedAt) > lastUsedPrecision {
+		key.LastUsedAt = time.Now()
+		key.UpdatedAt = key.LastUsedAt
+
+		err = s.apiKeysStore.Update(ctx, key)
+		if err != nil {
+			log.Println("error on api key last used update: ", err)
+		}
+	}
+
+	token, err := tokens.NewScoped(user.ToProfile(), key.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SignInResponse{Token: token}, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/security/oidc"
+	"go-delivery/security/tokens"
+	"go-delivery/services/accounts/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"strings"
+	"time"
+)
+
+func (s *service) GetOIDCAuthURL(ctx context.Context, req *pb.OIDCAuthURLRequest) (*pb.OIDCAuthURLResponse, error) {
+	provider, err := s.getProvider(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := tokens.NewState(provider.Name(), nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := provider.AuthCodeURL(ctx, state, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.OIDCAuthURLResponse{Url: url, State: state}, nil
+}
+
+// OIDCSignIn redeems the authorization code, the external identity is
+// linked to the customer with the same verified email or to a new one.
+func (s *service) OIDCSignIn(ctx context.Context, req *pb.OIDCSignInRequest) (*pb.SignInResponse, error) {
+	provider, err := s.getProvider(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	name, nonce, err := tokens.ParseState(req.State)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != provider.Name() {
+		return nil, errors.New("invalid state, provider mismatch")
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.usersStore.GetByIdentity(ctx, provider.Name(), identity.Subject)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if user == nil {
+		user, err = s.linkIdentity(ctx, provider.Name(), identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.signIn(ctx, user)
+}
+
+func (s *service) linkIdentity(ctx context.Context, provider string, identity *oidc.Identity) (*store.User, error) {
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+
+	if email == "" || !identity.EmailVerified {
+		return nil, errors.New("oidc sign in requires a verified email")
+	}
+
+	user, err := s.usersStore.GetByEmail(ctx, email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	link := store.Identity{Provider: provider, Subject: identity.Subject, LinkedAt: time.Now()}
+
+	if user == nil {
+		user = &store.User{
+			Id:         primitive.NewObjectID(),
+			Email:      email,
+			Role:       int32(pb.Role_Customer),
+			Identities: []store.Identity{link},
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+
+		err = s.usersStore.Create(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}
+
+	if pb.Role(user.Role) != pb.Role_Customer {
+		return nil, fmt.Errorf("oidc sign in is only available for customers: email=%v", email)
+	}
+
+	user.Identities = append(user.Identities, link)
+	user.UpdatedAt = time.Now()
+
+	err = s.usersStore.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("identity linked: id=%v, provider=%v\n", user.Id.Hex(), provider)
+
+	return user, nil
+}
+
+func (s *service) getProvider(name string) (*oidc.Provider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("oidc provider not found: provider=%v", name)
+	}
+	return provider, nil
+}
+
+func newNonce() (string, error) {
+	nonce := make([]byte, 16)
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(nonce), nil
+}
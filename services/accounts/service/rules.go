@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/security/permissions"
+)
+
+func NewRules() permissions.Rules {
+	return permissions.Rules{
+		"/pb.AccountsService/SignUp": {
+			Public: true,
+		},
+		"/pb.AccountsService/SignIn": {
+			Public: true,
+		},
+		"/pb.AccountsService/CheckCredentials": {
+			Services: []string{credentials.ServiceAPI},
+		},
+		"/pb.AccountsService/VerifyTOTP": {
+			Public: true,
+		},
+		"/pb.AccountsService/GetUser": {
+			Permissions: []permissions.Permission{permissions.UsersReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.GetUserRequest).Id, nil
+			},
+			Services: []string{credentials.ServiceAPI, credentials.ServiceOrders},
+		},
+		"/pb.AccountsService/ListUsers": {
+			Permissions: []permissions.Permission{permissions.UsersReadAny},
+		},
+		"/pb.AccountsService/EnrollTOTP": {
+			Permissions: []permissions.Permission{permissions.UsersWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.EnrollTOTPRequest).UserId, nil
+			},
+			Services: []string{credentials.ServiceAPI},
+		},
+		"/pb.AccountsService/ConfirmTOTP": {
+			Permissions: []permissions.Permission{permissions.UsersWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ConfirmTOTPRequest).UserId, nil
+			},
+			Services: []string{credentials.ServiceAPI},
+		},
+		"/pb.AccountsService/DisableTOTP": {
+			Permissions: []permissions.Permission{permissions.UsersWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.DisableTOTPRequest).UserId, nil
+			},
+		},
+		"/pb.AccountsService/SetTOTPPolicy": {
+			Permissions: []permissions.Permission{permissions.PoliciesWriteAny},
+		},
+		"/pb.AccountsService/GetTOTPPolicy": {
+			Permissions: []permissions.Permission{permissions.PoliciesReadAny},
+		},
+		"/pb.AccountsService/CreateApiKey": {
+			Permissions: []permissions.Permission{permissions.ApiKeysWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.CreateApiKeyRequest).UserId, nil
+			},
+		},
+		"/pb.AccountsService/ListApiKeys": {
+			Permissions: []permissions.Permission{permissions.ApiKeysReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListApiKeysRequest).UserId, nil
+			},
+		},
+		"/pb.AccountsService/RevokeApiKey": {
+			Permissions: []permissions.Permission{permissions.ApiKeysWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.RevokeApiKeyRequest).UserId, nil
+			},
+		},
+		"/pb.AccountsService/AuthenticateApiKey": {
+			Services: []string{credentials.ServiceAPI},
+		},
+		"/pb.AccountsService/GetOIDCAuthURL": {
+			Services: []string{credentials.ServiceAPI},
+		},
+		"/pb.AccountsService/OIDCSignIn": {
+			Services: []string{credentials.ServiceAPI},
+		},
+
+		"/pb.AccountsService/UpdateProfile": {
+			Permissions: []permissions.Permission{permissions.UsersWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.UpdateProfileRequest).UserId, nil
+			},
+		},
+		"/pb.AccountsService/CreateAddress": {
+			Permissions: []permissions.Permission{permissions.UsersWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Address).UserId, nil
+			},
+		},
+		"/pb.AccountsService/GetAddress": {
+			Permissions: []permissions.Permission{permissions.UsersReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.GetAddressRequest).UserId, nil
+			},
+			Services: []string{credentials.ServiceOrders},
+		},
+		"/pb.AccountsService/ListAddresses": {
+			Permissions: []permissions.Permission{permissions.UsersReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListAddressesRequest).UserId, nil
+			},
+			Services: []string{credentials.ServiceOrders},
+		},
+		"/pb.AccountsService/UpdateAddress": {
+			Permissions: []permissions.Permission{permissions.UsersWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Address).UserId, nil
+			},
+		},
+		"/pb.AccountsService/DeleteAddress": {
+			Permissions: []permissions.Permission{permissions.UsersWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.DeleteAddressRequest).UserId, nil
+			},
+		},
+	}
+}
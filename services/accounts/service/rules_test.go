@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/security/permissions"
+	"testing"
+)
+
+func TestCheckCredentialsRule(t *testing.T) {
+	rule := NewRules()["/pb.AccountsService/CheckCredentials"]
+	cfg := permissions.NewConfig()
+
+	if rule.Public || len(rule.Services) != 1 || rule.Services[0] != credentials.ServiceAPI {
+		t.Fatalf("rule = %+v, want the api service only", rule)
+	}
+
+	for _, role := range []pb.Role{pb.Role_Customer, pb.Role_Seller, pb.Role_Delivery, pb.Role_Admin} {
+		err := permissions.Authorize(context.Background(), cfg, rule, role.String(), "u1", nil, &pb.CheckCredentialsRequest{})
+		if err == nil {
+			t.Errorf("Authorize(%v) = nil, want users to be denied", role)
+		}
+	}
+}
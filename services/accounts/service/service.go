@@ -4,23 +4,50 @@ import (
 	"context"
 	"errors"
 	"go-delivery/pb"
+	"go-delivery/security/oidc"
 	"go-delivery/security/passwords"
+	"go-delivery/security/permissions"
 	"go-delivery/security/tokens"
 	"go-delivery/services/accounts/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"time"
 )
 
 type service struct {
-	usersStore store.UsersStore
+	usersStore      store.UsersStore
+	policiesStore   store.PoliciesStore
+	challengesStore store.ChallengesStore
+	apiKeysStore    store.ApiKeysStore
+	addressesStore  store.AddressesStore
+	permissions     permissions.Config
+	providers       map[string]*oidc.Provider
 	pb.UnimplementedAccountsServiceServer
 }
 
-func NewService(usersStore store.UsersStore) pb.AccountsServiceServer {
-	return &service{usersStore: usersStore}
+func NewService(
+	usersStore store.UsersStore,
+	policiesStore store.PoliciesStore,
+	challengesStore store.ChallengesStore,
+	apiKeysStore store.ApiKeysStore,
+	addressesStore store.AddressesStore,
+	permissions permissions.Config,
+	providers map[string]*oidc.Provider,
+) pb.AccountsServiceServer {
+
+	return &service{
+		usersStore:      usersStore,
+		policiesStore:   policiesStore,
+		challengesStore: challengesStore,
+		apiKeysStore:    apiKeysStore,
+		addressesStore:  addressesStore,
+		permissions:     permissions,
+		providers:       providers,
+	}
 }
 
-func (s *service) SignUp(ctx context.Context, req *pb.User) (*pb.User, error) {
+func (s *service) SignUp(ctx context.Context, req *pb.User) (*pb.UserProfile, error) {
 
 	user, err := s.usersStore.GetByEmail(ctx, req.Email)
 	if err != nil && err != mongo.ErrNoDocuments {
@@ -37,23 +64,75 @@ func (s *service) SignUp(ctx context.Context, req *pb.User) (*pb.User, error) {
 	}
 
 	err = s.usersStore.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
 
-	return user.ToProto(), nil
+	return user.ToProfile(), nil
 }
 
 func (s *service) SignIn(ctx context.Context, req *pb.SignInRequest) (*pb.SignInResponse, error) {
 
-	user, err := s.usersStore.GetByEmail(ctx, req.Email)
+	user, err := s.checkCredentials(ctx, req.Email, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signIn(ctx, user)
+}
+
+// CheckCredentials is the internal credential check, it returns the profile
+// of the user without signing them in so the hash never leaves the service.
+func (s *service) CheckCredentials(ctx context.Context, req *pb.CheckCredentialsRequest) (*pb.UserProfile, error) {
+
+	user, err := s.checkCredentials(ctx, req.Email, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ToProfile(), nil
+}
+
+func (s *service) checkCredentials(ctx context.Context, email, raw string) (*store.User, error) {
+	user, err := s.usersStore.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	err = passwords.OK(user.Password, raw)
 	if err != nil {
 		return nil, err
 	}
 
-	err = passwords.OK(user.Password, req.Password)
+	if passwords.NeedsRehash(user.Password) {
+		s.rehash(ctx, user, raw)
+	}
+
+	return user, nil
+}
+
+// signIn completes a first factor sign in, it returns a TOTP challenge
+// instead of the token when a second factor is enabled or required.
+func (s *service) signIn(ctx context.Context, user *store.User) (*pb.SignInResponse, error) {
+	required, err := s.totpRequired(ctx, user.Role)
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := tokens.New(user.ToProto())
+	if user.TOTPEnabled || required {
+		challenge, err := tokens.NewChallenge(user.Id.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		return &pb.SignInResponse{
+			TotpRequired:      user.TOTPEnabled,
+			TotpSetupRequired: !user.TOTPEnabled,
+			Challenge:         challenge,
+		}, nil
+	}
+
+	token, err := tokens.New(user.ToProfile())
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +140,7 @@ func (s *service) SignIn(ctx context.Context, req *pb.SignInRequest) (*pb.SignIn
 	return &pb.SignInResponse{Token: token}, nil
 }
 
-func (s *service) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+func (s *service) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserProfile, error) {
 
 	id, err := primitive.ObjectIDFromHex(req.Id)
 	if err != nil {
@@ -73,7 +152,7 @@ func (s *service) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User
 		return nil, err
 	}
 
-	return user.ToProto(), nil
+	return user.ToProfile(), nil
 }
 
 func (s *service) ListUsers(_ *pb.ListUsersRequest, stream pb.AccountsService_ListUsersServer) error {
@@ -84,7 +163,7 @@ func (s *service) ListUsers(_ *pb.ListUsersRequest, stream pb.AccountsService_Li
 	}
 
 	for index := range users {
-		err := stream.Send(users[index].ToProto())
+		err := stream.Send(users[index].ToProfile())
 		if err != nil {
 			return err
 		}
@@ -92,3 +171,23 @@ func (s *service) ListUsers(_ *pb.ListUsersRequest, stream pb.AccountsService_Li
 
 	return nil
 }
+
+// rehash upgrades outdated password hashes on a successful sign in, failures
+// are only logged since the user is already authenticated.
+func (s *service) rehash(ctx context.Context, user *store.User, raw string) {
+	hashed, err := passwords.New(raw)
+	if err != nil {
+		log.Println("error on password rehash: ", err)
+		return
+	}
+
+	err = s.usersStore.UpdatePassword(ctx, user.Id, user.Password, hashed, time.Now())
+	if err != nil {
+		log.Println("error on password rehash: ", err)
+		return
+	}
+
+	user.Password = hashed
+
+	log.Printf("password rehashed: id=%v\n", user.Id.Hex())
+}
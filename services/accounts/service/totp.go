@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go-delivery/pb"
+	"go-delivery/security/passwords"
+	"go-delivery/security/tokens"
+	"go-delivery/security/totp"
+	"go-delivery/services/accounts/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"os"
+	"time"
+)
+
+// maxChallengeAttempts is the number of second factor codes tried with a
+// challenge, a new sign in is required after it.
+const maxChallengeAttempts = 5
+
+func (s *service) VerifyTOTP(ctx context.Context, req *pb.VerifyTOTPRequest) (*pb.SignInResponse, error) {
+	challenge, err := tokens.ParseChallenge(req.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.challengesStore.Attempt(ctx, challenge.Id, challenge.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempts > maxChallengeAttempts {
+		return nil, errors.New("too many two-factor attempts, sign in again")
+	}
+
+	user, err := s.getUser(ctx, challenge.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication not enabled: userId=%v", challenge.UserId)
+	}
+
+	err = s.verifySecondFactor(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := tokens.New(user.ToProfile())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SignInResponse{Token: token}, nil
+}
+
+func (s *service) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	user, err := s.getUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication already enabled: userId=%v", req.UserId)
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.usersStore.EnrollTOTP(ctx, user.Id, secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.EnrollTOTPResponse{
+		Secret: secret,
+		Uri:    totp.URI(os.Getenv("APP_NAME"), user.Email, secret),
+	}, nil
+}
+
+func (s *service) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	user, err := s.getUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication already enabled: userId=%v", req.UserId)
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("two-factor authentication not enrolled: userId=%v", req.UserId)
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, req.Code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	err = s.usersStore.UseTOTPStep(ctx, user.Id, step)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := totp.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	hashed := make([]string, len(codes))
+	for index := range codes {
+		hashed[index], err = passwords.New(codes[index])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.usersStore.EnableTOTP(ctx, user.Id, user.TOTPSecret, hashed, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *service) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*empty.Empty, error) {
+	user, err := s.getUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication not enabled: userId=%v", req.UserId)
+	}
+
+	required, err := s.totpRequired(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if required {
+		return nil, fmt.Errorf("two-factor authentication is required for role: role=%v", pb.Role(user.Role))
+	}
+
+	err = s.verifySecondFactor(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.usersStore.DisableTOTP(ctx, user.Id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) SetTOTPPolicy(ctx context.Context, req *pb.TOTPPolicy) (*pb.TOTPPolicy, error) {
+	if req.Role == pb.Role_None {
+		return nil, fmt.Errorf("invalid role for totp policy: role=%v", req.Role)
+	}
+
+	policy := &store.TOTPPolicy{
+		Role:      int32(req.Role),
+		Required:  req.Required,
+		UpdatedAt: time.Now(),
+	}
+
+	err := s.policiesStore.Save(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.ToProto(), nil
+}
+
+func (s *service) GetTOTPPolicy(ctx context.Context, req *pb.GetTOTPPolicyRequest) (*pb.TOTPPolicy, error) {
+	policy, err := s.policiesStore.Get(ctx, int32(req.Role))
+	if err == mongo.ErrNoDocuments {
+		return &pb.TOTPPolicy{Role: req.Role}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.ToProto(), nil
+}
+
+func (s *service) getUser(ctx context.Context, userId string) (*store.User, error) {
+	id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.usersStore.Get(ctx, id)
+}
+
+func (s *service) totpRequired(ctx context.Context, role int32) (bool, error) {
+	policy, err := s.policiesStore.Get(ctx, role)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return policy.Required, nil
+}
+
+// verifySecondFactor accepts either a TOTP code not used yet or one of the
+// unused recovery codes, both are consumed on success. Only inputs with the
+// recovery code format are checked against their slow hashes.
+func (s *service) verifySecondFactor(ctx context.Context, user *store.User, code string) error {
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		return s.usersStore.UseTOTPStep(ctx, user.Id, step)
+	}
+
+	if !totp.IsRecoveryCode(code) {
+		return errors.New("invalid two-factor code")
+	}
+
+	for index := range user.RecoveryCodes {
+		if passwords.OK(user.RecoveryCodes[index], code) != nil {
+			continue
+		}
+
+		err := s.usersStore.UseRecoveryCode(ctx, user.Id, user.RecoveryCodes[index])
+		if err != nil {
+			return err
+		}
+
+		user.RecoveryCodes = append(user.RecoveryCodes[:index], user.RecoveryCodes[index+1:]...)
+
+		return nil
+	}
+
+	return errors.New("invalid two-factor code")
+}
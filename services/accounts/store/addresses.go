@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const AddressesCollection = "addresses"
+
+type AddressesStore interface {
+	Create(ctx context.Context, address *Address) error
+	Update(ctx context.Context, address *Address) error
+	Get(ctx context.Context, id primitive.ObjectID) (*Address, error)
+	GetByUser(ctx context.Context, userId string) ([]*Address, error)
+	ClearDefault(ctx context.Context, userId string, except primitive.ObjectID) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type addressesStore struct {
+	conn *mongo.Collection
+}
+
+func NewAddressesStore(dbConn *mongo.Database) AddressesStore {
+	return &addressesStore{conn: dbConn.Collection(AddressesCollection)}
+}
+
+func (s *addressesStore) Create(ctx context.Context, address *Address) error {
+	result, err := s.conn.InsertOne(ctx, address)
+	if err != nil {
+		return err
+	}
+	log.Printf("address created: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *addressesStore) Update(ctx context.Context, address *Address) error {
+	update := bson.M{
+		"$set": bson.M{
+			"label":        address.Label,
+			"street":       address.Street,
+			"location":     address.Location,
+			"instructions": address.Instructions,
+			"is_default":   address.IsDefault,
+			"updated_at":   address.UpdatedAt,
+		},
+	}
+
+	filter := bson.M{"_id": bson.M{"$eq": address.Id}}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	log.Printf("address updated: total=%v\n", result.ModifiedCount)
+	return nil
+}
+
+func (s *addressesStore) Get(ctx context.Context, id primitive.ObjectID) (*Address, error) {
+	var address Address
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(&address)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("found address: id=%v\n", id.Hex())
+
+	return &address, nil
+}
+
+func (s *addressesStore) GetByUser(ctx context.Context, userId string) ([]*Address, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := s.conn.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var addresses []*Address
+	err = cursor.All(ctx, &addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("list addresses: total=%v\n", len(addresses))
+
+	return addresses, nil
+}
+
+// ClearDefault unsets the default flag of every address of the user except
+// the given one.
+func (s *addressesStore) ClearDefault(ctx context.Context, userId string, except primitive.ObjectID) error {
+	filter := bson.M{"user_id": userId, "_id": bson.M{"$ne": except}, "is_default": true}
+
+	result, err := s.conn.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"is_default": false}})
+	if err != nil {
+		return err
+	}
+	log.Printf("address default cleared: total=%v\n", result.ModifiedCount)
+	return nil
+}
+
+func (s *addressesStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.conn.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	log.Printf("address deleted: total=%v\n", result.DeletedCount)
+	return nil
+}
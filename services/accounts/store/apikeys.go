@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+)
+
+const ApiKeysCollection = "api_keys"
+
+type ApiKeysStore interface {
+	Create(ctx context.Context, key *ApiKey) error
+	Update(ctx context.Context, key *ApiKey) error
+	Get(ctx context.Context, id primitive.ObjectID) (*ApiKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*ApiKey, error)
+	GetByUser(ctx context.Context, userId primitive.ObjectID) ([]*ApiKey, error)
+}
+
+type apiKeysStore struct {
+	conn *mongo.Collection
+}
+
+func NewApiKeysStore(dbConn *mongo.Database) ApiKeysStore {
+	return &apiKeysStore{conn: dbConn.Collection(ApiKeysCollection)}
+}
+
+func (s *apiKeysStore) Create(ctx context.Context, key *ApiKey) error {
+	result, err := s.conn.InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+	log.Printf("api key created: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *apiKeysStore) Update(ctx context.Context, key *ApiKey) error {
+	update := bson.M{
+		"$set": bson.M{
+			"revoked":      key.Revoked,
+			"last_used_at": key.LastUsedAt,
+			"updated_at":   key.UpdatedAt,
+		},
+	}
+
+	filter := bson.M{"_id": bson.M{"$eq": key.Id}}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	log.Printf("api key updated: total=%v\n", result.ModifiedCount)
+	return nil
+}
+
+func (s *apiKeysStore) Get(ctx context.Context, id primitive.ObjectID) (*ApiKey, error) {
+	var key ApiKey
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("found api key: id=%v\n", id.Hex())
+
+	return &key, nil
+}
+
+func (s *apiKeysStore) GetByPrefix(ctx context.Context, prefix string) (*ApiKey, error) {
+	var key ApiKey
+
+	err := s.conn.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("found api key: prefix=%v\n", prefix)
+
+	return &key, nil
+}
+
+func (s *apiKeysStore) GetByUser(ctx context.Context, userId primitive.ObjectID) ([]*ApiKey, error) {
+	cursor, err := s.conn.Find(ctx, bson.M{"user_id": userId.Hex()})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []*ApiKey
+	err = cursor.All(ctx, &keys)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("list api keys: total=%v\n", len(keys))
+
+	return keys, nil
+}
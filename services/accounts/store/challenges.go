@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const ChallengesCollection = "totp_challenges"
+
+// ChallengesStore counts the second factor attempts of the sign in
+// challenges, the counters are removed once the challenge expired.
+type ChallengesStore interface {
+	Attempt(ctx context.Context, id string, expiresAt time.Time) (int32, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type challengesStore struct {
+	conn *mongo.Collection
+}
+
+func NewChallengesStore(dbConn *mongo.Database) ChallengesStore {
+	return &challengesStore{conn: dbConn.Collection(ChallengesCollection)}
+}
+
+// Attempt counts an attempt on the challenge and returns the attempts made
+// so far, this one included.
+func (s *challengesStore) Attempt(ctx context.Context, id string, expiresAt time.Time) (int32, error) {
+	update := bson.M{
+		"$inc":         bson.M{"attempts": 1},
+		"$setOnInsert": bson.M{"expires_at": expiresAt},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var challenge struct {
+		Attempts int32 `bson:"attempts"`
+	}
+
+	err := s.conn.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	return challenge.Attempts, nil
+}
+
+func (s *challengesStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("totp_challenges_expiry").SetExpireAfterSeconds(0),
+	})
+	return err
+}
@@ -7,22 +7,38 @@ import (
 )
 
 type User struct {
-	Id        primitive.ObjectID `bson:"_id"`
-	Email     string             `bson:"email"`
-	Password  string             `bson:"password"`
-	Role      int32              `bson:"role"`
-	CreatedAt time.Time          `bson:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at"`
+	Id            primitive.ObjectID `bson:"_id"`
+	Email         string             `bson:"email"`
+	Name          string             `bson:"name"`
+	Phone         string             `bson:"phone"`
+	Password      string             `bson:"password"`
+	Role          int32              `bson:"role"`
+	TOTPSecret    string             `bson:"totp_secret"`
+	TOTPEnabled   bool               `bson:"totp_enabled"`
+	TOTPLastStep  int64              `bson:"totp_last_step"`
+	RecoveryCodes []string           `bson:"recovery_codes"`
+	Identities    []Identity         `bson:"identities"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at"`
 }
 
-func (u *User) ToProto() *pb.User {
-	return &pb.User{
-		Id:        u.Id.Hex(),
-		Email:     u.Email,
-		Password:  u.Password,
-		Role:      pb.Role(u.Role),
-		CreatedAt: u.CreatedAt.Unix(),
-		UpdatedAt: u.UpdatedAt.Unix(),
+// Identity links a user to the subject of an external OIDC provider.
+type Identity struct {
+	Provider string    `bson:"provider"`
+	Subject  string    `bson:"subject"`
+	LinkedAt time.Time `bson:"linked_at"`
+}
+
+func (u *User) ToProfile() *pb.UserProfile {
+	return &pb.UserProfile{
+		Id:          u.Id.Hex(),
+		Email:       u.Email,
+		Name:        u.Name,
+		Phone:       u.Phone,
+		Role:        pb.Role(u.Role),
+		TotpEnabled: u.TOTPEnabled,
+		CreatedAt:   u.CreatedAt.Unix(),
+		UpdatedAt:   u.UpdatedAt.Unix(),
 	}
 }
 
@@ -42,3 +58,110 @@ func FromProto(user *pb.User) (*User, error) {
 
 	return &dbUser, nil
 }
+
+type TOTPPolicy struct {
+	Role      int32     `bson:"_id"`
+	Required  bool      `bson:"required"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func (p *TOTPPolicy) ToProto() *pb.TOTPPolicy {
+	return &pb.TOTPPolicy{
+		Role:      pb.Role(p.Role),
+		Required:  p.Required,
+		UpdatedAt: p.UpdatedAt.Unix(),
+	}
+}
+
+type ApiKey struct {
+	Id         primitive.ObjectID `bson:"_id"`
+	UserId     string             `bson:"user_id"`
+	Name       string             `bson:"name"`
+	Prefix     string             `bson:"prefix"`
+	Hash       string             `bson:"hash"`
+	Scopes     []string           `bson:"scopes"`
+	Revoked    bool               `bson:"revoked"`
+	LastUsedAt time.Time          `bson:"last_used_at"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
+
+func (k *ApiKey) ToProto() *pb.ApiKey {
+	var lastUsedAt int64
+	if !k.LastUsedAt.IsZero() {
+		lastUsedAt = k.LastUsedAt.Unix()
+	}
+
+	return &pb.ApiKey{
+		Id:         k.Id.Hex(),
+		UserId:     k.UserId,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		Revoked:    k.Revoked,
+		LastUsedAt: lastUsedAt,
+		CreatedAt:  k.CreatedAt.Unix(),
+		UpdatedAt:  k.UpdatedAt.Unix(),
+	}
+}
+
+// GeoPoint is stored as a GeoJSON point, coordinates are [longitude, latitude].
+type GeoPoint struct {
+	Type        string    `bson:"type"`
+	Coordinates []float64 `bson:"coordinates"`
+}
+
+func NewGeoPoint(latitude, longitude float64) GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+type Address struct {
+	Id           primitive.ObjectID `bson:"_id"`
+	UserId       string             `bson:"user_id"`
+	Label        string             `bson:"label"`
+	Street       string             `bson:"street"`
+	Location     GeoPoint           `bson:"location"`
+	Instructions string             `bson:"instructions"`
+	IsDefault    bool               `bson:"is_default"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+}
+
+func (a *Address) ToProto() *pb.Address {
+	var latitude, longitude float64
+	if len(a.Location.Coordinates) == 2 {
+		longitude, latitude = a.Location.Coordinates[0], a.Location.Coordinates[1]
+	}
+
+	return &pb.Address{
+		Id:           a.Id.Hex(),
+		UserId:       a.UserId,
+		Label:        a.Label,
+		Street:       a.Street,
+		Latitude:     latitude,
+		Longitude:    longitude,
+		Instructions: a.Instructions,
+		IsDefault:    a.IsDefault,
+		CreatedAt:    a.CreatedAt.Unix(),
+		UpdatedAt:    a.UpdatedAt.Unix(),
+	}
+}
+
+func AddressFromProto(address *pb.Address) (*Address, error) {
+	id, err := primitive.ObjectIDFromHex(address.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Address{
+		Id:           id,
+		UserId:       address.UserId,
+		Label:        address.Label,
+		Street:       address.Street,
+		Location:     NewGeoPoint(address.Latitude, address.Longitude),
+		Instructions: address.Instructions,
+		IsDefault:    address.IsDefault,
+		CreatedAt:    time.Unix(address.CreatedAt, 0),
+		UpdatedAt:    time.Unix(address.UpdatedAt, 0),
+	}, nil
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const PoliciesCollection = "totp_policies"
+
+type PoliciesStore interface {
+	Save(ctx context.Context, policy *TOTPPolicy) error
+	Get(ctx context.Context, role int32) (*TOTPPolicy, error)
+}
+
+type policiesStore struct {
+	conn *mongo.Collection
+}
+
+func NewPoliciesStore(dbConn *mongo.Database) PoliciesStore {
+	return &policiesStore{conn: dbConn.Collection(PoliciesCollection)}
+}
+
+func (s *policiesStore) Save(ctx context.Context, policy *TOTPPolicy) error {
+	update := bson.M{
+		"$set": bson.M{
+			"required":   policy.Required,
+			"updated_at": policy.UpdatedAt,
+		},
+	}
+
+	filter := bson.M{"_id": policy.Role}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	log.Printf("totp policy saved: role=%v, total=%v\n", policy.Role, result.ModifiedCount+result.UpsertedCount)
+	return nil
+}
+
+func (s *policiesStore) Get(ctx context.Context, role int32) (*TOTPPolicy, error) {
+	var policy TOTPPolicy
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": role}).Decode(&policy)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("found totp policy: role=%v\n", role)
+
+	return &policy, nil
+}
@@ -2,10 +2,13 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"log"
+	"time"
 )
 
 const UsersCollection = "users"
@@ -15,7 +18,14 @@ type UsersStore interface {
 	Update(ctx context.Context, user *User) error
 	Get(ctx context.Context, id primitive.ObjectID) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByIdentity(ctx context.Context, provider, subject string) (*User, error)
 	GetAll(ctx context.Context) ([]*User, error)
+	UpdatePassword(ctx context.Context, id primitive.ObjectID, previous, hashed string, at time.Time) error
+	UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) error
+	EnrollTOTP(ctx context.Context, id primitive.ObjectID, secret string, at time.Time) error
+	EnableTOTP(ctx context.Context, id primitive.ObjectID, secret string, recoveryCodes []string, at time.Time) error
+	DisableTOTP(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	UseRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) error
 }
 
 type store struct {
@@ -38,9 +48,15 @@ func (s *store) Create(ctx context.Context, user *User) error {
 func (s *store) Update(ctx context.Context, user *User) error {
 	update := bson.M{
 		"$set": bson.M{
-			"email":      user.Email,
-			"password":   user.Password,
-			"updated_at": user.UpdatedAt,
+			"email":          user.Email,
+			"name":           user.Name,
+			"phone":          user.Phone,
+			"password":       user.Password,
+			"totp_secret":    user.TOTPSecret,
+			"totp_enabled":   user.TOTPEnabled,
+			"recovery_codes": user.RecoveryCodes,
+			"identities":     user.Identities,
+			"updated_at":     user.UpdatedAt,
 		},
 	}
 
@@ -80,6 +96,21 @@ func (s *store) GetByEmail(ctx context.Context, email string) (*User, error) {
 	return &user, nil
 }
 
+func (s *store) GetByIdentity(ctx context.Context, provider, subject string) (*User, error) {
+	var user User
+
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+
+	err := s.conn.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("found user: provider=%v, subject=%v\n", provider, subject)
+
+	return &user, nil
+}
+
 func (s *store) GetAll(ctx context.Context) ([]*User, error) {
 
 	cursor, err := s.conn.Find(ctx, bson.D{})
@@ -98,3 +129,136 @@ func (s *store) GetAll(ctx context.Context) ([]*User, error) {
 
 	return users, nil
 }
+
+// UpdatePassword replaces the password hash only while it is still the
+// previous one, a password changed meanwhile is kept.
+func (s *store) UpdatePassword(ctx context.Context, id primitive.ObjectID, previous, hashed string, at time.Time) error {
+	filter := bson.M{"_id": id, "password": previous}
+	update := bson.M{"$set": bson.M{"password": hashed, "updated_at": at}}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("password changed: userId=%v", id.Hex())
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code, a step not
+// after the last recorded one was already used.
+func (s *store) UseTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) error {
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"totp_last_step": bson.M{"$lt": step}},
+			bson.M{"totp_last_step": bson.M{"$exists": false}},
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("two-factor code already used")
+	}
+
+	return nil
+}
+
+// EnrollTOTP stores a new secret for a user without two-factor
+// authentication enabled.
+func (s *store) EnrollTOTP(ctx context.Context, id primitive.ObjectID, secret string, at time.Time) error {
+	filter := bson.M{"_id": id, "totp_enabled": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{"totp_secret": secret, "updated_at": at}}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("two-factor authentication already enabled: userId=%v", id.Hex())
+	}
+
+	log.Printf("totp enrolled: userId=%v\n", id.Hex())
+	return nil
+}
+
+// EnableTOTP enables the enrolled secret, a secret replaced by a concurrent
+// enrollment or already enabled doesn't match.
+func (s *store) EnableTOTP(ctx context.Context, id primitive.ObjectID, secret string, recoveryCodes []string, at time.Time) error {
+	filter := bson.M{"_id": id, "totp_secret": secret, "totp_enabled": bson.M{"$ne": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"totp_enabled":   true,
+			"recovery_codes": recoveryCodes,
+			"updated_at":     at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("two-factor authentication changed, enroll again: userId=%v", id.Hex())
+	}
+
+	log.Printf("totp enabled: userId=%v\n", id.Hex())
+	return nil
+}
+
+// DisableTOTP removes the secret and the recovery codes of a user with
+// two-factor authentication enabled.
+func (s *store) DisableTOTP(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "totp_enabled": true}
+	update := bson.M{
+		"$set": bson.M{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"recovery_codes": nil,
+			"updated_at":     at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("two-factor authentication not enabled: userId=%v", id.Hex())
+	}
+
+	log.Printf("totp disabled: userId=%v\n", id.Hex())
+	return nil
+}
+
+// UseRecoveryCode removes a recovery code of the user, a code already
+// removed by a concurrent sign in can't be used again.
+func (s *store) UseRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) error {
+	filter := bson.M{"_id": id, "recovery_codes": hash}
+	update := bson.M{
+		"$pull": bson.M{"recovery_codes": hash},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("recovery code already used")
+	}
+
+	log.Printf("recovery code used: userId=%v\n", id.Hex())
+	return nil
+}
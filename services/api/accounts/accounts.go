@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/mux"
 	"go-delivery/pb"
 	"go-delivery/security/passwords"
+	"go-delivery/security/permissions"
 	"go-delivery/services/api/middlewares"
 	"go-delivery/services/api/rest"
 	"go-delivery/services/api/rest/form"
@@ -18,6 +19,7 @@ import (
 type accountsHandler struct {
 	authClient pb.AccountsServiceClient
 	validate   *validator.Validate
+	policy     passwords.Policy
 }
 
 func RegisterAccountsHandlers(authClient pb.AccountsServiceClient, m middlewares.Middlewares, router *mux.Router) {
@@ -25,21 +27,110 @@ func RegisterAccountsHandlers(authClient pb.AccountsServiceClient, m middlewares
 	h := &accountsHandler{
 		authClient: authClient,
 		validate:   validator.New(),
+		policy:     passwords.NewPolicy(),
 	}
 
 	router.Path("/signup").HandlerFunc(h.PostSignUp).Methods(http.MethodPost)
 	router.Path("/signin").HandlerFunc(h.PostSignIn).Methods(http.MethodPost)
+	router.Path("/signin/totp").HandlerFunc(h.PostVerifyTOTP).Methods(http.MethodPost)
+	router.Path("/signin/totp/enroll").HandlerFunc(h.PostChallengeEnrollTOTP).Methods(http.MethodPost)
+	router.Path("/signin/totp/confirm").HandlerFunc(h.PostChallengeConfirmTOTP).Methods(http.MethodPost)
+	router.Path("/oidc/{provider}/login").HandlerFunc(h.GetOIDCLogin).Methods(http.MethodGet)
+	router.Path("/oidc/{provider}/callback").HandlerFunc(h.GetOIDCCallback).Methods(http.MethodGet)
 	router.Path("/token").HandlerFunc(h.ValidateToken).Methods(http.MethodGet)
 
 	router.Path("/users/{id}").HandlerFunc(m.Apply(h.GetUser, middlewares.Options{
 		AuthRequired: true,
 		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersReadOwn},
 	})).Methods(http.MethodGet)
 
 	router.Path("/users").HandlerFunc(m.Apply(h.GetUsers, middlewares.Options{
 		AuthRequired: true,
-		RoleRequired: pb.Role_Admin,
+		Permissions:  []permissions.Permission{permissions.UsersReadAny},
 	})).Methods(http.MethodGet)
+
+	router.Path("/users/{id}/totp").HandlerFunc(m.Apply(h.PostEnrollTOTP, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersWriteOwn},
+	})).Methods(http.MethodPost)
+
+	router.Path("/users/{id}/totp").HandlerFunc(m.Apply(h.PutConfirmTOTP, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersWriteOwn},
+	})).Methods(http.MethodPut)
+
+	router.Path("/users/{id}/totp").HandlerFunc(m.Apply(h.DeleteTOTP, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersWriteOwn},
+	})).Methods(http.MethodDelete)
+
+	router.Path("/users/{id}/profile").HandlerFunc(m.Apply(h.PutProfile, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersWriteOwn},
+	})).Methods(http.MethodPut)
+
+	router.Path("/users/{id}/addresses").HandlerFunc(m.Apply(h.PostAddress, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersWriteOwn},
+	})).Methods(http.MethodPost)
+
+	router.Path("/users/{id}/addresses").HandlerFunc(m.Apply(h.GetAddresses, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersReadOwn},
+	})).Methods(http.MethodGet)
+
+	router.Path("/users/{id}/addresses/{address_id}").HandlerFunc(m.Apply(h.GetAddress, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersReadOwn},
+	})).Methods(http.MethodGet)
+
+	router.Path("/users/{id}/addresses/{address_id}").HandlerFunc(m.Apply(h.PutAddress, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersWriteOwn},
+	})).Methods(http.MethodPut)
+
+	router.Path("/users/{id}/addresses/{address_id}").HandlerFunc(m.Apply(h.DeleteAddress, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersWriteOwn},
+	})).Methods(http.MethodDelete)
+
+	router.Path("/sellers/{id}/api-keys").HandlerFunc(m.Apply(h.PostApiKey, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.ApiKeysWriteOwn},
+	})).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/api-keys").HandlerFunc(m.Apply(h.GetApiKeys, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.ApiKeysReadOwn},
+	})).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/api-keys/{key_id}").HandlerFunc(m.Apply(h.DeleteApiKey, middlewares.Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.ApiKeysWriteOwn},
+	})).Methods(http.MethodDelete)
+
+	router.Path("/totp/policies/{role}").HandlerFunc(m.Apply(h.GetTOTPPolicy, middlewares.Options{
+		AuthRequired: true,
+		Permissions:  []permissions.Permission{permissions.PoliciesReadAny},
+	})).Methods(http.MethodGet)
+
+	router.Path("/totp/policies/{role}").HandlerFunc(m.Apply(h.PutTOTPPolicy, middlewares.Options{
+		AuthRequired: true,
+		Permissions:  []permissions.Permission{permissions.PoliciesWriteAny},
+	})).Methods(http.MethodPut)
 }
 
 func (h *accountsHandler) PostSignUp(w http.ResponseWriter, r *http.Request) {
@@ -65,6 +156,12 @@ func (h *accountsHandler) PostSignUp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = h.policy.Validate(input.Password)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	user := new(pb.User)
 
 	user.Password, err = passwords.New(input.Password)
@@ -79,13 +176,13 @@ func (h *accountsHandler) PostSignUp(w http.ResponseWriter, r *http.Request) {
 	user.CreatedAt = time.Now().Unix()
 	user.UpdatedAt = user.CreatedAt
 
-	user, err = h.authClient.SignUp(r.Context(), user)
+	profile, err := h.authClient.SignUp(r.Context(), user)
 	if err != nil {
 		rest.WriteError(w, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	rest.WriteAsJson(w, http.StatusCreated, form.FromUser(user))
+	rest.WriteAsJson(w, http.StatusCreated, form.FromUser(profile))
 }
 
 func (h *accountsHandler) PostSignIn(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,201 @@
+package accounts
+
+import (
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"time"
+)
+
+func (h *accountsHandler) PutProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.ProfileInput)
+
+	err = h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := h.authClient.UpdateProfile(r.Context(), &pb.UpdateProfileRequest{
+		UserId: id.Hex(),
+		Name:   input.Name,
+		Phone:  input.Phone,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromUser(user))
+}
+
+func (h *accountsHandler) PostAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.AddressInput)
+
+	err = h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	address, err := h.authClient.CreateAddress(r.Context(), &pb.Address{
+		Id:           primitive.NewObjectID().Hex(),
+		UserId:       id.Hex(),
+		Label:        input.Label,
+		Street:       input.Street,
+		Latitude:     input.Latitude,
+		Longitude:    input.Longitude,
+		Instructions: input.Instructions,
+		IsDefault:    input.IsDefault,
+		CreatedAt:    time.Now().Unix(),
+		UpdatedAt:    time.Now().Unix(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromAddress(address))
+}
+
+func (h *accountsHandler) GetAddresses(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.authClient.ListAddresses(r.Context(), &pb.ListAddressesRequest{UserId: id.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var addresses []*form.Address
+
+	for {
+		address, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		addresses = append(addresses, form.FromAddress(address))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, addresses)
+}
+
+func (h *accountsHandler) GetAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	addressId, err := primitive.ObjectIDFromHex(vars["address_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	address, err := h.authClient.GetAddress(r.Context(), &pb.GetAddressRequest{
+		Id:     addressId.Hex(),
+		UserId: id.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromAddress(address))
+}
+
+func (h *accountsHandler) PutAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	addressId, err := primitive.ObjectIDFromHex(vars["address_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.AddressInput)
+
+	err = h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	address, err := h.authClient.UpdateAddress(r.Context(), &pb.Address{
+		Id:           addressId.Hex(),
+		UserId:       id.Hex(),
+		Label:        input.Label,
+		Street:       input.Street,
+		Latitude:     input.Latitude,
+		Longitude:    input.Longitude,
+		Instructions: input.Instructions,
+		IsDefault:    input.IsDefault,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromAddress(address))
+}
+
+func (h *accountsHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	addressId, err := primitive.ObjectIDFromHex(vars["address_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.authClient.DeleteAddress(r.Context(), &pb.DeleteAddressRequest{
+		Id:     addressId.Hex(),
+		UserId: id.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
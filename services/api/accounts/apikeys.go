@@ -0,0 +1,101 @@
+package accounts
+
+import (
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func (h *accountsHandler) PostApiKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.ApiKeyInput)
+
+	err = h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.authClient.CreateApiKey(r.Context(), &pb.CreateApiKeyRequest{
+		UserId: id.Hex(),
+		Name:   input.Name,
+		Scopes: input.Scopes,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, &form.CreatedApiKey{
+		ApiKey: form.FromApiKey(res.ApiKey),
+		Key:    res.Key,
+	})
+}
+
+func (h *accountsHandler) GetApiKeys(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.authClient.ListApiKeys(r.Context(), &pb.ListApiKeysRequest{UserId: id.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var keys []*form.ApiKey
+
+	for {
+		key, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		keys = append(keys, form.FromApiKey(key))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, keys)
+}
+
+func (h *accountsHandler) DeleteApiKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	keyId, err := primitive.ObjectIDFromHex(vars["key_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.authClient.RevokeApiKey(r.Context(), &pb.RevokeApiKeyRequest{
+		Id:     keyId.Hex(),
+		UserId: id.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,85 @@
+package accounts
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/services/api/rest"
+	"net/http"
+	"path"
+	"time"
+)
+
+const (
+	stateCookie    = "oidc_state"
+	stateCookieAge = 10 * time.Minute
+)
+
+// GetOIDCLogin redirects to the provider and keeps the state in an HttpOnly
+// cookie scoped to the provider callback.
+func (h *accountsHandler) GetOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	res, err := h.authClient.GetOIDCAuthURL(r.Context(), &pb.OIDCAuthURLRequest{Provider: vars["provider"]})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    res.State,
+		Path:     path.Dir(r.URL.Path),
+		MaxAge:   int(stateCookieAge.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, res.Url, http.StatusFound)
+}
+
+// GetOIDCCallback only accepts the state of the browser that started the
+// sign in, the state cookie is cleared whatever the outcome.
+func (h *accountsHandler) GetOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Path:     path.Dir(r.URL.Path),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if reason := query.Get("error"); reason != "" {
+		rest.WriteError(w, http.StatusUnauthorized, errors.New(reason))
+		return
+	}
+
+	if query.Get("code") == "" || query.Get("state") == "" {
+		rest.WriteError(w, http.StatusBadRequest, errors.New("code and state are required"))
+		return
+	}
+
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+		rest.WriteError(w, http.StatusUnauthorized, errors.New("invalid state, sign in was not started by this browser"))
+		return
+	}
+
+	res, err := h.authClient.OIDCSignIn(r.Context(), &pb.OIDCSignInRequest{
+		Provider: vars["provider"],
+		Code:     query.Get("code"),
+		State:    query.Get("state"),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, res)
+}
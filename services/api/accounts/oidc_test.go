@@ -0,0 +1,178 @@
+package accounts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/oidc"
+	"go-delivery/security/tokens"
+	"go-delivery/tools/mockoidc/provider"
+	"google.golang.org/grpc"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// oidcClient signs in like the accounts service, without the users store.
+type oidcClient struct {
+	pb.AccountsServiceClient
+	provider *oidc.Provider
+}
+
+func (c *oidcClient) GetOIDCAuthURL(ctx context.Context, _ *pb.OIDCAuthURLRequest, _ ...grpc.CallOption) (*pb.OIDCAuthURLResponse, error) {
+	random := make([]byte, 16)
+	_, err := rand.Read(random)
+	if err != nil {
+		return nil, err
+	}
+	nonce := hex.EncodeToString(random)
+
+	state, err := tokens.NewState(c.provider.Name(), nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := c.provider.AuthCodeURL(ctx, state, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.OIDCAuthURLResponse{Url: url, State: state}, nil
+}
+
+func (c *oidcClient) OIDCSignIn(ctx context.Context, req *pb.OIDCSignInRequest, _ ...grpc.CallOption) (*pb.SignInResponse, error) {
+	_, nonce, err := tokens.ParseState(req.State)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := c.provider.Exchange(ctx, req.Code, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SignInResponse{Token: identity.Subject}, nil
+}
+
+type oidcFlow struct {
+	t      *testing.T
+	api    *httptest.Server
+	client *http.Client
+}
+
+func newOIDCFlow(t *testing.T) *oidcFlow {
+	previous, ok := os.LookupEnv("JWT_SECRET_KEY")
+	os.Setenv("JWT_SECRET_KEY", "test-secret-key-of-at-least-32-bytes")
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("JWT_SECRET_KEY", previous)
+		} else {
+			os.Unsetenv("JWT_SECRET_KEY")
+		}
+	})
+
+	mock, err := provider.New(provider.Options{ClientId: "go-delivery", ClientSecret: "secret", Email: "customer@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := httptest.NewServer(mock)
+	t.Cleanup(issuer.Close)
+	mock.Issuer = issuer.URL
+
+	router := mux.NewRouter()
+	api := httptest.NewServer(router)
+	t.Cleanup(api.Close)
+
+	h := &accountsHandler{authClient: &oidcClient{provider: oidc.NewProvider(oidc.ProviderConfig{
+		Name:         "mock",
+		Issuer:       issuer.URL,
+		ClientID:     "go-delivery",
+		ClientSecret: "secret",
+		RedirectURL:  api.URL + "/oidc/mock/callback",
+		Scopes:       []string{"openid", "email"},
+	})}}
+
+	router.Path("/oidc/{provider}/login").HandlerFunc(h.GetOIDCLogin).Methods(http.MethodGet)
+	router.Path("/oidc/{provider}/callback").HandlerFunc(h.GetOIDCCallback).Methods(http.MethodGet)
+
+	return &oidcFlow{
+		t:   t,
+		api: api,
+		client: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}},
+	}
+}
+
+// login starts a sign in and returns the state cookie of the browser and
+// the callback URL the provider redirects to.
+func (f *oidcFlow) login() (*http.Cookie, string) {
+	res := f.get(f.api.URL+"/oidc/mock/login", nil)
+
+	var cookie *http.Cookie
+	for _, candidate := range res.Cookies() {
+		if candidate.Name == stateCookie {
+			cookie = candidate
+		}
+	}
+
+	if cookie == nil || !cookie.HttpOnly || cookie.Path != "/oidc/mock" {
+		f.t.Fatalf("login state cookie = %+v, want an HttpOnly cookie scoped to the provider", cookie)
+	}
+
+	res = f.get(res.Header.Get("Location"), nil)
+
+	return cookie, res.Header.Get("Location")
+}
+
+func (f *oidcFlow) get(url string, cookie *http.Cookie) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	if cookie != nil {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	res.Body.Close()
+
+	return res
+}
+
+func TestOIDCCallbackRequiresTheBrowserState(t *testing.T) {
+	flow := newOIDCFlow(t)
+
+	t.Run("same browser", func(t *testing.T) {
+		cookie, callback := flow.login()
+
+		if res := flow.get(callback, cookie); res.StatusCode != http.StatusOK {
+			t.Fatalf("callback status = %v, want %v", res.StatusCode, http.StatusOK)
+		}
+	})
+
+	t.Run("without state cookie", func(t *testing.T) {
+		_, callback := flow.login()
+
+		if res := flow.get(callback, nil); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("callback status = %v, want %v", res.StatusCode, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("state of another browser", func(t *testing.T) {
+		victim, _ := flow.login()
+		_, attackerCallback := flow.login()
+
+		if res := flow.get(attackerCallback, victim); res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("callback status = %v, want %v", res.StatusCode, http.StatusUnauthorized)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package accounts
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/tokens"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (h *accountsHandler) PostVerifyTOTP(w http.ResponseWriter, r *http.Request) {
+	input := new(form.TOTPChallengeInput)
+
+	err := h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.authClient.VerifyTOTP(r.Context(), &pb.VerifyTOTPRequest{
+		Challenge: input.Challenge,
+		Code:      input.Code,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, res)
+}
+
+func (h *accountsHandler) PostChallengeEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	input := new(form.TOTPChallengeInput)
+
+	err := h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	challenge, err := tokens.ParseChallenge(input.Challenge)
+	if err != nil {
+		rest.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	res, err := h.authClient.EnrollTOTP(r.Context(), &pb.EnrollTOTPRequest{UserId: challenge.UserId})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromTOTPEnrollment(res))
+}
+
+func (h *accountsHandler) PostChallengeConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	input := new(form.TOTPChallengeInput)
+
+	err := h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	challenge, err := tokens.ParseChallenge(input.Challenge)
+	if err != nil {
+		rest.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	res, err := h.authClient.ConfirmTOTP(r.Context(), &pb.ConfirmTOTPRequest{
+		UserId: challenge.UserId,
+		Code:   input.Code,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, res)
+}
+
+func (h *accountsHandler) PostEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.authClient.EnrollTOTP(r.Context(), &pb.EnrollTOTPRequest{UserId: id.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromTOTPEnrollment(res))
+}
+
+func (h *accountsHandler) PutConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.TOTPCodeInput)
+
+	err = h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.authClient.ConfirmTOTP(r.Context(), &pb.ConfirmTOTPRequest{
+		UserId: id.Hex(),
+		Code:   input.Code,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, res)
+}
+
+func (h *accountsHandler) DeleteTOTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.TOTPCodeInput)
+
+	err = h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.authClient.DisableTOTP(r.Context(), &pb.DisableTOTPRequest{
+		UserId: id.Hex(),
+		Code:   input.Code,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
+
+func (h *accountsHandler) GetTOTPPolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	role, err := strconv.Atoi(vars["role"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	policy, err := h.authClient.GetTOTPPolicy(r.Context(), &pb.GetTOTPPolicyRequest{Role: pb.Role(role)})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromTOTPPolicy(policy))
+}
+
+func (h *accountsHandler) PutTOTPPolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	role, err := strconv.Atoi(vars["role"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.TOTPPolicyInput)
+
+	err = h.readInput(r, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	policy, err := h.authClient.SetTOTPPolicy(r.Context(), &pb.TOTPPolicy{
+		Role:     pb.Role(role),
+		Required: input.Required,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromTOTPPolicy(policy))
+}
+
+func (h *accountsHandler) readInput(r *http.Request, input interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		return err
+	}
+
+	if clearer, ok := input.(interface{ Clear() }); ok {
+		clearer.Clear()
+	}
+
+	return h.validate.Struct(input)
+}
@@ -6,7 +6,12 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"go-delivery/media"
 	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/security/mtls"
+	"go-delivery/security/permissions"
+	"go-delivery/security/tokens"
 	"go-delivery/services/api/accounts"
 	"go-delivery/services/api/middlewares"
 	"go-delivery/services/api/orders"
@@ -17,10 +22,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 )
 
 var (
 	port         int
+	tlsConfig    mtls.Config
+	mediaConfig  media.Config
 	accountsAddr string
 	walletsAddr  string
 	sellersAddr  string
@@ -39,11 +47,26 @@ func init() {
 	flag.StringVar(&sellersAddr, "sellers_addr", "localhost:7502", "sellers service address")
 	flag.StringVar(&ordersAddr, "orders_addr", "localhost:7503", "orders service address")
 
+	tlsConfig.RegisterFlags()
+	mediaConfig.RegisterFlags()
+
 	flag.Parse()
 }
 
 func main() {
-	accountsConn, err := grpc.Dial(accountsAddr, grpc.WithInsecure())
+	err := tokens.CheckSecrets()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	tlsOption, err := tlsConfig.DialOption()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	dialOptions := append(credentials.DialOptions(credentials.ServiceAPI), tlsOption)
+
+	accountsConn, err := grpc.Dial(accountsAddr, dialOptions...)
 	if err != nil {
 		log.Panicln(err)
 	}
@@ -52,11 +75,11 @@ func main() {
 	router := mux.NewRouter().StrictSlash(true)
 
 	accountsClient := pb.NewAccountsServiceClient(accountsConn)
-	middlewareGroup := middlewares.New(accountsClient)
+	middlewareGroup := middlewares.New(accountsClient, permissions.NewConfig())
 
 	accounts.RegisterAccountsHandlers(accountsClient, middlewareGroup, router)
 
-	walletsConn, err := grpc.Dial(walletsAddr, grpc.WithInsecure())
+	walletsConn, err := grpc.Dial(walletsAddr, dialOptions...)
 	if err != nil {
 		log.Panicln(err)
 	}
@@ -65,23 +88,39 @@ func main() {
 	walletsClient := pb.NewWalletsServiceClient(walletsConn)
 	wallets.RegisterWalletsHandlers(walletsClient, middlewareGroup, router)
 
-	sellersConn, err := grpc.Dial(sellersAddr, grpc.WithInsecure())
+	sellersConn, err := grpc.Dial(sellersAddr, dialOptions...)
 	if err != nil {
 		log.Panicln(err)
 	}
 	defer util.HandleClose(sellersConn)
 
 	productsClient := pb.NewProductsServiceClient(sellersConn)
-	sellers.RegisterSellersHandlers(productsClient, middlewareGroup, router)
+	storesClient := pb.NewStoresServiceClient(sellersConn)
+
+	blobStore, err := mediaConfig.NewBlobStore()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	sellers.RegisterSellersHandlers(productsClient, storesClient, blobStore, mediaConfig.MaxSize, middlewareGroup, router)
+
+	if strings.HasPrefix(mediaConfig.BaseURL, "/") {
+		prefix := strings.TrimSuffix(mediaConfig.BaseURL, "/") + "/"
+		router.PathPrefix(prefix).Handler(http.StripPrefix(prefix, mediaConfig.Handler())).Methods(http.MethodGet)
+	}
 
-	ordersConn, err := grpc.Dial(ordersAddr, grpc.WithInsecure())
+	ordersConn, err := grpc.Dial(ordersAddr, dialOptions...)
 	if err != nil {
 		log.Panicln(err)
 	}
 	defer util.HandleClose(ordersConn)
 
 	ordersClient := pb.NewOrdersServiceClient(ordersConn)
-	orders.RegisterOrdersHandlers(ordersClient, middlewareGroup, router)
+	promotionsClient := pb.NewPromotionsServiceClient(ordersConn)
+	reviewsClient := pb.NewReviewsServiceClient(ordersConn)
+	disputesClient := pb.NewDisputesServiceClient(ordersConn)
+	favoritesClient := pb.NewFavoritesServiceClient(ordersConn)
+	orders.RegisterOrdersHandlers(ordersClient, promotionsClient, reviewsClient, disputesClient, favoritesClient, middlewareGroup, router)
 
 	headers := handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Requested-with"})
 	methods := handlers.AllowedMethods([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete})
@@ -3,57 +3,84 @@ package middlewares
 import (
 	"github.com/gorilla/mux"
 	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/security/permissions"
 	"go-delivery/services/api/rest"
 	"log"
 	"net/http"
+	"strings"
 )
 
+const apiKeyPrefix = "ApiKey "
+
 type Middlewares interface {
 	EnsureAuthentication(next http.HandlerFunc) http.HandlerFunc
 	EnsureUser(next http.HandlerFunc) http.HandlerFunc
+	EnsurePermissions(next http.HandlerFunc, perms []permissions.Permission) http.HandlerFunc
 	Apply(next http.HandlerFunc, opt Options) http.HandlerFunc
 }
 
 type impl struct {
 	accountsService pb.AccountsServiceClient
+	permissions     permissions.Config
 }
 
-func New(accountsService pb.AccountsServiceClient) Middlewares {
-	return &impl{accountsService: accountsService}
+func New(accountsService pb.AccountsServiceClient, permissions permissions.Config) Middlewares {
+	return &impl{accountsService: accountsService, permissions: permissions}
 }
 
+// Options.Permissions is satisfied when the caller holds any of the listed
+// permissions, the owner of own scoped permissions is the {id} route variable.
+// Routes without permissions only accept the {id} user.
 type Options struct {
 	AuthRequired bool
 	UserRequired bool
-	RoleRequired pb.Role
+	Permissions  []permissions.Permission
 }
 
 func (i *impl) Apply(next http.HandlerFunc, opt Options) http.HandlerFunc {
 	if opt.AuthRequired {
-		next = i.EnsureAuthentication(next)
+		next = i.propagateToken(next)
+	}
+
+	if len(opt.Permissions) > 0 {
+		next = i.EnsurePermissions(next, opt.Permissions)
+	} else if opt.UserRequired {
+		next = i.ensureOwner(next)
 	}
 
 	if opt.UserRequired {
 		next = i.EnsureUser(next)
 	}
 
-	switch opt.RoleRequired {
-	case pb.Role_Customer:
-		next = i.OnlyCustomer(next)
-	case pb.Role_Seller:
-		next = i.OnlySeller(next)
-	case pb.Role_Delivery:
-		next = i.OnlyDelivery(next)
-	case pb.Role_Admin:
-		next = i.OnlyAdmin(next)
+	if opt.AuthRequired {
+		next = i.EnsureAuthentication(next)
 	}
 
 	return next
 }
 
+// EnsureAuthentication accepts a bearer token or an API key sent as
+// "Authorization: ApiKey <key>", API keys are exchanged for a short-lived
+// scoped token which replaces the header for the next handlers.
 func (i *impl) EnsureAuthentication(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
+		header := strings.TrimSpace(r.Header.Get("Authorization"))
+
+		if strings.HasPrefix(header, apiKeyPrefix) {
+			key := strings.TrimSpace(strings.TrimPrefix(header, apiKeyPrefix))
+
+			res, err := i.accountsService.AuthenticateApiKey(r.Context(), &pb.AuthenticateApiKeyRequest{Key: key})
+			if err != nil {
+				log.Println("invalid api key: ", err.Error())
+				WriteUnauthorized(w)
+				return
+			}
+
+			r.Header.Set("Authorization", res.Token)
+		}
+
 		_, err := rest.GetToken(r)
 		if err != nil {
 			log.Println("invalid token: ", err.Error())
@@ -66,38 +93,16 @@ func (i *impl) EnsureAuthentication(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (i *impl) EnsureUser(next http.HandlerFunc) http.HandlerFunc {
+// propagateToken forwards the user token to the gRPC calls of the handler.
+func (i *impl) propagateToken(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		raw := strings.TrimSpace(r.Header.Get("Authorization"))
 
-		token, err := rest.GetToken(r)
-		if err != nil {
-			log.Println("invalid token: ", err.Error())
-			WriteUnauthorized(w)
-			return
-		}
-
-		vars := mux.Vars(r)
-		if vars["id"] != token.Id {
-			WriteUnauthorized(w)
-			return
-		}
-
-		user, err := i.accountsService.GetUser(r.Context(), &pb.GetUserRequest{Id: token.Id})
-		if err != nil {
-			WriteUnauthorized(w)
-			return
-		}
-
-		if user.Role.String() != token.Role {
-			WriteUnauthorized(w)
-			return
-		}
-
-		next(w, r)
+		next(w, r.WithContext(credentials.WithUserToken(r.Context(), raw)))
 	}
 }
 
-func (i *impl) OnlyAdmin(next http.HandlerFunc) http.HandlerFunc {
+func (i *impl) EnsureUser(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		token, err := rest.GetToken(r)
@@ -107,30 +112,13 @@ func (i *impl) OnlyAdmin(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		role := pb.Role_value[token.Role]
-
-		if role != int32(pb.Role_Admin) {
-			WriteUnauthorized(w)
-			return
-		}
-
-		next(w, r)
-	}
-}
-
-func (i *impl) OnlySeller(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-
-		token, err := rest.GetToken(r)
+		user, err := i.accountsService.GetUser(r.Context(), &pb.GetUserRequest{Id: token.Id})
 		if err != nil {
-			log.Println("invalid token: ", err.Error())
 			WriteUnauthorized(w)
 			return
 		}
 
-		role := pb.Role_value[token.Role]
-
-		if role != int32(pb.Role_Seller) {
+		if user.Role.String() != token.Role {
 			WriteUnauthorized(w)
 			return
 		}
@@ -139,7 +127,8 @@ func (i *impl) OnlySeller(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (i *impl) OnlyDelivery(next http.HandlerFunc) http.HandlerFunc {
+// ensureOwner requires the {id} route variable to be the caller.
+func (i *impl) ensureOwner(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		token, err := rest.GetToken(r)
@@ -149,9 +138,7 @@ func (i *impl) OnlyDelivery(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		role := pb.Role_value[token.Role]
-
-		if role != int32(pb.Role_Delivery) {
+		if !permissions.IsOwner(token.Id, mux.Vars(r)["id"]) {
 			WriteUnauthorized(w)
 			return
 		}
@@ -160,7 +147,11 @@ func (i *impl) OnlyDelivery(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (i *impl) OnlyCustomer(next http.HandlerFunc) http.HandlerFunc {
+// EnsurePermissions checks the permissions against the {id} route variable.
+// Own scoped permissions address the resources of the {id} user, an any
+// scoped one held by an admin covers them too. On routes requiring an any
+// scoped permission {id} names the caller, it must be the caller.
+func (i *impl) EnsurePermissions(next http.HandlerFunc, perms []permissions.Permission) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		token, err := rest.GetToken(r)
@@ -170,14 +161,24 @@ func (i *impl) OnlyCustomer(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		role := pb.Role_value[token.Role]
+		owner := mux.Vars(r)["id"]
 
-		if role != int32(pb.Role_Customer) {
-			WriteUnauthorized(w)
-			return
+		for _, perm := range perms {
+			if !permissions.InScopes(token.Scopes, perm) {
+				continue
+			}
+
+			if perm.IsAny() && owner != "" && owner != token.Id {
+				continue
+			}
+
+			if i.permissions.Can(token.Role, perm, token.Id, owner) {
+				next(w, r)
+				return
+			}
 		}
 
-		next(w, r)
+		WriteUnauthorized(w)
 	}
 }
 
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/security/tokens"
+	"google.golang.org/grpc"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// accountsClient knows the users of the tokens signed by the test.
+type accountsClient struct {
+	pb.AccountsServiceClient
+	users map[string]pb.Role
+}
+
+func (c *accountsClient) GetUser(_ context.Context, req *pb.GetUserRequest, _ ...grpc.CallOption) (*pb.UserProfile, error) {
+	return &pb.UserProfile{Id: req.Id, Role: c.users[req.Id]}, nil
+}
+
+func TestApplyChecksTheRouteOwner(t *testing.T) {
+	previous, ok := os.LookupEnv("JWT_SECRET_KEY")
+	os.Setenv("JWT_SECRET_KEY", "test-secret-key-of-at-least-32-bytes")
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("JWT_SECRET_KEY", previous)
+		} else {
+			os.Unsetenv("JWT_SECRET_KEY")
+		}
+	})
+
+	users := map[string]pb.Role{"customer": pb.Role_Customer, "admin": pb.Role_Admin, "other-admin": pb.Role_Admin}
+	m := New(&accountsClient{users: users}, permissions.NewConfig())
+
+	ok200 := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	router := mux.NewRouter()
+	router.Path("/users/{id}").HandlerFunc(m.Apply(ok200, Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.UsersReadOwn},
+	}))
+	router.Path("/orders/admins/{id}").HandlerFunc(m.Apply(ok200, Options{
+		AuthRequired: true,
+		UserRequired: true,
+		Permissions:  []permissions.Permission{permissions.OrdersReadAny},
+	}))
+	router.Path("/profiles/{id}").HandlerFunc(m.Apply(ok200, Options{
+		AuthRequired: true,
+		UserRequired: true,
+	}))
+
+	tests := []struct {
+		name   string
+		caller string
+		path   string
+		status int
+	}{
+		{"own route, owner", "customer", "/users/customer", http.StatusOK},
+		{"own route, foreign id", "customer", "/users/admin", http.StatusUnauthorized},
+		{"own route, any scope", "admin", "/users/customer", http.StatusOK},
+		{"any route, caller id", "admin", "/orders/admins/admin", http.StatusOK},
+		{"any route, foreign id", "admin", "/orders/admins/other-admin", http.StatusUnauthorized},
+		{"any route, not granted", "customer", "/orders/admins/customer", http.StatusUnauthorized},
+		{"route without permissions, owner", "customer", "/profiles/customer", http.StatusOK},
+		{"route without permissions, foreign id", "admin", "/profiles/customer", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := tokens.New(&pb.UserProfile{Id: test.caller, Role: users[test.caller]})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("Authorization", token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != test.status {
+				t.Fatalf("status = %v, want %v", w.Code, test.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package orders
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/api/middlewares"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func registerDisputesHandlers(handler *ordersHandler, m middlewares.Middlewares, router *mux.Router) {
+	router.Path("/orders/{order_id}/customers/{id}/disputes").
+		HandlerFunc(
+			m.Apply(handler.PostDispute, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.DisputesWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/disputes/customers/{id}").
+		HandlerFunc(
+			m.Apply(handler.GetCustomerDisputes, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.DisputesWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/disputes/{dispute_id}/customers/{id}").
+		HandlerFunc(
+			m.Apply(handler.GetCustomerDispute, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.DisputesWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/disputes/admins/{id}").
+		HandlerFunc(
+			m.Apply(handler.GetDisputes, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.DisputesResolveAny},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/disputes/{dispute_id}/admins/{id}/resolve").
+		HandlerFunc(
+			m.Apply(handler.PutResolveDispute, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.DisputesResolveAny},
+			}),
+		).Methods(http.MethodPut)
+
+	router.Path("/disputes/{dispute_id}/admins/{id}/reject").
+		HandlerFunc(
+			m.Apply(handler.PutRejectDispute, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.DisputesResolveAny},
+			}),
+		).Methods(http.MethodPut)
+}
+
+func (h *ordersHandler) PostDispute(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderId, err := primitive.ObjectIDFromHex(vars["order_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.DisputeInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input.Clear()
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := input.ToProto()
+	req.OrderId = orderId.Hex()
+	req.CustomerId = customerId.Hex()
+
+	dispute, err := h.disputesClient.OpenDispute(r.Context(), req)
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromDispute(dispute))
+}
+
+func (h *ordersHandler) GetCustomerDisputes(w http.ResponseWriter, r *http.Request) {
+	customerId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writeDisputes(w, r, &pb.ListDisputesRequest{CustomerId: customerId.Hex()})
+}
+
+func (h *ordersHandler) GetCustomerDispute(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	disputeId, err := primitive.ObjectIDFromHex(vars["dispute_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dispute, err := h.disputesClient.GetDispute(r.Context(), &pb.GetDisputeRequest{Id: disputeId.Hex()})
+	if err != nil || dispute.CustomerId != customerId.Hex() {
+		rest.WriteError(w, http.StatusNotFound, fmt.Errorf("dispute not found: id=%v", disputeId.Hex()))
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromDispute(dispute))
+}
+
+// GetDisputes lists the disputes with the ?status query parameter, open ones
+// by default.
+func (h *ordersHandler) GetDisputes(w http.ResponseWriter, r *http.Request) {
+	status := pb.DisputeStatus_DisputeOpen
+
+	if value := r.URL.Query().Get("status"); value != "" {
+		var ok bool
+		status, ok = form.DisputeStatuses[value]
+		if !ok {
+			rest.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid dispute status: status=%v", value))
+			return
+		}
+	}
+
+	h.writeDisputes(w, r, &pb.ListDisputesRequest{Status: status})
+}
+
+func (h *ordersHandler) PutResolveDispute(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	disputeId, err := primitive.ObjectIDFromHex(vars["dispute_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.ResolveDisputeInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input.Clear()
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dispute, err := h.disputesClient.ResolveDispute(r.Context(), &pb.ResolveDisputeRequest{
+		Id:              disputeId.Hex(),
+		RefundAmount:    input.RefundAmount,
+		SellerCharge:    input.SellerCharge,
+		DelivererCharge: input.DelivererCharge,
+		Note:            input.Note,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromDispute(dispute))
+}
+
+func (h *ordersHandler) PutRejectDispute(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	disputeId, err := primitive.ObjectIDFromHex(vars["dispute_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.RejectDisputeInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input.Clear()
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dispute, err := h.disputesClient.RejectDispute(r.Context(), &pb.RejectDisputeRequest{
+		Id:   disputeId.Hex(),
+		Note: input.Note,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromDispute(dispute))
+}
+
+func (h *ordersHandler) writeDisputes(w http.ResponseWriter, r *http.Request, req *pb.ListDisputesRequest) {
+	stream, err := h.disputesClient.ListDisputes(r.Context(), req)
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	disputes := make([]*form.Dispute, 0)
+
+	for {
+		dispute, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		disputes = append(disputes, form.FromDispute(dispute))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, disputes)
+}
@@ -0,0 +1,239 @@
+package orders
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/api/middlewares"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func registerFavoritesHandlers(handler *ordersHandler, m middlewares.Middlewares, router *mux.Router) {
+	router.Path("/orders/{order_id}/customers/{id}/reorder").
+		HandlerFunc(
+			m.Apply(handler.PostReorder, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.OrdersCreateOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/favorites/customers/{id}/products").
+		HandlerFunc(
+			m.Apply(handler.GetFavoriteProducts, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.FavoritesWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/favorites/customers/{id}/products/{product_id}").
+		HandlerFunc(
+			m.Apply(handler.PostFavoriteProduct, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.FavoritesWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/favorites/customers/{id}/products/{product_id}").
+		HandlerFunc(
+			m.Apply(handler.DeleteFavoriteProduct, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.FavoritesWriteOwn},
+			}),
+		).Methods(http.MethodDelete)
+
+	router.Path("/favorites/customers/{id}/stores").
+		HandlerFunc(
+			m.Apply(handler.GetFavoriteStores, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.FavoritesWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/favorites/customers/{id}/stores/{seller_id}").
+		HandlerFunc(
+			m.Apply(handler.PostFavoriteStore, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.FavoritesWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/favorites/customers/{id}/stores/{seller_id}").
+		HandlerFunc(
+			m.Apply(handler.DeleteFavoriteStore, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.FavoritesWriteOwn},
+			}),
+		).Methods(http.MethodDelete)
+}
+
+func (h *ordersHandler) PostReorder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderId, err := primitive.ObjectIDFromHex(vars["order_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.ReorderInput)
+	if len(body) > 0 {
+		err = json.Unmarshal(body, input)
+		if err != nil {
+			rest.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := h.ordersClient.Reorder(r.Context(), &pb.ReorderRequest{
+		OrderId:    orderId.Hex(),
+		CustomerId: customerId.Hex(),
+		AddressId:  input.AddressId,
+		PromoCode:  input.PromoCode,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromOrder(order))
+}
+
+func (h *ordersHandler) GetFavoriteProducts(w http.ResponseWriter, r *http.Request) {
+	h.writeFavorites(w, r, pb.FavoriteKind_FavoriteProduct)
+}
+
+func (h *ordersHandler) PostFavoriteProduct(w http.ResponseWriter, r *http.Request) {
+	h.addFavorite(w, r, pb.FavoriteKind_FavoriteProduct, "product_id")
+}
+
+func (h *ordersHandler) DeleteFavoriteProduct(w http.ResponseWriter, r *http.Request) {
+	h.removeFavorite(w, r, pb.FavoriteKind_FavoriteProduct, "product_id")
+}
+
+func (h *ordersHandler) GetFavoriteStores(w http.ResponseWriter, r *http.Request) {
+	h.writeFavorites(w, r, pb.FavoriteKind_FavoriteStore)
+}
+
+func (h *ordersHandler) PostFavoriteStore(w http.ResponseWriter, r *http.Request) {
+	h.addFavorite(w, r, pb.FavoriteKind_FavoriteStore, "seller_id")
+}
+
+func (h *ordersHandler) DeleteFavoriteStore(w http.ResponseWriter, r *http.Request) {
+	h.removeFavorite(w, r, pb.FavoriteKind_FavoriteStore, "seller_id")
+}
+
+func (h *ordersHandler) addFavorite(w http.ResponseWriter, r *http.Request, kind pb.FavoriteKind, targetVar string) {
+	vars := mux.Vars(r)
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	targetId, err := primitive.ObjectIDFromHex(vars[targetVar])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	favorite, err := h.favoritesClient.AddFavorite(r.Context(), &pb.Favorite{
+		CustomerId: customerId.Hex(),
+		Kind:       kind,
+		TargetId:   targetId.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromFavorite(favorite))
+}
+
+func (h *ordersHandler) removeFavorite(w http.ResponseWriter, r *http.Request, kind pb.FavoriteKind, targetVar string) {
+	vars := mux.Vars(r)
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	targetId, err := primitive.ObjectIDFromHex(vars[targetVar])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.favoritesClient.RemoveFavorite(r.Context(), &pb.RemoveFavoriteRequest{
+		CustomerId: customerId.Hex(),
+		Kind:       kind,
+		TargetId:   targetId.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
+
+func (h *ordersHandler) writeFavorites(w http.ResponseWriter, r *http.Request, kind pb.FavoriteKind) {
+	customerId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.favoritesClient.ListFavorites(r.Context(), &pb.ListFavoritesRequest{
+		CustomerId: customerId.Hex(),
+		Kind:       kind,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	favorites := make([]*form.Favorite, 0)
+
+	for {
+		favorite, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		favorites = append(favorites, form.FromFavorite(favorite))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, favorites)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"go-delivery/pb"
+	"go-delivery/security/permissions"
 	"go-delivery/services/api/middlewares"
 	"go-delivery/services/api/rest"
 	"go-delivery/services/api/rest/form"
@@ -16,19 +17,38 @@ import (
 )
 
 type ordersHandler struct {
-	ordersClient pb.OrdersServiceClient
-	validate     *validator.Validate
+	ordersClient     pb.OrdersServiceClient
+	promotionsClient pb.PromotionsServiceClient
+	reviewsClient    pb.ReviewsServiceClient
+	disputesClient   pb.DisputesServiceClient
+	favoritesClient  pb.FavoritesServiceClient
+	validate         *validator.Validate
 }
 
-func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.Middlewares, router *mux.Router) {
-	handler := ordersHandler{ordersClient: ordersClient, validate: validator.New()}
+func RegisterOrdersHandlers(
+	ordersClient pb.OrdersServiceClient,
+	promotionsClient pb.PromotionsServiceClient,
+	reviewsClient pb.ReviewsServiceClient,
+	disputesClient pb.DisputesServiceClient,
+	favoritesClient pb.FavoritesServiceClient,
+	m middlewares.Middlewares,
+	router *mux.Router,
+) {
+	handler := ordersHandler{
+		ordersClient:     ordersClient,
+		promotionsClient: promotionsClient,
+		reviewsClient:    reviewsClient,
+		disputesClient:   disputesClient,
+		favoritesClient:  favoritesClient,
+		validate:         validator.New(),
+	}
 
 	router.Path("/orders/customers/{id}").
 		HandlerFunc(
 			m.Apply(handler.PostOrder, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Customer,
+				Permissions:  []permissions.Permission{permissions.OrdersCreateOwn},
 			}),
 		).Methods(http.MethodPost)
 
@@ -37,7 +57,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.GetSellerOrders, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Seller,
+				Permissions:  []permissions.Permission{permissions.OrdersReadOwn},
 			}),
 		).Methods(http.MethodGet)
 
@@ -46,7 +66,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.GetOrdersAccepted, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Delivery,
+				Permissions:  []permissions.Permission{permissions.DeliveriesReadAny},
 			}),
 		).Methods(http.MethodGet)
 
@@ -55,7 +75,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.PutApproveOrder, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Seller,
+				Permissions:  []permissions.Permission{permissions.OrdersApproveOwn},
 			}),
 		).Methods(http.MethodPut)
 
@@ -64,7 +84,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.PutDeliverOrder, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Delivery,
+				Permissions:  []permissions.Permission{permissions.DeliveriesWriteOwn},
 			}),
 		).Methods(http.MethodPut)
 
@@ -73,7 +93,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.PutOrderDelivered, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Customer,
+				Permissions:  []permissions.Permission{permissions.OrdersConfirmOwn},
 			}),
 		).Methods(http.MethodPut)
 
@@ -82,7 +102,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.GetOrder, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Admin,
+				Permissions:  []permissions.Permission{permissions.OrdersReadAny},
 			}),
 		).Methods(http.MethodGet)
 
@@ -91,7 +111,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.DeleteOrder, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Admin,
+				Permissions:  []permissions.Permission{permissions.OrdersDeleteAny},
 			}),
 		).Methods(http.MethodDelete)
 
@@ -100,7 +120,7 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.GetOrders, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Admin,
+				Permissions:  []permissions.Permission{permissions.OrdersReadAny},
 			}),
 		).Methods(http.MethodGet)
 
@@ -109,9 +129,15 @@ func RegisterOrdersHandlers(ordersClient pb.OrdersServiceClient, m middlewares.M
 			m.Apply(handler.GetByStatus, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Admin,
+				Permissions:  []permissions.Permission{permissions.OrdersReadAny},
 			}),
 		).Methods(http.MethodGet)
+
+	registerPromotionsHandlers(&handler, m, router)
+	registerReviewsHandlers(&handler, m, router)
+	registerTipsHandlers(&handler, m, router)
+	registerDisputesHandlers(&handler, m, router)
+	registerFavoritesHandlers(&handler, m, router)
 }
 
 func (h *ordersHandler) PostOrder(w http.ResponseWriter, r *http.Request) {
@@ -141,14 +167,23 @@ func (h *ordersHandler) PostOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	deliverFrom, deliverUntil := input.DeliveryWindow.Unix()
+
 	order := &pb.Order{
-		Id:         primitive.NewObjectID().Hex(),
-		CustomerId: customerId.Hex(),
-		SellerId:   input.SellerId,
-		ProductId:  input.ProductId,
-		Quantity:   input.Quantity,
-		CreatedAt:  time.Now().Unix(),
-		UpdatedAt:  time.Now().Unix(),
+		Id:           primitive.NewObjectID().Hex(),
+		CustomerId:   customerId.Hex(),
+		SellerId:     input.SellerId,
+		ProductId:    input.ProductId,
+		Quantity:     input.Quantity,
+		AddressId:    input.AddressId,
+		VariantId:    input.VariantId,
+		OptionIds:    input.OptionIds,
+		PromoCode:    input.PromoCode,
+		Tip:          input.Tip,
+		DeliverFrom:  deliverFrom,
+		DeliverUntil: deliverUntil,
+		CreatedAt:    time.Now().Unix(),
+		UpdatedAt:    time.Now().Unix(),
 	}
 
 	order, err = h.ordersClient.CreateOrder(r.Context(), order)
@@ -0,0 +1,291 @@
+package orders
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/api/middlewares"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func registerPromotionsHandlers(handler *ordersHandler, m middlewares.Middlewares, router *mux.Router) {
+	router.Path("/orders/customers/{id}/promo-quote").
+		HandlerFunc(
+			m.Apply(handler.PostPromoQuote, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.OrdersCreateOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/promotions").
+		HandlerFunc(
+			m.Apply(handler.PostSellerPromotion, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.PromotionsWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/promotions").
+		HandlerFunc(
+			m.Apply(handler.GetSellerPromotions, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.PromotionsWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/promotions/{promotion_id}").
+		HandlerFunc(
+			m.Apply(handler.DeleteSellerPromotion, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.PromotionsWriteOwn},
+			}),
+		).Methods(http.MethodDelete)
+
+	router.Path("/promotions/admins/{id}").
+		HandlerFunc(
+			m.Apply(handler.PostPromotion, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.PromotionsWriteAny},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/promotions/admins/{id}").
+		HandlerFunc(
+			m.Apply(handler.GetPromotions, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.PromotionsWriteAny},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/promotions/{promotion_id}/admins/{id}").
+		HandlerFunc(
+			m.Apply(handler.DeletePromotion, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.PromotionsWriteAny},
+			}),
+		).Methods(http.MethodDelete)
+}
+
+func (h *ordersHandler) PostPromoQuote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.PromoQuoteInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	deliverFrom, deliverUntil := input.DeliveryWindow.Unix()
+
+	quote, err := h.ordersClient.ApplyPromoCode(r.Context(), &pb.ApplyPromoCodeRequest{
+		CustomerId:   customerId.Hex(),
+		SellerId:     input.SellerId,
+		ProductId:    input.ProductId,
+		Quantity:     input.Quantity,
+		VariantId:    input.VariantId,
+		OptionIds:    input.OptionIds,
+		PromoCode:    input.PromoCode,
+		DeliverFrom:  deliverFrom,
+		DeliverUntil: deliverUntil,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromOrderQuote(quote))
+}
+
+// PostSellerPromotion creates a promotion funded by the seller of the path,
+// platform funded promotions are created by admins.
+func (h *ordersHandler) PostSellerPromotion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input, ok := h.readPromotionInput(w, r)
+	if !ok {
+		return
+	}
+
+	input.SellerId = sellerId.Hex()
+	input.Funding = "seller"
+
+	h.createPromotion(w, r, input)
+}
+
+func (h *ordersHandler) PostPromotion(w http.ResponseWriter, r *http.Request) {
+	input, ok := h.readPromotionInput(w, r)
+	if !ok {
+		return
+	}
+
+	if input.Funding == "" {
+		input.Funding = "platform"
+	}
+
+	h.createPromotion(w, r, input)
+}
+
+func (h *ordersHandler) GetSellerPromotions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writePromotions(w, r, sellerId.Hex())
+}
+
+// GetPromotions lists the promotions valid for every seller, or the ones of
+// the seller_id query parameter.
+func (h *ordersHandler) GetPromotions(w http.ResponseWriter, r *http.Request) {
+	sellerId := r.URL.Query().Get("seller_id")
+	if sellerId != "" {
+		_, err := primitive.ObjectIDFromHex(sellerId)
+		if err != nil {
+			rest.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	h.writePromotions(w, r, sellerId)
+}
+
+func (h *ordersHandler) DeleteSellerPromotion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	promotionId, err := primitive.ObjectIDFromHex(vars["promotion_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	promotion, err := h.promotionsClient.GetPromotion(r.Context(), &pb.GetPromotionRequest{Id: promotionId.Hex()})
+	if err != nil || promotion.SellerId != sellerId.Hex() {
+		rest.WriteError(w, http.StatusNotFound, fmt.Errorf("promotion not found: id=%v", promotionId.Hex()))
+		return
+	}
+
+	h.deactivatePromotion(w, r, promotionId)
+}
+
+func (h *ordersHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	promotionId, err := primitive.ObjectIDFromHex(vars["promotion_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.deactivatePromotion(w, r, promotionId)
+}
+
+func (h *ordersHandler) readPromotionInput(w http.ResponseWriter, r *http.Request) (*form.PromotionInput, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	input := new(form.PromotionInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	input.Clear()
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	return input, true
+}
+
+func (h *ordersHandler) createPromotion(w http.ResponseWriter, r *http.Request, input *form.PromotionInput) {
+	promotion, err := h.promotionsClient.CreatePromotion(r.Context(), input.ToProto())
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromPromotion(promotion))
+}
+
+func (h *ordersHandler) writePromotions(w http.ResponseWriter, r *http.Request, sellerId string) {
+	stream, err := h.promotionsClient.ListPromotions(r.Context(), &pb.ListPromotionsRequest{SellerId: sellerId})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	promotions := make([]*form.Promotion, 0)
+
+	for {
+		promotion, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		promotions = append(promotions, form.FromPromotion(promotion))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, promotions)
+}
+
+func (h *ordersHandler) deactivatePromotion(w http.ResponseWriter, r *http.Request, promotionId primitive.ObjectID) {
+	promotion, err := h.promotionsClient.DeactivatePromotion(r.Context(), &pb.DeactivatePromotionRequest{Id: promotionId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromPromotion(promotion))
+}
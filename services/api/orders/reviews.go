@@ -0,0 +1,269 @@
+package orders
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/api/middlewares"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func registerReviewsHandlers(handler *ordersHandler, m middlewares.Middlewares, router *mux.Router) {
+	router.Path("/orders/{order_id}/customers/{id}/review").
+		HandlerFunc(
+			m.Apply(handler.PostReview, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ReviewsWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/products/{product_id}/reviews").
+		HandlerFunc(
+			m.Apply(handler.GetProductReviews, middlewares.Options{}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/reviews").
+		HandlerFunc(
+			m.Apply(handler.GetSellerReviews, middlewares.Options{}),
+		).Methods(http.MethodGet)
+
+	router.Path("/deliverers/{id}/reviews").
+		HandlerFunc(
+			m.Apply(handler.GetDelivererReviews, middlewares.Options{}),
+		).Methods(http.MethodGet)
+
+	router.Path("/deliverers/{id}/rating").
+		HandlerFunc(
+			m.Apply(handler.GetDelivererRating, middlewares.Options{}),
+		).Methods(http.MethodGet)
+
+	router.Path("/reviews/admins/{id}").
+		HandlerFunc(
+			m.Apply(handler.GetModerationQueue, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ReviewsModerateAny},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/reviews/{review_id}/admins/{id}").
+		HandlerFunc(
+			m.Apply(handler.PutModerateReview, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ReviewsModerateAny},
+			}),
+		).Methods(http.MethodPut)
+}
+
+func (h *ordersHandler) PostReview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderId, err := primitive.ObjectIDFromHex(vars["order_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.ReviewInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input.Clear()
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	review, err := h.reviewsClient.CreateReview(r.Context(), &pb.Review{
+		OrderId:         orderId.Hex(),
+		CustomerId:      customerId.Hex(),
+		SellerRating:    input.SellerRating,
+		ProductRating:   input.ProductRating,
+		DelivererRating: input.DelivererRating,
+		Comment:         input.Comment,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromReview(review))
+}
+
+func (h *ordersHandler) GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	productId, err := primitive.ObjectIDFromHex(mux.Vars(r)["product_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writeReviews(w, r, &pb.ListReviewsRequest{ProductId: productId.Hex()})
+}
+
+func (h *ordersHandler) GetSellerReviews(w http.ResponseWriter, r *http.Request) {
+	sellerId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writeReviews(w, r, &pb.ListReviewsRequest{SellerId: sellerId.Hex()})
+}
+
+func (h *ordersHandler) GetDelivererReviews(w http.ResponseWriter, r *http.Request) {
+	delivererId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writeReviews(w, r, &pb.ListReviewsRequest{DelivererId: delivererId.Hex()})
+}
+
+func (h *ordersHandler) GetDelivererRating(w http.ResponseWriter, r *http.Request) {
+	delivererId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rating, err := h.reviewsClient.GetDelivererRating(r.Context(), &pb.GetDelivererRatingRequest{DelivererId: delivererId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, &form.Rating{Average: rating.Average, Count: rating.Count})
+}
+
+// GetModerationQueue lists the visible reviews, or the hidden ones with
+// ?hidden=true.
+func (h *ordersHandler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	var hidden bool
+
+	if value := r.URL.Query().Get("hidden"); value != "" {
+		var err error
+		hidden, err = strconv.ParseBool(value)
+		if err != nil {
+			rest.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	stream, err := h.reviewsClient.ListModerationQueue(r.Context(), &pb.ListModerationQueueRequest{Hidden: hidden})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	reviews := make([]*form.Review, 0)
+
+	for {
+		review, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		reviews = append(reviews, form.FromReview(review))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, reviews)
+}
+
+func (h *ordersHandler) PutModerateReview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reviewId, err := primitive.ObjectIDFromHex(vars["review_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	moderatorId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.ModerationInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	review, err := h.reviewsClient.ModerateReview(r.Context(), &pb.ModerateReviewRequest{
+		Id:          reviewId.Hex(),
+		Hidden:      input.Hidden,
+		Reason:      input.Reason,
+		ModeratorId: moderatorId.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromReview(review))
+}
+
+func (h *ordersHandler) writeReviews(w http.ResponseWriter, r *http.Request, req *pb.ListReviewsRequest) {
+	stream, err := h.reviewsClient.ListReviews(r.Context(), req)
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	reviews := make([]*form.Review, 0)
+
+	for {
+		review, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		reviews = append(reviews, form.FromReview(review))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, reviews)
+}
@@ -0,0 +1,117 @@
+package orders
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/api/middlewares"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"time"
+)
+
+func registerTipsHandlers(handler *ordersHandler, m middlewares.Middlewares, router *mux.Router) {
+	router.Path("/orders/{order_id}/customers/{id}/tip").
+		HandlerFunc(
+			m.Apply(handler.PostTip, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.OrdersConfirmOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/orders/deliverers/{id}/earnings").
+		HandlerFunc(
+			m.Apply(handler.GetDelivererEarnings, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.EarningsReadOwn},
+			}),
+		).Methods(http.MethodGet)
+}
+
+func (h *ordersHandler) PostTip(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orderId, err := primitive.ObjectIDFromHex(vars["order_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	customerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.TipInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := h.ordersClient.AddTip(r.Context(), &pb.AddTipRequest{
+		OrderId:    orderId.Hex(),
+		CustomerId: customerId.Hex(),
+		Amount:     input.Amount,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromOrder(order))
+}
+
+// GetDelivererEarnings accepts optional RFC 3339 from and to query
+// parameters to bound the delivery dates.
+func (h *ordersHandler) GetDelivererEarnings(w http.ResponseWriter, r *http.Request) {
+	delivererId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := &pb.GetDelivererEarningsRequest{DelivererId: delivererId.Hex()}
+
+	for name, field := range map[string]*int64{"from": &req.From, "to": &req.To} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			rest.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: value=%v", name, value))
+			return
+		}
+
+		*field = parsed.Unix()
+	}
+
+	earnings, err := h.ordersClient.GetDelivererEarnings(r.Context(), req)
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromDelivererEarnings(earnings))
+}
@@ -0,0 +1,115 @@
+package form
+
+import (
+	"go-delivery/pb"
+	"strings"
+	"time"
+)
+
+type DisputeInput struct {
+	Reason      string `validate:"oneof=missing_item damaged_item wrong_item not_delivered other" json:"reason"`
+	Description string `validate:"required,lte=1000" json:"description"`
+}
+
+var disputeReasons = map[string]pb.DisputeReason{
+	"missing_item":  pb.DisputeReason_MissingItem,
+	"damaged_item":  pb.DisputeReason_DamagedItem,
+	"wrong_item":    pb.DisputeReason_WrongItem,
+	"not_delivered": pb.DisputeReason_NotDelivered,
+	"other":         pb.DisputeReason_OtherReason,
+}
+
+var DisputeStatuses = map[string]pb.DisputeStatus{
+	"open":      pb.DisputeStatus_DisputeOpen,
+	"resolved":  pb.DisputeStatus_DisputeResolved,
+	"rejected":  pb.DisputeStatus_DisputeRejected,
+	"resolving": pb.DisputeStatus_DisputeResolving,
+}
+
+func (i *DisputeInput) Clear() {
+	i.Description = strings.TrimSpace(i.Description)
+}
+
+func (i *DisputeInput) ToProto() *pb.Dispute {
+	return &pb.Dispute{
+		Reason:      disputeReasons[i.Reason],
+		Description: i.Description,
+	}
+}
+
+type ResolveDisputeInput struct {
+	RefundAmount    float32 `validate:"gt=0" json:"refund_amount"`
+	SellerCharge    float32 `validate:"gte=0" json:"seller_charge"`
+	DelivererCharge float32 `validate:"gte=0" json:"deliverer_charge"`
+	Note            string  `validate:"lte=500" json:"note"`
+}
+
+func (i *ResolveDisputeInput) Clear() {
+	i.Note = strings.TrimSpace(i.Note)
+}
+
+type RejectDisputeInput struct {
+	Note string `validate:"required,lte=500" json:"note"`
+}
+
+func (i *RejectDisputeInput) Clear() {
+	i.Note = strings.TrimSpace(i.Note)
+}
+
+type Dispute struct {
+	Id              string     `json:"id"`
+	OrderId         string     `json:"order_id"`
+	CustomerId      string     `json:"customer_id"`
+	SellerId        string     `json:"seller_id"`
+	DelivererId     string     `json:"deliverer_id,omitempty"`
+	Reason          string     `json:"reason"`
+	Description     string     `json:"description"`
+	Status          string     `json:"status"`
+	RefundAmount    float32    `json:"refund_amount"`
+	SellerCharge    float32    `json:"seller_charge"`
+	DelivererCharge float32    `json:"deliverer_charge"`
+	PlatformCharge  float32    `json:"platform_charge"`
+	ResolutionNote  string     `json:"resolution_note,omitempty"`
+	ResolvedBy      string     `json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func FromDispute(d *pb.Dispute) *Dispute {
+	dispute := &Dispute{
+		Id:              d.Id,
+		OrderId:         d.OrderId,
+		CustomerId:      d.CustomerId,
+		SellerId:        d.SellerId,
+		DelivererId:     d.DelivererId,
+		Description:     d.Description,
+		RefundAmount:    d.RefundAmount,
+		SellerCharge:    d.SellerCharge,
+		DelivererCharge: d.DelivererCharge,
+		PlatformCharge:  d.PlatformCharge,
+		ResolutionNote:  d.ResolutionNote,
+		ResolvedBy:      d.ResolvedBy,
+		CreatedAt:       time.Unix(d.CreatedAt, 0),
+		UpdatedAt:       time.Unix(d.UpdatedAt, 0),
+	}
+
+	for name, reason := range disputeReasons {
+		if reason == d.Reason {
+			dispute.Reason = name
+		}
+	}
+
+	for name, status := range DisputeStatuses {
+		if status == d.Status {
+			dispute.Status = name
+		}
+	}
+
+	if d.ResolvedAt > 0 {
+		resolvedAt := time.Unix(d.ResolvedAt, 0)
+		dispute.ResolvedAt = &resolvedAt
+	}
+
+	return dispute
+}
@@ -0,0 +1,30 @@
+package form
+
+import (
+	"go-delivery/pb"
+	"time"
+)
+
+type Favorite struct {
+	Id            string    `json:"id"`
+	TargetId      string    `json:"target_id"`
+	SellerId      string    `json:"seller_id"`
+	Name          string    `json:"name"`
+	Price         float32   `json:"price,omitempty"`
+	RatingAverage float32   `json:"rating_average"`
+	Available     bool      `json:"available"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func FromFavorite(f *pb.Favorite) *Favorite {
+	return &Favorite{
+		Id:            f.Id,
+		TargetId:      f.TargetId,
+		SellerId:      f.SellerId,
+		Name:          f.Name,
+		Price:         f.Price,
+		RatingAverage: f.RatingAverage,
+		Available:     f.Available,
+		CreatedAt:     time.Unix(f.CreatedAt, 0),
+	}
+}
@@ -6,39 +6,238 @@ import (
 )
 
 type OrderInput struct {
-	SellerId  string `validate:"required" json:"seller_id"`
-	ProductId string `validate:"required" json:"product_id"`
-	Quantity  int32  `validate:"required" json:"quantity"`
+	SellerId  string   `validate:"required" json:"seller_id"`
+	ProductId string   `validate:"required" json:"product_id"`
+	Quantity  int32    `validate:"required,gt=0" json:"quantity"`
+	AddressId string   `json:"address_id"`
+	VariantId string   `json:"variant_id"`
+	OptionIds []string `validate:"dive,required" json:"option_ids"`
+	PromoCode string   `validate:"lte=32" json:"promo_code"`
+	Tip       float32  `validate:"gte=0,lte=500" json:"tip"`
+	// DeliveryWindow schedules the order, it is delivered immediately when
+	// not set.
+	DeliveryWindow *DeliveryWindow `json:"delivery_window"`
+}
+
+type DeliveryWindow struct {
+	From  time.Time  `validate:"required" json:"from"`
+	Until *time.Time `json:"until"`
+}
+
+// Unix returns the window bounds as unix times, zero when not set.
+func (w *DeliveryWindow) Unix() (int64, int64) {
+	if w == nil {
+		return 0, 0
+	}
+
+	var until int64
+	if w.Until != nil {
+		until = w.Until.Unix()
+	}
+
+	return w.From.Unix(), until
+}
+
+// ReorderInput is optional, the delivery address of the past order is used
+// when no address is given.
+type ReorderInput struct {
+	AddressId string `validate:"omitempty,len=24,hexadecimal" json:"address_id"`
+	PromoCode string `validate:"lte=32" json:"promo_code"`
+}
+
+type TipInput struct {
+	Amount float32 `validate:"gt=0,lte=500" json:"amount"`
 }
 
 type Order struct {
-	Id           string    `json:"id"`
-	CustomerId   string    `json:"customer_id"`
-	SellerId     string    `json:"seller_id"`
-	ProductId    string    `json:"product_id"`
-	DelivererId  string    `json:"delivery_id"`
-	Status       string    `json:"status"`
-	Quantity     int32     `json:"quantity"`
-	UnitPrice    float32   `json:"unit_price"`
-	DeliveryCost float32   `json:"delivery_cost"`
-	Amount       float32   `json:"amount"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	Id             string           `json:"id"`
+	CustomerId     string           `json:"customer_id"`
+	SellerId       string           `json:"seller_id"`
+	ProductId      string           `json:"product_id"`
+	ProductVersion int32            `json:"product_version"`
+	DelivererId    string           `json:"delivery_id"`
+	Status         string           `json:"status"`
+	Quantity       int32            `json:"quantity"`
+	UnitPrice      float32          `json:"unit_price"`
+	DeliveryCost   float32          `json:"delivery_cost"`
+	Subtotal       float32          `json:"subtotal"`
+	Discount       *OrderDiscount   `json:"discount,omitempty"`
+	Amount         float32          `json:"amount"`
+	Tip            float32          `json:"tip"`
+	Refunded       float32          `json:"refunded,omitempty"`
+	TippedAt       *time.Time       `json:"tipped_at,omitempty"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+	DeliverFrom    *time.Time       `json:"deliver_from,omitempty"`
+	DeliverUntil   *time.Time       `json:"deliver_until,omitempty"`
+	ReleasedAt     *time.Time       `json:"released_at,omitempty"`
+	AddressId      string           `json:"address_id"`
+	Address        *DeliveryAddress `json:"delivery_address"`
+	Item           *OrderItem       `json:"item"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+type DeliveryAddress struct {
+	Label        string  `json:"label"`
+	Street       string  `json:"street"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Instructions string  `json:"instructions"`
+}
+
+type OrderItemOption struct {
+	GroupId    string  `json:"group_id"`
+	GroupName  string  `json:"group_name"`
+	OptionId   string  `json:"option_id"`
+	Name       string  `json:"name"`
+	PriceDelta float32 `json:"price_delta"`
+}
+
+type OrderItem struct {
+	ProductId   string             `json:"product_id"`
+	ProductName string             `json:"product_name"`
+	VariantId   string             `json:"variant_id,omitempty"`
+	VariantName string             `json:"variant_name,omitempty"`
+	SKU         string             `json:"sku,omitempty"`
+	Options     []*OrderItemOption `json:"options"`
+	UnitPrice   float32            `json:"unit_price"`
+	Quantity    int32              `json:"quantity"`
+}
+
+func FromOrderItem(i *pb.OrderItem) *OrderItem {
+	item := &OrderItem{
+		ProductId:   i.ProductId,
+		ProductName: i.ProductName,
+		VariantId:   i.VariantId,
+		VariantName: i.VariantName,
+		SKU:         i.Sku,
+		UnitPrice:   i.UnitPrice,
+		Quantity:    i.Quantity,
+	}
+
+	for _, option := range i.Options {
+		item.Options = append(item.Options, &OrderItemOption{
+			GroupId:    option.GroupId,
+			GroupName:  option.GroupName,
+			OptionId:   option.OptionId,
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+		})
+	}
+
+	return item
 }
 
 func FromOrder(order *pb.Order) *Order {
-	return &Order{
-		Id:           order.Id,
-		CustomerId:   order.CustomerId,
-		SellerId:     order.SellerId,
-		ProductId:    order.ProductId,
-		DelivererId:  order.DelivererId,
-		Status:       order.Status.String(),
-		Quantity:     order.Quantity,
-		UnitPrice:    order.UnitPrice,
-		DeliveryCost: order.DeliveryCost,
-		Amount:       order.Amount,
-		CreatedAt:    time.Unix(order.CreatedAt, 0),
-		UpdatedAt:    time.Unix(order.UpdatedAt, 0),
+	var address *DeliveryAddress
+	if order.DeliveryAddress != nil {
+		address = &DeliveryAddress{
+			Label:        order.DeliveryAddress.Label,
+			Street:       order.DeliveryAddress.Street,
+			Latitude:     order.DeliveryAddress.Latitude,
+			Longitude:    order.DeliveryAddress.Longitude,
+			Instructions: order.DeliveryAddress.Instructions,
+		}
+	}
+
+	var item *OrderItem
+	if order.Item != nil {
+		item = FromOrderItem(order.Item)
 	}
+
+	var discount *OrderDiscount
+	if order.Discount != nil {
+		discount = FromOrderDiscount(order.Discount)
+	}
+
+	result := &Order{
+		Id:             order.Id,
+		CustomerId:     order.CustomerId,
+		SellerId:       order.SellerId,
+		ProductId:      order.ProductId,
+		ProductVersion: order.ProductVersion,
+		DelivererId:    order.DelivererId,
+		Status:         order.Status.String(),
+		Quantity:       order.Quantity,
+		UnitPrice:      order.UnitPrice,
+		DeliveryCost:   order.DeliveryCost,
+		Subtotal:       order.Subtotal,
+		Discount:       discount,
+		Amount:         order.Amount,
+		Tip:            order.Tip,
+		Refunded:       order.Refunded,
+		AddressId:      order.AddressId,
+		Address:        address,
+		Item:           item,
+		CreatedAt:      time.Unix(order.CreatedAt, 0),
+		UpdatedAt:      time.Unix(order.UpdatedAt, 0),
+	}
+
+	if order.TippedAt > 0 {
+		tippedAt := time.Unix(order.TippedAt, 0)
+		result.TippedAt = &tippedAt
+	}
+
+	if order.DeliveredAt > 0 {
+		deliveredAt := time.Unix(order.DeliveredAt, 0)
+		result.DeliveredAt = &deliveredAt
+	}
+
+	if order.DeliverFrom > 0 {
+		deliverFrom := time.Unix(order.DeliverFrom, 0)
+		deliverUntil := time.Unix(order.DeliverUntil, 0)
+		result.DeliverFrom = &deliverFrom
+		result.DeliverUntil = &deliverUntil
+	}
+
+	if order.ReleasedAt > 0 {
+		releasedAt := time.Unix(order.ReleasedAt, 0)
+		result.ReleasedAt = &releasedAt
+	}
+
+	return result
+}
+
+type DeliveryEarning struct {
+	OrderId     string     `json:"order_id"`
+	DeliveryFee float32    `json:"delivery_fee"`
+	Tip         float32    `json:"tip"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+type DelivererEarnings struct {
+	DelivererId  string             `json:"deliverer_id"`
+	Deliveries   int32              `json:"deliveries"`
+	DeliveryFees float32            `json:"delivery_fees"`
+	Tips         float32            `json:"tips"`
+	Total        float32            `json:"total"`
+	Orders       []*DeliveryEarning `json:"orders"`
+}
+
+func FromDelivererEarnings(e *pb.DelivererEarnings) *DelivererEarnings {
+	earnings := &DelivererEarnings{
+		DelivererId:  e.DelivererId,
+		Deliveries:   e.Deliveries,
+		DeliveryFees: e.DeliveryFees,
+		Tips:         e.Tips,
+		Total:        e.Total,
+		Orders:       make([]*DeliveryEarning, 0, len(e.Orders)),
+	}
+
+	for _, order := range e.Orders {
+		earning := &DeliveryEarning{
+			OrderId:     order.OrderId,
+			DeliveryFee: order.DeliveryFee,
+			Tip:         order.Tip,
+		}
+
+		if order.DeliveredAt > 0 {
+			deliveredAt := time.Unix(order.DeliveredAt, 0)
+			earning.DeliveredAt = &deliveredAt
+		}
+
+		earnings.Orders = append(earnings.Orders, earning)
+	}
+
+	return earnings
 }
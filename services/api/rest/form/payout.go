@@ -0,0 +1,159 @@
+package form
+
+import (
+	"go-delivery/pb"
+	"strings"
+	"time"
+)
+
+type BankAccountInput struct {
+	HolderName string `validate:"required,max=100" json:"holder_name"`
+	Iban       string `validate:"required,max=42" json:"iban"`
+	Bic        string `validate:"required,max=11" json:"bic"`
+}
+
+func (i *BankAccountInput) Clear() {
+	i.HolderName = strings.TrimSpace(i.HolderName)
+	i.Iban = strings.TrimSpace(i.Iban)
+	i.Bic = strings.TrimSpace(i.Bic)
+}
+
+var PayoutSchedules = map[string]pb.PayoutSchedule{
+	"manual": pb.PayoutSchedule_PayoutManual,
+	"daily":  pb.PayoutSchedule_PayoutDaily,
+	"weekly": pb.PayoutSchedule_PayoutWeekly,
+}
+
+type PayoutScheduleInput struct {
+	Schedule  string  `validate:"required,oneof=manual daily weekly" json:"schedule"`
+	Threshold float32 `validate:"gte=0" json:"threshold"`
+}
+
+type PayoutInput struct {
+	Amount float32 `validate:"required,gt=0" json:"amount"`
+}
+
+var PayoutStatuses = map[string]pb.PayoutStatus{
+	"requested":  pb.PayoutStatus_PayoutRequested,
+	"processing": pb.PayoutStatus_PayoutProcessing,
+	"paid":       pb.PayoutStatus_PayoutPaid,
+	"failed":     pb.PayoutStatus_PayoutFailed,
+}
+
+type PayoutStatusInput struct {
+	Status string `validate:"required,oneof=paid failed" json:"status"`
+	Reason string `validate:"required_if=Status failed,max=500" json:"reason"`
+}
+
+func (i *PayoutStatusInput) Clear() {
+	i.Reason = strings.TrimSpace(i.Reason)
+}
+
+type BankAccount struct {
+	Id              string     `json:"id"`
+	HolderName      string     `json:"holder_name"`
+	IbanLast4       string     `json:"iban_last4"`
+	Schedule        string     `json:"schedule"`
+	PayoutThreshold float32    `json:"payout_threshold,omitempty"`
+	NextPayoutAt    *time.Time `json:"next_payout_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func FromBankAccount(b *pb.BankAccount) *BankAccount {
+	account := &BankAccount{
+		Id:              b.Id,
+		HolderName:      b.HolderName,
+		IbanLast4:       b.IbanLast4,
+		PayoutThreshold: b.PayoutThreshold,
+		CreatedAt:       time.Unix(b.CreatedAt, 0),
+		UpdatedAt:       time.Unix(b.UpdatedAt, 0),
+	}
+
+	for name, schedule := range PayoutSchedules {
+		if schedule == b.Schedule {
+			account.Schedule = name
+		}
+	}
+
+	if b.NextPayoutAt > 0 {
+		nextPayoutAt := time.Unix(b.NextPayoutAt, 0)
+		account.NextPayoutAt = &nextPayoutAt
+	}
+
+	return account
+}
+
+type Payout struct {
+	Id            string     `json:"id"`
+	WalletId      string     `json:"wallet_id"`
+	UserId        string     `json:"user_id"`
+	Amount        float32    `json:"amount"`
+	IbanLast4     string     `json:"iban_last4"`
+	Status        string     `json:"status"`
+	Scheduled     bool       `json:"scheduled"`
+	BatchId       string     `json:"batch_id,omitempty"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+	ExecutedAt    *time.Time `json:"executed_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func FromPayout(p *pb.Payout) *Payout {
+	payout := &Payout{
+		Id:            p.Id,
+		WalletId:      p.WalletId,
+		UserId:        p.UserId,
+		Amount:        p.Amount,
+		IbanLast4:     p.IbanLast4,
+		Scheduled:     p.Scheduled,
+		BatchId:       p.BatchId,
+		FailureReason: p.FailureReason,
+		CreatedAt:     time.Unix(p.CreatedAt, 0),
+		UpdatedAt:     time.Unix(p.UpdatedAt, 0),
+	}
+
+	for name, status := range PayoutStatuses {
+		if status == p.Status {
+			payout.Status = name
+		}
+	}
+
+	if p.ExecutedAt > 0 {
+		executedAt := time.Unix(p.ExecutedAt, 0)
+		payout.ExecutedAt = &executedAt
+	}
+
+	if p.CompletedAt > 0 {
+		completedAt := time.Unix(p.CompletedAt, 0)
+		payout.CompletedAt = &completedAt
+	}
+
+	return payout
+}
+
+var ledgerEntryKinds = map[pb.LedgerEntryKind]string{
+	pb.LedgerEntryKind_LedgerPayout:         "payout",
+	pb.LedgerEntryKind_LedgerPayoutReversal: "payout_reversal",
+}
+
+type LedgerEntry struct {
+	Id        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Amount    float32   `json:"amount"`
+	Balance   float32   `json:"balance"`
+	Reference string    `json:"reference"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func FromLedgerEntry(l *pb.LedgerEntry) *LedgerEntry {
+	return &LedgerEntry{
+		Id:        l.Id,
+		Kind:      ledgerEntryKinds[l.Kind],
+		Amount:    l.Amount,
+		Balance:   l.Balance,
+		Reference: l.Reference,
+		CreatedAt: time.Unix(l.CreatedAt, 0),
+	}
+}
@@ -7,36 +7,425 @@ import (
 )
 
 type ProductInput struct {
-	Name         string  `validate:"required" json:"name"`
-	Price        float32 `validate:"required" json:"price"`
-	DeliveryCost float32 `validate:"required" json:"delivery_cost"`
-	Quantity     int32   `validate:"required" json:"quantity"`
+	UpdateProductInput
+	Quantity int32 `validate:"required_without=Variants" json:"quantity"`
 }
 
-func (i *ProductInput) Clear() {
+// UpdateProductInput edits a product without its stock, the quantity of the
+// existing variants is kept and new variants start out of stock. The stock
+// only changes through inventory adjustments.
+type UpdateProductInput struct {
+	SKU               string   `validate:"lte=64" json:"sku"`
+	Name              string   `validate:"required" json:"name"`
+	Description       string   `validate:"lte=2000" json:"description"`
+	Category          string   `validate:"lte=50" json:"category"`
+	Tags              []string `validate:"lte=20,dive,lte=30" json:"tags"`
+	Price             float32  `validate:"required" json:"price"`
+	DeliveryCost      float32  `validate:"required" json:"delivery_cost"`
+	LowStockThreshold int32    `validate:"gte=0" json:"low_stock_threshold"`
+
+	Variants     []VariantInput     `validate:"lte=50,dive" json:"variants"`
+	OptionGroups []OptionGroupInput `validate:"lte=20,dive" json:"option_groups"`
+}
+
+type VariantInput struct {
+	Id       string  `json:"id"`
+	SKU      string  `validate:"required,lte=64" json:"sku"`
+	Name     string  `validate:"required,lte=100" json:"name"`
+	Price    float32 `validate:"gt=0" json:"price"`
+	Quantity int32   `validate:"gte=0" json:"quantity"`
+}
+
+type OptionInput struct {
+	Id         string  `json:"id"`
+	Name       string  `validate:"required,lte=100" json:"name"`
+	PriceDelta float32 `validate:"gte=0" json:"price_delta"`
+}
+
+type OptionGroupInput struct {
+	Id            string        `json:"id"`
+	Name          string        `validate:"required,lte=100" json:"name"`
+	MinSelections int32         `validate:"gte=0,ltefield=MaxSelections" json:"min_selections"`
+	MaxSelections int32         `validate:"gte=1" json:"max_selections"`
+	Options       []OptionInput `validate:"required,min=1,lte=50,dive" json:"options"`
+}
+
+func (i *UpdateProductInput) VariantsToProto() []*pb.ProductVariant {
+	var variants []*pb.ProductVariant
+	for _, variant := range i.Variants {
+		variants = append(variants, &pb.ProductVariant{
+			Id:       variant.Id,
+			Sku:      variant.SKU,
+			Name:     variant.Name,
+			Price:    variant.Price,
+			Quantity: variant.Quantity,
+		})
+	}
+	return variants
+}
+
+func (i *UpdateProductInput) OptionGroupsToProto() []*pb.OptionGroup {
+	var groups []*pb.OptionGroup
+	for _, group := range i.OptionGroups {
+		item := &pb.OptionGroup{
+			Id:            group.Id,
+			Name:          group.Name,
+			MinSelections: group.MinSelections,
+			MaxSelections: group.MaxSelections,
+		}
+		for _, option := range group.Options {
+			item.Options = append(item.Options, &pb.ProductOption{
+				Id:         option.Id,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		groups = append(groups, item)
+	}
+	return groups
+}
+
+func (i *UpdateProductInput) Clear() {
+	i.SKU = strings.TrimSpace(i.SKU)
 	i.Name = strings.TrimSpace(i.Name)
+	i.Description = strings.TrimSpace(i.Description)
+	i.Category = strings.TrimSpace(i.Category)
+}
+
+type ProductSearchInput struct {
+	Query    string  `validate:"lte=100"`
+	Category string  `validate:"lte=50"`
+	Tag      string  `validate:"lte=30"`
+	SellerId string  `validate:"omitempty,len=24,hexadecimal"`
+	MinPrice float32 `validate:"gte=0"`
+	MaxPrice float32 `validate:"gte=0"`
+	InStock  bool
+	Sort     string `validate:"omitempty,oneof=recent rating"`
+	Limit    int32  `validate:"gte=0,lte=100"`
+	Offset   int32  `validate:"gte=0"`
+}
+
+func (i *ProductSearchInput) ToProto() *pb.SearchProductsRequest {
+	return &pb.SearchProductsRequest{
+		Query:    i.Query,
+		Category: i.Category,
+		Tag:      i.Tag,
+		SellerId: i.SellerId,
+		MinPrice: i.MinPrice,
+		MaxPrice: i.MaxPrice,
+		InStock:  i.InStock,
+		Sort:     i.Sort,
+		Limit:    i.Limit,
+		Offset:   i.Offset,
+	}
 }
 
 type Product struct {
+	Id                string          `json:"id"`
+	SellerId          string          `json:"seller_id"`
+	SKU               string          `json:"sku,omitempty"`
+	Name              string          `json:"name"`
+	Description       string          `json:"description"`
+	Category          string          `json:"category"`
+	Tags              []string        `json:"tags"`
+	Variants          []*Variant      `json:"variants"`
+	OptionGroups      []*OptionGroup  `json:"option_groups"`
+	Images            []*ProductImage `json:"images"`
+	Price             float32         `json:"price"`
+	DeliveryCost      float32         `json:"delivery_cost"`
+	Quantity          int32           `json:"quantity"`
+	Version           int32           `json:"version"`
+	LowStockThreshold int32           `json:"low_stock_threshold"`
+	OutOfStock        bool            `json:"out_of_stock"`
+	Archived          bool            `json:"archived"`
+	ArchivedAt        *time.Time      `json:"archived_at,omitempty"`
+	RatingAverage     float32         `json:"rating_average"`
+	RatingCount       int32           `json:"rating_count"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+type ProductImage struct {
 	Id           string    `json:"id"`
-	SellerId     string    `json:"seller_id"`
-	Name         string    `json:"name"`
-	Price        float32   `json:"price"`
-	DeliveryCost float32   `json:"delivery_cost"`
-	Quantity     int32     `json:"quantity"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	Size         int64     `json:"size"`
 	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Variant struct {
+	Id       string  `json:"id"`
+	SKU      string  `json:"sku"`
+	Name     string  `json:"name"`
+	Price    float32 `json:"price"`
+	Quantity int32   `json:"quantity"`
+}
+
+type Option struct {
+	Id         string  `json:"id"`
+	Name       string  `json:"name"`
+	PriceDelta float32 `json:"price_delta"`
+}
+
+type OptionGroup struct {
+	Id            string    `json:"id"`
+	Name          string    `json:"name"`
+	MinSelections int32     `json:"min_selections"`
+	MaxSelections int32     `json:"max_selections"`
+	Options       []*Option `json:"options"`
 }
 
 func FromProduct(p *pb.Product) *Product {
-	return &Product{
-		Id:           p.Id,
-		SellerId:     p.SellerId,
+	var variants []*Variant
+	for _, variant := range p.Variants {
+		variants = append(variants, &Variant{
+			Id:       variant.Id,
+			SKU:      variant.Sku,
+			Name:     variant.Name,
+			Price:    variant.Price,
+			Quantity: variant.Quantity,
+		})
+	}
+
+	var groups []*OptionGroup
+	for _, group := range p.OptionGroups {
+		item := &OptionGroup{
+			Id:            group.Id,
+			Name:          group.Name,
+			MinSelections: group.MinSelections,
+			MaxSelections: group.MaxSelections,
+		}
+		for _, option := range group.Options {
+			item.Options = append(item.Options, &Option{
+				Id:         option.Id,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		groups = append(groups, item)
+	}
+
+	var images []*ProductImage
+	for _, image := range p.Images {
+		images = append(images, &ProductImage{
+			Id:           image.Id,
+			URL:          image.Url,
+			ThumbnailURL: image.ThumbnailUrl,
+			ContentType:  image.ContentType,
+			Width:        image.Width,
+			Height:       image.Height,
+			Size:         image.Size,
+			CreatedAt:    time.Unix(image.CreatedAt, 0),
+		})
+	}
+
+	product := &Product{
+		Id:                p.Id,
+		SellerId:          p.SellerId,
+		SKU:               p.Sku,
+		Name:              p.Name,
+		Description:       p.Description,
+		Category:          p.Category,
+		Tags:              p.Tags,
+		Variants:          variants,
+		OptionGroups:      groups,
+		Images:            images,
+		Price:             p.Price,
+		DeliveryCost:      p.DeliveryCost,
+		Quantity:          p.Quantity,
+		Version:           p.Version,
+		LowStockThreshold: p.LowStockThreshold,
+		OutOfStock:        p.OutOfStock,
+		Archived:          p.Archived,
+		RatingAverage:     p.RatingAverage,
+		RatingCount:       p.RatingCount,
+		CreatedAt:         time.Unix(p.CreatedAt, 0),
+		UpdatedAt:         time.Unix(p.UpdatedAt, 0),
+	}
+
+	if p.Archived {
+		archivedAt := time.Unix(p.ArchivedAt, 0)
+		product.ArchivedAt = &archivedAt
+	}
+
+	return product
+}
+
+type ImportRowError struct {
+	Row     int32  `json:"row"`
+	SKU     string `json:"sku"`
+	Message string `json:"message"`
+}
+
+type ImportJob struct {
+	Id        string            `json:"id"`
+	SellerId  string            `json:"seller_id"`
+	Format    string            `json:"format"`
+	Status    string            `json:"status"`
+	Total     int32             `json:"total"`
+	Processed int32             `json:"processed"`
+	Created   int32             `json:"created"`
+	Updated   int32             `json:"updated"`
+	Failed    int32             `json:"failed"`
+	Errors    []*ImportRowError `json:"errors"`
+	Message   string            `json:"message,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func FromImportJob(j *pb.ImportJob) *ImportJob {
+	job := &ImportJob{
+		Id:        j.Id,
+		SellerId:  j.SellerId,
+		Format:    j.Format,
+		Status:    strings.TrimPrefix(j.Status.String(), "Import"),
+		Total:     j.Total,
+		Processed: j.Processed,
+		Created:   j.Created,
+		Updated:   j.Updated,
+		Failed:    j.Failed,
+		Message:   j.Message,
+		CreatedAt: time.Unix(j.CreatedAt, 0),
+		UpdatedAt: time.Unix(j.UpdatedAt, 0),
+	}
+
+	for _, rowError := range j.Errors {
+		job.Errors = append(job.Errors, &ImportRowError{
+			Row:     rowError.Row,
+			SKU:     rowError.Sku,
+			Message: rowError.Message,
+		})
+	}
+
+	return job
+}
+
+// CatalogRow is the exported product, it has the same shape the bulk
+// import accepts.
+type CatalogRow struct {
+	SKU          string   `json:"sku"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Category     string   `json:"category"`
+	Tags         []string `json:"tags"`
+	Price        float32  `json:"price"`
+	DeliveryCost float32  `json:"delivery_cost"`
+	Quantity     int32    `json:"quantity"`
+}
+
+func FromCatalogProduct(p *pb.Product) *CatalogRow {
+	return &CatalogRow{
+		SKU:          p.Sku,
 		Name:         p.Name,
+		Description:  p.Description,
+		Category:     p.Category,
+		Tags:         p.Tags,
 		Price:        p.Price,
 		DeliveryCost: p.DeliveryCost,
 		Quantity:     p.Quantity,
-		CreatedAt:    time.Unix(p.CreatedAt, 0),
-		UpdatedAt:    time.Unix(p.UpdatedAt, 0),
+	}
+}
+
+type PriceChangeInput struct {
+	Price         float32   `validate:"gt=0" json:"price"`
+	DeliveryCost  float32   `validate:"gte=0" json:"delivery_cost"`
+	EffectiveFrom time.Time `validate:"required" json:"effective_from"`
+}
+
+type PriceChange struct {
+	Id            string    `json:"id"`
+	ProductId     string    `json:"product_id"`
+	Version       int32     `json:"version,omitempty"`
+	Price         float32   `json:"price"`
+	DeliveryCost  float32   `json:"delivery_cost"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Applied       bool      `json:"applied"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func FromPriceChange(c *pb.PriceChange) *PriceChange {
+	return &PriceChange{
+		Id:            c.Id,
+		ProductId:     c.ProductId,
+		Version:       c.Version,
+		Price:         c.Price,
+		DeliveryCost:  c.DeliveryCost,
+		EffectiveFrom: time.Unix(c.EffectiveFrom, 0),
+		Applied:       c.Applied,
+		CreatedAt:     time.Unix(c.CreatedAt, 0),
+	}
+}
+
+type InventoryAdjustmentInput struct {
+	VariantId string `validate:"omitempty,len=24,hexadecimal" json:"variant_id"`
+	Reason    string `validate:"oneof=restock spoilage correction" json:"reason"`
+	Delta     int32  `validate:"ne=0" json:"delta"`
+	Note      string `validate:"lte=200" json:"note"`
+}
+
+var inventoryReasons = map[string]pb.InventoryReason{
+	"restock":    pb.InventoryReason_InventoryRestock,
+	"spoilage":   pb.InventoryReason_InventorySpoilage,
+	"correction": pb.InventoryReason_InventoryCorrection,
+}
+
+func (i *InventoryAdjustmentInput) ToProto(productId string) *pb.AdjustInventoryRequest {
+	return &pb.AdjustInventoryRequest{
+		ProductId: productId,
+		VariantId: i.VariantId,
+		Reason:    inventoryReasons[i.Reason],
+		Delta:     i.Delta,
+		Note:      strings.TrimSpace(i.Note),
+	}
+}
+
+type InventoryAdjustment struct {
+	Id        string    `json:"id"`
+	ProductId string    `json:"product_id"`
+	VariantId string    `json:"variant_id,omitempty"`
+	Reason    string    `json:"reason"`
+	Delta     int32     `json:"delta"`
+	Quantity  int32     `json:"quantity"`
+	OrderId   string    `json:"order_id,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func FromInventoryAdjustment(a *pb.InventoryAdjustment) *InventoryAdjustment {
+	return &InventoryAdjustment{
+		Id:        a.Id,
+		ProductId: a.ProductId,
+		VariantId: a.VariantId,
+		Reason:    strings.ToLower(strings.TrimPrefix(a.Reason.String(), "Inventory")),
+		Delta:     a.Delta,
+		Quantity:  a.Quantity,
+		OrderId:   a.OrderId,
+		Note:      a.Note,
+		CreatedAt: time.Unix(a.CreatedAt, 0),
+	}
+}
+
+type StockAlert struct {
+	Id          string    `json:"id"`
+	ProductId   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Kind        string    `json:"kind"`
+	Quantity    int32     `json:"quantity"`
+	Threshold   int32     `json:"low_stock_threshold"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func FromStockAlert(a *pb.StockAlert) *StockAlert {
+	return &StockAlert{
+		Id:          a.Id,
+		ProductId:   a.ProductId,
+		ProductName: a.ProductName,
+		Kind:        a.Kind.String(),
+		Quantity:    a.Quantity,
+		Threshold:   a.Threshold,
+		CreatedAt:   time.Unix(a.CreatedAt, 0),
 	}
 }
@@ -0,0 +1,172 @@
+package form
+
+import (
+	"go-delivery/pb"
+	"strings"
+	"time"
+)
+
+type PromotionInput struct {
+	Code         string     `validate:"required,min=3,max=32" json:"code"`
+	Description  string     `validate:"lte=200" json:"description"`
+	Kind         string     `validate:"oneof=percentage fixed free_delivery" json:"kind"`
+	Value        float32    `validate:"gte=0" json:"value"`
+	MaxDiscount  float32    `validate:"gte=0" json:"max_discount"`
+	MinBasket    float32    `validate:"gte=0" json:"min_basket"`
+	SellerId     string     `validate:"omitempty,len=24,hexadecimal" json:"seller_id"`
+	Funding      string     `validate:"omitempty,oneof=seller platform" json:"funding"`
+	UsageLimit   int32      `validate:"gte=0" json:"usage_limit"`
+	PerUserLimit int32      `validate:"gte=0" json:"per_user_limit"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+}
+
+var promotionKinds = map[string]pb.PromotionKind{
+	"percentage":    pb.PromotionKind_PercentageDiscount,
+	"fixed":         pb.PromotionKind_FixedDiscount,
+	"free_delivery": pb.PromotionKind_FreeDelivery,
+}
+
+var promotionFundings = map[string]pb.PromotionFunding{
+	"seller":   pb.PromotionFunding_SellerFunded,
+	"platform": pb.PromotionFunding_PlatformFunded,
+}
+
+func (i *PromotionInput) Clear() {
+	i.Code = strings.ToUpper(strings.TrimSpace(i.Code))
+	i.Description = strings.TrimSpace(i.Description)
+}
+
+func (i *PromotionInput) ToProto() *pb.Promotion {
+	promotion := &pb.Promotion{
+		Code:         i.Code,
+		Description:  i.Description,
+		Kind:         promotionKinds[i.Kind],
+		Value:        i.Value,
+		MaxDiscount:  i.MaxDiscount,
+		MinBasket:    i.MinBasket,
+		SellerId:     i.SellerId,
+		Funding:      promotionFundings[i.Funding],
+		UsageLimit:   i.UsageLimit,
+		PerUserLimit: i.PerUserLimit,
+	}
+
+	if i.StartsAt != nil {
+		promotion.StartsAt = i.StartsAt.Unix()
+	}
+	if i.EndsAt != nil {
+		promotion.EndsAt = i.EndsAt.Unix()
+	}
+
+	return promotion
+}
+
+type Promotion struct {
+	Id           string     `json:"id"`
+	Code         string     `json:"code"`
+	Description  string     `json:"description,omitempty"`
+	Kind         string     `json:"kind"`
+	Value        float32    `json:"value"`
+	MaxDiscount  float32    `json:"max_discount,omitempty"`
+	MinBasket    float32    `json:"min_basket,omitempty"`
+	SellerId     string     `json:"seller_id,omitempty"`
+	Funding      string     `json:"funding"`
+	UsageLimit   int32      `json:"usage_limit"`
+	PerUserLimit int32      `json:"per_user_limit"`
+	Uses         int32      `json:"uses"`
+	StartsAt     *time.Time `json:"starts_at,omitempty"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"`
+	Active       bool       `json:"active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func FromPromotion(p *pb.Promotion) *Promotion {
+	promotion := &Promotion{
+		Id:           p.Id,
+		Code:         p.Code,
+		Description:  p.Description,
+		Value:        p.Value,
+		MaxDiscount:  p.MaxDiscount,
+		MinBasket:    p.MinBasket,
+		SellerId:     p.SellerId,
+		UsageLimit:   p.UsageLimit,
+		PerUserLimit: p.PerUserLimit,
+		Uses:         p.Uses,
+		Active:       p.Active,
+		CreatedAt:    time.Unix(p.CreatedAt, 0),
+		UpdatedAt:    time.Unix(p.UpdatedAt, 0),
+	}
+
+	for name, kind := range promotionKinds {
+		if kind == p.Kind {
+			promotion.Kind = name
+		}
+	}
+	for name, funding := range promotionFundings {
+		if funding == p.Funding {
+			promotion.Funding = name
+		}
+	}
+
+	if p.StartsAt > 0 {
+		startsAt := time.Unix(p.StartsAt, 0)
+		promotion.StartsAt = &startsAt
+	}
+	if p.EndsAt > 0 {
+		endsAt := time.Unix(p.EndsAt, 0)
+		promotion.EndsAt = &endsAt
+	}
+
+	return promotion
+}
+
+type PromoQuoteInput struct {
+	SellerId  string   `validate:"required" json:"seller_id"`
+	ProductId string   `validate:"required,gt=0" json:"product_id"`
+	Quantity  int32    `validate:"required" json:"quantity"`
+	VariantId string   `json:"variant_id"`
+	OptionIds []string `validate:"dive,required" json:"option_ids"`
+	PromoCode string   `validate:"required,lte=32" json:"promo_code"`
+	// DeliveryWindow quotes a scheduled order.
+	DeliveryWindow *DeliveryWindow `json:"delivery_window"`
+}
+
+type OrderDiscount struct {
+	PromotionId      string  `json:"promotion_id"`
+	Code             string  `json:"code"`
+	ItemDiscount     float32 `json:"item_discount"`
+	DeliveryDiscount float32 `json:"delivery_discount"`
+	FundedBy         string  `json:"funded_by"`
+}
+
+func FromOrderDiscount(d *pb.OrderDiscount) *OrderDiscount {
+	return &OrderDiscount{
+		PromotionId:      d.PromotionId,
+		Code:             d.Code,
+		ItemDiscount:     d.ItemDiscount,
+		DeliveryDiscount: d.DeliveryDiscount,
+		FundedBy:         d.FundedBy,
+	}
+}
+
+type OrderQuote struct {
+	Subtotal     float32        `json:"subtotal"`
+	DeliveryCost float32        `json:"delivery_cost"`
+	Discount     *OrderDiscount `json:"discount,omitempty"`
+	Amount       float32        `json:"amount"`
+}
+
+func FromOrderQuote(q *pb.OrderQuote) *OrderQuote {
+	quote := &OrderQuote{
+		Subtotal:     q.Subtotal,
+		DeliveryCost: q.DeliveryCost,
+		Amount:       q.Amount,
+	}
+
+	if q.Discount != nil {
+		quote.Discount = FromOrderDiscount(q.Discount)
+	}
+
+	return quote
+}
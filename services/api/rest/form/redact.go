@@ -0,0 +1,101 @@
+package form
+
+import "reflect"
+
+const maxRedactDepth = 8
+
+// sensitiveFields are cleared from every response, whatever the type
+// holding them, fields tagged with `redact:"true"` are cleared as well.
+var sensitiveFields = map[string]bool{
+	"Password":     true,
+	"PasswordHash": true,
+	"TOTPSecret":   true,
+}
+
+// Redact clears secret fields before a value is written to a response and
+// returns the value to write, it walks pointers, slices, maps and nested
+// structs. Pointed values are cleared in place, other values are copied.
+func Redact(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr {
+		value = settable(value)
+	}
+
+	redact(value, 0)
+
+	return value.Interface()
+}
+
+// settable copies a value that can't be set in place, like map values and
+// the values held by interfaces.
+func settable(v reflect.Value) reflect.Value {
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	return copied
+}
+
+func redact(v reflect.Value, depth int) {
+	if depth > maxRedactDepth || !v.IsValid() {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			redact(v.Elem(), depth+1)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+
+		elem := v.Elem()
+		if elem.Kind() == reflect.Ptr || !v.CanSet() {
+			redact(elem, depth+1)
+			return
+		}
+
+		copied := settable(elem)
+		redact(copied, depth+1)
+		v.Set(copied)
+	case reflect.Slice, reflect.Array:
+		for index := 0; index < v.Len(); index++ {
+			redact(v.Index(index), depth+1)
+		}
+	case reflect.Map:
+		switch v.Type().Elem().Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		default:
+			return
+		}
+
+		for _, key := range v.MapKeys() {
+			value := settable(v.MapIndex(key))
+			redact(value, depth+1)
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for index := 0; index < v.NumField(); index++ {
+			field := t.Field(index)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			value := v.Field(index)
+
+			if sensitiveFields[field.Name] || field.Tag.Get("redact") == "true" {
+				if value.CanSet() {
+					value.Set(reflect.Zero(field.Type))
+				}
+				continue
+			}
+
+			redact(value, depth+1)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package form
+
+import (
+	"reflect"
+	"testing"
+)
+
+type account struct {
+	Email        string
+	Password     string
+	PasswordHash string
+	TOTPSecret   string
+	ApiKey       string `redact:"true"`
+	Note         string `redact:"false"`
+}
+
+type team struct {
+	Name     string
+	Owner    account
+	Admin    *account
+	Members  []account
+	Invites  []*account
+	ByEmail  map[string]account
+	Extra    interface{}
+	password string
+}
+
+func secretAccount(email string) account {
+	return account{
+		Email:        email,
+		Password:     "password",
+		PasswordHash: "hash",
+		TOTPSecret:   "totp",
+		ApiKey:       "key",
+		Note:         "note",
+	}
+}
+
+func cleanAccount(email string) account {
+	return account{Email: email, Note: "note"}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		input func() interface{}
+		want  interface{}
+	}{
+		{
+			name:  "struct pointer",
+			input: func() interface{} { a := secretAccount("a"); return &a },
+			want:  func() *account { a := cleanAccount("a"); return &a }(),
+		},
+		{
+			name:  "struct value",
+			input: func() interface{} { return secretAccount("a") },
+			want:  cleanAccount("a"),
+		},
+		{
+			name: "nested structs",
+			input: func() interface{} {
+				admin := secretAccount("admin")
+				return &team{Name: "team", Owner: secretAccount("owner"), Admin: &admin, password: "kept"}
+			},
+			want: func() *team {
+				admin := cleanAccount("admin")
+				return &team{Name: "team", Owner: cleanAccount("owner"), Admin: &admin, password: "kept"}
+			}(),
+		},
+		{
+			name: "slices",
+			input: func() interface{} {
+				invite := secretAccount("invite")
+				return &team{Members: []account{secretAccount("a"), secretAccount("b")}, Invites: []*account{&invite, nil}}
+			},
+			want: func() *team {
+				invite := cleanAccount("invite")
+				return &team{Members: []account{cleanAccount("a"), cleanAccount("b")}, Invites: []*account{&invite, nil}}
+			}(),
+		},
+		{
+			name: "maps and interfaces",
+			input: func() interface{} {
+				return &team{ByEmail: map[string]account{"a": secretAccount("a")}, Extra: secretAccount("extra")}
+			},
+			want: &team{ByEmail: map[string]account{"a": cleanAccount("a")}, Extra: cleanAccount("extra")},
+		},
+		{
+			name:  "slice of values",
+			input: func() interface{} { return []account{secretAccount("a")} },
+			want:  []account{cleanAccount("a")},
+		},
+		{
+			name:  "map of interfaces",
+			input: func() interface{} { return map[string]interface{}{"user": secretAccount("a"), "total": 1} },
+			want:  map[string]interface{}{"user": cleanAccount("a"), "total": 1},
+		},
+		{
+			name:  "nil",
+			input: func() interface{} { return nil },
+			want:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Redact(test.input())
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Redact() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRedactClearsPointedValuesInPlace(t *testing.T) {
+	a := secretAccount("a")
+
+	Redact(&a)
+
+	if a != cleanAccount("a") {
+		t.Fatalf("Redact() left %+v", a)
+	}
+}
+
+func TestRedactStopsOnCycles(t *testing.T) {
+	type node struct {
+		Password string
+		Next     *node
+	}
+
+	n := &node{Password: "password"}
+	n.Next = n
+
+	Redact(n)
+
+	if n.Password != "" {
+		t.Fatal("Redact() left the password of a cyclic value")
+	}
+}
@@ -0,0 +1,74 @@
+package form
+
+import (
+	"go-delivery/pb"
+	"strings"
+	"time"
+)
+
+type ReviewInput struct {
+	SellerRating    int32  `validate:"min=1,max=5" json:"seller_rating"`
+	ProductRating   int32  `validate:"min=1,max=5" json:"product_rating"`
+	DelivererRating int32  `validate:"min=0,max=5" json:"deliverer_rating"`
+	Comment         string `validate:"lte=1000" json:"comment"`
+}
+
+func (i *ReviewInput) Clear() {
+	i.Comment = strings.TrimSpace(i.Comment)
+}
+
+type ModerationInput struct {
+	Hidden bool   `json:"hidden"`
+	Reason string `validate:"required_if=Hidden true,lte=200" json:"reason"`
+}
+
+type Review struct {
+	Id               string     `json:"id"`
+	OrderId          string     `json:"order_id"`
+	CustomerId       string     `json:"customer_id"`
+	SellerId         string     `json:"seller_id"`
+	ProductId        string     `json:"product_id"`
+	DelivererId      string     `json:"deliverer_id,omitempty"`
+	SellerRating     int32      `json:"seller_rating"`
+	ProductRating    int32      `json:"product_rating"`
+	DelivererRating  int32      `json:"deliverer_rating,omitempty"`
+	Comment          string     `json:"comment,omitempty"`
+	Hidden           bool       `json:"hidden"`
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	ModeratedBy      string     `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func FromReview(r *pb.Review) *Review {
+	review := &Review{
+		Id:               r.Id,
+		OrderId:          r.OrderId,
+		CustomerId:       r.CustomerId,
+		SellerId:         r.SellerId,
+		ProductId:        r.ProductId,
+		DelivererId:      r.DelivererId,
+		SellerRating:     r.SellerRating,
+		ProductRating:    r.ProductRating,
+		DelivererRating:  r.DelivererRating,
+		Comment:          r.Comment,
+		Hidden:           r.Hidden,
+		ModerationReason: r.ModerationReason,
+		ModeratedBy:      r.ModeratedBy,
+		CreatedAt:        time.Unix(r.CreatedAt, 0),
+		UpdatedAt:        time.Unix(r.UpdatedAt, 0),
+	}
+
+	if r.ModeratedAt > 0 {
+		moderatedAt := time.Unix(r.ModeratedAt, 0)
+		review.ModeratedAt = &moderatedAt
+	}
+
+	return review
+}
+
+type Rating struct {
+	Average float32 `json:"average"`
+	Count   int32   `json:"count"`
+}
@@ -0,0 +1,132 @@
+package form
+
+import (
+	"go-delivery/pb"
+	"strings"
+	"time"
+)
+
+type OpeningHoursInput struct {
+	Weekday int32  `validate:"gte=0,lte=6" json:"weekday"`
+	Opens   string `validate:"required,datetime=15:04" json:"opens"`
+	Closes  string `validate:"required,datetime=15:04" json:"closes"`
+}
+
+type HolidayInput struct {
+	Date string `validate:"required,datetime=2006-01-02" json:"date"`
+	Name string `validate:"lte=100" json:"name"`
+}
+
+type StoreInput struct {
+	Name         string              `validate:"required,lte=100" json:"name"`
+	Description  string              `validate:"lte=1000" json:"description"`
+	Cuisine      string              `validate:"lte=50" json:"cuisine"`
+	LogoURL      string              `validate:"omitempty,url" json:"logo_url"`
+	Timezone     string              `validate:"omitempty,timezone" json:"timezone"`
+	OpeningHours []OpeningHoursInput `validate:"dive" json:"opening_hours"`
+	Holidays     []HolidayInput      `validate:"dive" json:"holidays"`
+}
+
+func (i *StoreInput) Clear() {
+	i.Name = strings.TrimSpace(i.Name)
+	i.Description = strings.TrimSpace(i.Description)
+	i.Cuisine = strings.TrimSpace(i.Cuisine)
+	i.LogoURL = strings.TrimSpace(i.LogoURL)
+}
+
+func (i *StoreInput) ToProto(sellerId string) *pb.Store {
+	store := &pb.Store{
+		SellerId:    sellerId,
+		Name:        i.Name,
+		Description: i.Description,
+		Cuisine:     i.Cuisine,
+		LogoUrl:     i.LogoURL,
+		Timezone:    i.Timezone,
+	}
+
+	for _, hours := range i.OpeningHours {
+		store.OpeningHours = append(store.OpeningHours, &pb.OpeningHours{
+			Weekday: hours.Weekday,
+			Opens:   hours.Opens,
+			Closes:  hours.Closes,
+		})
+	}
+
+	for _, holiday := range i.Holidays {
+		store.Holidays = append(store.Holidays, &pb.Holiday{Date: holiday.Date, Name: holiday.Name})
+	}
+
+	return store
+}
+
+type PauseStoreInput struct {
+	Until  time.Time `validate:"required" json:"until"`
+	Reason string    `validate:"lte=200" json:"reason"`
+}
+
+type OpeningHours struct {
+	Weekday string `json:"weekday"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
+
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+type Store struct {
+	SellerId      string          `json:"seller_id"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Cuisine       string          `json:"cuisine"`
+	LogoURL       string          `json:"logo_url"`
+	Timezone      string          `json:"timezone"`
+	OpeningHours  []*OpeningHours `json:"opening_hours"`
+	Holidays      []*Holiday      `json:"holidays"`
+	PausedUntil   *time.Time      `json:"paused_until,omitempty"`
+	PauseReason   string          `json:"pause_reason,omitempty"`
+	Open          bool            `json:"open"`
+	ClosedReason  string          `json:"closed_reason,omitempty"`
+	RatingAverage float32         `json:"rating_average"`
+	RatingCount   int32           `json:"rating_count"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+func FromStore(s *pb.Store) *Store {
+	store := &Store{
+		SellerId:      s.SellerId,
+		Name:          s.Name,
+		Description:   s.Description,
+		Cuisine:       s.Cuisine,
+		LogoURL:       s.LogoUrl,
+		Timezone:      s.Timezone,
+		PauseReason:   s.PauseReason,
+		Open:          s.Open,
+		ClosedReason:  s.ClosedReason,
+		RatingAverage: s.RatingAverage,
+		RatingCount:   s.RatingCount,
+		CreatedAt:     time.Unix(s.CreatedAt, 0),
+		UpdatedAt:     time.Unix(s.UpdatedAt, 0),
+	}
+
+	if s.PausedUntil > 0 {
+		pausedUntil := time.Unix(s.PausedUntil, 0)
+		store.PausedUntil = &pausedUntil
+	}
+
+	for _, hours := range s.OpeningHours {
+		store.OpeningHours = append(store.OpeningHours, &OpeningHours{
+			Weekday: time.Weekday(hours.Weekday).String(),
+			Opens:   hours.Opens,
+			Closes:  hours.Closes,
+		})
+	}
+
+	for _, holiday := range s.Holidays {
+		store.Holidays = append(store.Holidays, &Holiday{Date: holiday.Date, Name: holiday.Name})
+	}
+
+	return store
+}
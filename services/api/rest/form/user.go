@@ -32,19 +32,165 @@ func (i *SignInInput) ToProto() *pb.SignInRequest {
 }
 
 type User struct {
-	Id        string    `json:"id"`
-	Email     string    `json:"email"`
+	Id          string    `json:"id"`
+	Email       string    `json:"email"`
+	Name        string    `json:"name"`
+	Phone       string    `json:"phone"`
+	Role        string    `json:"role"`
+	TOTPEnabled bool      `json:"totp_enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func FromUser(u *pb.UserProfile) *User {
+	return &User{
+		Id:          u.Id,
+		Email:       u.Email,
+		Name:        u.Name,
+		Phone:       u.Phone,
+		Role:        u.Role.String(),
+		TOTPEnabled: u.TotpEnabled,
+		CreatedAt:   time.Unix(u.CreatedAt, 0),
+		UpdatedAt:   time.Unix(u.UpdatedAt, 0),
+	}
+}
+
+type ProfileInput struct {
+	Name  string `validate:"lte=100" json:"name"`
+	Phone string `validate:"omitempty,e164" json:"phone"`
+}
+
+func (i *ProfileInput) Clear() {
+	i.Name = strings.TrimSpace(i.Name)
+	i.Phone = strings.TrimSpace(i.Phone)
+}
+
+type AddressInput struct {
+	Label        string  `validate:"lte=50" json:"label"`
+	Street       string  `validate:"required,lte=200" json:"street"`
+	Latitude     float64 `validate:"gte=-90,lte=90" json:"latitude"`
+	Longitude    float64 `validate:"gte=-180,lte=180" json:"longitude"`
+	Instructions string  `validate:"lte=500" json:"instructions"`
+	IsDefault    bool    `json:"is_default"`
+}
+
+func (i *AddressInput) Clear() {
+	i.Label = strings.TrimSpace(i.Label)
+	i.Street = strings.TrimSpace(i.Street)
+	i.Instructions = strings.TrimSpace(i.Instructions)
+}
+
+type Address struct {
+	Id           string    `json:"id"`
+	UserId       string    `json:"user_id"`
+	Label        string    `json:"label"`
+	Street       string    `json:"street"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	Instructions string    `json:"instructions"`
+	IsDefault    bool      `json:"is_default"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func FromAddress(a *pb.Address) *Address {
+	return &Address{
+		Id:           a.Id,
+		UserId:       a.UserId,
+		Label:        a.Label,
+		Street:       a.Street,
+		Latitude:     a.Latitude,
+		Longitude:    a.Longitude,
+		Instructions: a.Instructions,
+		IsDefault:    a.IsDefault,
+		CreatedAt:    time.Unix(a.CreatedAt, 0),
+		UpdatedAt:    time.Unix(a.UpdatedAt, 0),
+	}
+}
+
+type TOTPCodeInput struct {
+	Code string `validate:"required" json:"code"`
+}
+
+type TOTPChallengeInput struct {
+	Challenge string `validate:"required" json:"challenge"`
+	Code      string `json:"code"`
+}
+
+type TOTPPolicyInput struct {
+	Required bool `json:"required"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func FromTOTPEnrollment(e *pb.EnrollTOTPResponse) *TOTPEnrollment {
+	return &TOTPEnrollment{
+		Secret: e.Secret,
+		URI:    e.Uri,
+	}
+}
+
+type TOTPPolicy struct {
 	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	Required  bool      `json:"required"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-func FromUser(u *pb.User) *User {
-	return &User{
-		Id:        u.Id,
-		Email:     u.Email,
-		Role:      u.Role.String(),
-		CreatedAt: time.Unix(u.CreatedAt, 0),
-		UpdatedAt: time.Unix(u.UpdatedAt, 0),
+func FromTOTPPolicy(p *pb.TOTPPolicy) *TOTPPolicy {
+	return &TOTPPolicy{
+		Role:      p.Role.String(),
+		Required:  p.Required,
+		UpdatedAt: time.Unix(p.UpdatedAt, 0),
 	}
 }
+
+type ApiKeyInput struct {
+	Name   string   `validate:"required,lte=100" json:"name"`
+	Scopes []string `validate:"required,min=1,dive,required" json:"scopes"`
+}
+
+func (i *ApiKeyInput) Clear() {
+	i.Name = strings.TrimSpace(i.Name)
+	for index := range i.Scopes {
+		i.Scopes[index] = strings.ToLower(strings.TrimSpace(i.Scopes[index]))
+	}
+}
+
+type ApiKey struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	Revoked    bool       `json:"revoked"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func FromApiKey(k *pb.ApiKey) *ApiKey {
+	key := &ApiKey{
+		Id:        k.Id,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		Revoked:   k.Revoked,
+		CreatedAt: time.Unix(k.CreatedAt, 0),
+		UpdatedAt: time.Unix(k.UpdatedAt, 0),
+	}
+
+	if k.LastUsedAt > 0 {
+		lastUsedAt := time.Unix(k.LastUsedAt, 0)
+		key.LastUsedAt = &lastUsedAt
+	}
+
+	return key
+}
+
+// CreatedApiKey is the only response carrying the raw key.
+type CreatedApiKey struct {
+	*ApiKey
+	Key string `json:"key"`
+}
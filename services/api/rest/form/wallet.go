@@ -5,8 +5,8 @@ import (
 	"time"
 )
 
-type CreditInput struct {
-	Amount float32 `validate:"required,gte=0" json:"amount"`
+type TopUpInput struct {
+	Amount float32 `validate:"required,gte=1,lte=1000" json:"amount"`
 }
 
 type Wallet struct {
@@ -24,3 +24,47 @@ func FromWallet(w *pb.Wallet) *Wallet {
 		UpdatedAt: time.Unix(w.UpdatedAt, 0),
 	}
 }
+
+type TopUp struct {
+	Id           string     `json:"id"`
+	WalletId     string     `json:"wallet_id"`
+	Amount       float32    `json:"amount"`
+	Provider     string     `json:"provider"`
+	IntentId     string     `json:"intent_id"`
+	ClientSecret string     `json:"client_secret,omitempty"`
+	Status       string     `json:"status"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+var topUpStatuses = map[pb.TopUpStatus]string{
+	pb.TopUpStatus_TopUpPending:   "pending",
+	pb.TopUpStatus_TopUpSucceeded: "succeeded",
+	pb.TopUpStatus_TopUpFailed:    "failed",
+}
+
+// FromTopUp only shows the client secret while the payment is pending.
+func FromTopUp(t *pb.TopUp) *TopUp {
+	topUp := &TopUp{
+		Id:        t.Id,
+		WalletId:  t.WalletId,
+		Amount:    t.Amount,
+		Provider:  t.Provider,
+		IntentId:  t.IntentId,
+		Status:    topUpStatuses[t.Status],
+		CreatedAt: time.Unix(t.CreatedAt, 0),
+		UpdatedAt: time.Unix(t.UpdatedAt, 0),
+	}
+
+	if t.Status == pb.TopUpStatus_TopUpPending {
+		topUp.ClientSecret = t.ClientSecret
+	}
+
+	if t.CompletedAt > 0 {
+		completedAt := time.Unix(t.CompletedAt, 0)
+		topUp.CompletedAt = &completedAt
+	}
+
+	return topUp
+}
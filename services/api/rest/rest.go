@@ -3,6 +3,7 @@ package rest
 import (
 	"encoding/json"
 	"go-delivery/security/tokens"
+	"go-delivery/services/api/rest/form"
 	"net/http"
 	"strings"
 )
@@ -20,6 +21,8 @@ func NewErr(e error) *Err {
 }
 
 func WriteAsJson(w http.ResponseWriter, statusCode int, data interface{}) {
+	data = form.Redact(data)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	_ = json.NewEncoder(w).Encode(data)
@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"go-delivery/services/api/rest/form"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type profile struct {
+	Email        string `json:"email"`
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"password_hash,omitempty"`
+	TOTPSecret   string `json:"totp_secret,omitempty"`
+}
+
+func TestWriteAsJsonNeverEmitsSecrets(t *testing.T) {
+	secret := func() profile {
+		return profile{Email: "customer@example.com", Password: "s3cret-password", PasswordHash: "s3cret-hash", TOTPSecret: "s3cret-totp"}
+	}
+
+	owner := secret()
+
+	tests := []struct {
+		name string
+		data interface{}
+	}{
+		{"value", secret()},
+		{"pointer", &owner},
+		{"slice", []profile{secret(), secret()}},
+		{"map", map[string]interface{}{"user": secret()}},
+		{"sign up form", &form.SignUpInput{UserForm: form.UserForm{Email: "customer@example.com", Password: "s3cret-password"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			WriteAsJson(w, http.StatusOK, test.data)
+
+			body := w.Body.String()
+			if !strings.Contains(body, "customer@example.com") {
+				t.Fatalf("WriteAsJson() body = %v, want the email", body)
+			}
+
+			for _, field := range []string{"s3cret", "password_hash", "totp_secret", `"password":"s`} {
+				if strings.Contains(body, field) {
+					t.Errorf("WriteAsJson() body = %v, contains %v", body, field)
+				}
+			}
+		})
+	}
+}
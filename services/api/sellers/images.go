@@ -0,0 +1,158 @@
+package sellers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go-delivery/media"
+	"go-delivery/pb"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const imageField = "image"
+
+func (h *sellersHandler) PostProductImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	productId, err := primitive.ObjectIDFromHex(vars["product_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := h.productsClient.GetProduct(r.Context(), &pb.GetProductRequest{Id: productId.Hex()})
+	if err != nil || product.SellerId != sellerId.Hex() {
+		rest.WriteError(w, http.StatusNotFound, fmt.Errorf("product not found: id=%v", productId.Hex()))
+		return
+	}
+
+	// leaves room for the multipart headers around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxImageSize+(1<<20))
+
+	file, _, err := r.FormFile(imageField)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxImageSize+1))
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if int64(len(data)) > h.maxImageSize {
+		rest.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("image too large: max=%d bytes", h.maxImageSize))
+		return
+	}
+
+	img, err := media.ProcessImage(data)
+	if err != nil {
+		rest.WriteError(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	imageId := primitive.NewObjectID().Hex()
+	key := fmt.Sprintf("products/%s/%s.%s", productId.Hex(), imageId, img.Extension)
+	thumbnailKey := fmt.Sprintf("products/%s/%s_thumb.%s", productId.Hex(), imageId, img.ThumbnailExt)
+
+	err = h.blobs.Put(r.Context(), key, img.ContentType, bytes.NewReader(img.Data))
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.blobs.Put(r.Context(), thumbnailKey, img.ThumbnailType, bytes.NewReader(img.Thumbnail))
+	if err != nil {
+		h.deleteBlobs(r.Context(), key)
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	product, err = h.productsClient.AddProductImage(r.Context(), &pb.AddProductImageRequest{
+		ProductId: productId.Hex(),
+		Image: &pb.ProductImage{
+			Id:           imageId,
+			Key:          key,
+			ThumbnailKey: thumbnailKey,
+			Url:          h.blobs.URL(key),
+			ThumbnailUrl: h.blobs.URL(thumbnailKey),
+			ContentType:  img.ContentType,
+			Width:        int32(img.Width),
+			Height:       int32(img.Height),
+			Size:         int64(len(img.Data)),
+			CreatedAt:    time.Now().Unix(),
+		},
+	})
+	if err != nil {
+		h.deleteBlobs(r.Context(), key, thumbnailKey)
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromProduct(product))
+}
+
+func (h *sellersHandler) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	productId, err := primitive.ObjectIDFromHex(vars["product_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := h.productsClient.GetProduct(r.Context(), &pb.GetProductRequest{Id: productId.Hex()})
+	if err != nil || product.SellerId != sellerId.Hex() {
+		rest.WriteError(w, http.StatusNotFound, fmt.Errorf("product not found: id=%v", productId.Hex()))
+		return
+	}
+
+	if vars["image_id"] == "" {
+		rest.WriteError(w, http.StatusBadRequest, errors.New("image id is required"))
+		return
+	}
+
+	image, err := h.productsClient.RemoveProductImage(r.Context(), &pb.RemoveProductImageRequest{
+		ProductId: productId.Hex(),
+		ImageId:   vars["image_id"],
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.deleteBlobs(r.Context(), image.Key, image.ThumbnailKey)
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
+
+// deleteBlobs removes uploaded files, failures are only logged since the
+// files are no longer referenced.
+func (h *sellersHandler) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		err := h.blobs.Delete(ctx, key)
+		if err != nil {
+			log.Printf("error on media delete: key=%v, err=%v\n", key, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package sellers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxImportSize = 2 << 20
+
+var catalogColumns = []string{"sku", "name", "description", "category", "tags", "price", "delivery_cost", "quantity"}
+
+// PostProductsImport takes the raw csv or json file as body, the products
+// are imported in the background and the job is polled for progress.
+func (h *sellersHandler) PostProductsImport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	format, err := catalogFormat(r)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(data) > maxImportSize {
+		rest.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("import too large: max=%d bytes", maxImportSize))
+		return
+	}
+
+	job, err := h.productsClient.StartImport(r.Context(), &pb.StartImportRequest{
+		SellerId: sellerId.Hex(),
+		Format:   format,
+		Data:     data,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusAccepted, form.FromImportJob(job))
+}
+
+func (h *sellersHandler) GetProductsImport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	jobId, err := primitive.ObjectIDFromHex(vars["job_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := h.productsClient.GetImportJob(r.Context(), &pb.GetImportJobRequest{
+		Id:       jobId.Hex(),
+		SellerId: sellerId.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromImportJob(job))
+}
+
+func (h *sellersHandler) GetProductsExport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	format, err := catalogFormat(r)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.productsClient.ListSellerProducts(r.Context(), &pb.ListSellerProductsRequest{
+		SellerId:          sellerId.Hex(),
+		IncludeOutOfStock: true,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	rows := make([]*form.CatalogRow, 0)
+
+	for {
+		product, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		rows = append(rows, form.FromCatalogProduct(product))
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", sellerId.Hex(), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		rest.WriteAsJson(w, http.StatusOK, rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write(catalogColumns)
+
+	for _, row := range rows {
+		_ = writer.Write([]string{
+			row.SKU,
+			row.Name,
+			row.Description,
+			row.Category,
+			strings.Join(row.Tags, ";"),
+			strconv.FormatFloat(float64(row.Price), 'f', -1, 32),
+			strconv.FormatFloat(float64(row.DeliveryCost), 'f', -1, 32),
+			strconv.Itoa(int(row.Quantity)),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("error on products export: seller_id=%v, err=%v\n", sellerId.Hex(), err)
+	}
+}
+
+// catalogFormat reads the format query parameter and falls back to the
+// content type, csv is the default.
+func catalogFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+
+	if format == "" {
+		format = "csv"
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			format = "json"
+		}
+	}
+
+	if format != "csv" && format != "json" {
+		return "", fmt.Errorf("invalid format, must be csv or json: format=%v", format)
+	}
+
+	return format, nil
+}
@@ -0,0 +1,105 @@
+package sellers
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func (h *sellersHandler) PostProductInventory(w http.ResponseWriter, r *http.Request) {
+	productId, ok := h.sellerProductId(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.InventoryAdjustmentInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := h.productsClient.AdjustInventory(r.Context(), input.ToProto(productId.Hex()))
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromProduct(product))
+}
+
+func (h *sellersHandler) GetProductInventory(w http.ResponseWriter, r *http.Request) {
+	productId, ok := h.sellerProductId(w, r)
+	if !ok {
+		return
+	}
+
+	stream, err := h.productsClient.ListInventoryLog(r.Context(), &pb.ListInventoryLogRequest{ProductId: productId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	adjustments := make([]*form.InventoryAdjustment, 0)
+
+	for {
+		adjustment, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		adjustments = append(adjustments, form.FromInventoryAdjustment(adjustment))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, adjustments)
+}
+
+func (h *sellersHandler) GetStockAlerts(w http.ResponseWriter, r *http.Request) {
+	sellerId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.productsClient.ListStockAlerts(r.Context(), &pb.ListStockAlertsRequest{SellerId: sellerId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	alerts := make([]*form.StockAlert, 0)
+
+	for {
+		alert, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		alerts = append(alerts, form.FromStockAlert(alert))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, alerts)
+}
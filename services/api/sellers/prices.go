@@ -0,0 +1,130 @@
+package sellers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func (h *sellersHandler) PostProductPrice(w http.ResponseWriter, r *http.Request) {
+	productId, ok := h.sellerProductId(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.PriceChangeInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	change, err := h.productsClient.SchedulePrice(r.Context(), &pb.SchedulePriceRequest{
+		ProductId:     productId.Hex(),
+		Price:         input.Price,
+		DeliveryCost:  input.DeliveryCost,
+		EffectiveFrom: input.EffectiveFrom.Unix(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromPriceChange(change))
+}
+
+func (h *sellersHandler) GetProductPrices(w http.ResponseWriter, r *http.Request) {
+	productId, ok := h.sellerProductId(w, r)
+	if !ok {
+		return
+	}
+
+	stream, err := h.productsClient.ListPriceHistory(r.Context(), &pb.ListPriceHistoryRequest{ProductId: productId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	changes := make([]*form.PriceChange, 0)
+
+	for {
+		change, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		changes = append(changes, form.FromPriceChange(change))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, changes)
+}
+
+func (h *sellersHandler) DeleteProductPrice(w http.ResponseWriter, r *http.Request) {
+	productId, ok := h.sellerProductId(w, r)
+	if !ok {
+		return
+	}
+
+	priceId, err := primitive.ObjectIDFromHex(mux.Vars(r)["price_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.productsClient.CancelScheduledPrice(r.Context(), &pb.CancelScheduledPriceRequest{
+		Id:        priceId.Hex(),
+		ProductId: productId.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
+
+// sellerProductId reads the product id of the path and checks it belongs
+// to the seller of the path, the error response is written otherwise.
+func (h *sellersHandler) sellerProductId(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return primitive.NilObjectID, false
+	}
+
+	productId, err := primitive.ObjectIDFromHex(vars["product_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return primitive.NilObjectID, false
+	}
+
+	product, err := h.productsClient.GetProduct(r.Context(), &pb.GetProductRequest{Id: productId.Hex()})
+	if err != nil || product.SellerId != sellerId.Hex() {
+		rest.WriteError(w, http.StatusNotFound, fmt.Errorf("product not found: id=%v", productId.Hex()))
+		return primitive.NilObjectID, false
+	}
+
+	return productId, true
+}
@@ -0,0 +1,113 @@
+package sellers
+
+import (
+	"fmt"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func (h *sellersHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	input, err := readSearchInput(r.URL.Query())
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.productsClient.SearchProducts(r.Context(), input.ToProto())
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	products := make([]*form.Product, 0)
+
+	for {
+		product, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		products = append(products, form.FromProduct(product))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, products)
+}
+
+func readSearchInput(query url.Values) (*form.ProductSearchInput, error) {
+	input := &form.ProductSearchInput{
+		Query:    strings.TrimSpace(query.Get("q")),
+		Category: strings.TrimSpace(query.Get("category")),
+		Tag:      strings.TrimSpace(query.Get("tag")),
+		SellerId: strings.TrimSpace(query.Get("seller_id")),
+		Sort:     strings.TrimSpace(query.Get("sort")),
+	}
+
+	var err error
+
+	if value := query.Get("min_price"); value != "" {
+		input.MinPrice, err = parseFloat32("min_price", value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if value := query.Get("max_price"); value != "" {
+		input.MaxPrice, err = parseFloat32("max_price", value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if value := query.Get("in_stock"); value != "" {
+		input.InStock, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid in_stock: value=%v", value)
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		input.Limit, err = parseInt32("limit", value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if value := query.Get("offset"); value != "" {
+		input.Offset, err = parseInt32("offset", value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return input, nil
+}
+
+func parseFloat32(name, value string) (float32, error) {
+	parsed, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: value=%v", name, value)
+	}
+	return float32(parsed), nil
+}
+
+func parseInt32(name, value string) (int32, error) {
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: value=%v", name, value)
+	}
+	return int32(parsed), nil
+}
@@ -2,50 +2,201 @@ package sellers
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"go-delivery/media"
 	"go-delivery/pb"
+	"go-delivery/security/permissions"
 	"go-delivery/services/api/middlewares"
 	"go-delivery/services/api/rest"
 	"go-delivery/services/api/rest/form"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type sellersHandler struct {
 	productsClient pb.ProductsServiceClient
+	storesClient   pb.StoresServiceClient
+	blobs          media.BlobStore
+	maxImageSize   int64
 	validate       *validator.Validate
 }
 
-func RegisterSellersHandlers(productsClient pb.ProductsServiceClient, m middlewares.Middlewares, router *mux.Router) {
-
-	handler := &sellersHandler{productsClient: productsClient, validate: validator.New()}
+func RegisterSellersHandlers(
+	productsClient pb.ProductsServiceClient,
+	storesClient pb.StoresServiceClient,
+	blobs media.BlobStore,
+	maxImageSize int64,
+	m middlewares.Middlewares,
+	router *mux.Router,
+) {
+
+	handler := &sellersHandler{
+		productsClient: productsClient,
+		storesClient:   storesClient,
+		blobs:          blobs,
+		maxImageSize:   maxImageSize,
+		validate:       validator.New(),
+	}
 
 	router.Path("/sellers/{id}/products").
 		HandlerFunc(
 			m.Apply(handler.PostProduct, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Seller,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/products/import").
+		HandlerFunc(
+			m.Apply(handler.PostProductsImport, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
 			}),
 		).Methods(http.MethodPost)
 
+	router.Path("/sellers/{id}/products/import/{job_id}").
+		HandlerFunc(
+			m.Apply(handler.GetProductsImport, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/products/export").
+		HandlerFunc(
+			m.Apply(handler.GetProductsExport, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
 	router.Path("/sellers/{id}/products/{product_id}").
 		HandlerFunc(
 			m.Apply(handler.PutProduct, middlewares.Options{
 				AuthRequired: true,
 				UserRequired: true,
-				RoleRequired: pb.Role_Seller,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
 			}),
 		).Methods(http.MethodPut)
 
+	router.Path("/sellers/{id}/products/{product_id}").
+		HandlerFunc(
+			m.Apply(handler.DeleteProduct, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodDelete)
+
+	router.Path("/sellers/{id}/products/{product_id}/restore").
+		HandlerFunc(
+			m.Apply(handler.PostProductRestore, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/products/archived").
+		HandlerFunc(
+			m.Apply(handler.GetArchivedProducts, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/products/{product_id}/prices").
+		HandlerFunc(
+			m.Apply(handler.PostProductPrice, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/products/{product_id}/prices").
+		HandlerFunc(
+			m.Apply(handler.GetProductPrices, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/products/{product_id}/prices/{price_id}").
+		HandlerFunc(
+			m.Apply(handler.DeleteProductPrice, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodDelete)
+
+	router.Path("/sellers/{id}/products/{product_id}/inventory").
+		HandlerFunc(
+			m.Apply(handler.PostProductInventory, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/products/{product_id}/inventory").
+		HandlerFunc(
+			m.Apply(handler.GetProductInventory, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/stock-alerts").
+		HandlerFunc(
+			m.Apply(handler.GetStockAlerts, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/products/{product_id}/images").
+		HandlerFunc(
+			m.Apply(handler.PostProductImage, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodPost)
+
+	router.Path("/sellers/{id}/products/{product_id}/images/{image_id}").
+		HandlerFunc(
+			m.Apply(handler.DeleteProductImage, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.ProductsWriteOwn},
+			}),
+		).Methods(http.MethodDelete)
+
 	router.Path("/sellers/{id}/products").
 		HandlerFunc(
 			m.Apply(handler.GetProductsBySeller, middlewares.Options{}),
 		).Methods(http.MethodGet)
 
+	router.Path("/products/search").
+		HandlerFunc(
+			m.Apply(handler.SearchProducts, middlewares.Options{}),
+		).Methods(http.MethodGet)
+
 	router.Path("/products/{product_id}").
 		HandlerFunc(
 			m.Apply(handler.GetProduct, middlewares.Options{}),
@@ -56,6 +207,38 @@ func RegisterSellersHandlers(productsClient pb.ProductsServiceClient, m middlewa
 			m.Apply(handler.GetProducts, middlewares.Options{}),
 		).Methods(http.MethodGet)
 
+	router.Path("/sellers/{id}/store").
+		HandlerFunc(
+			m.Apply(handler.GetStore, middlewares.Options{}),
+		).Methods(http.MethodGet)
+
+	router.Path("/sellers/{id}/store").
+		HandlerFunc(
+			m.Apply(handler.PutStore, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.StoresWriteOwn},
+			}),
+		).Methods(http.MethodPut)
+
+	router.Path("/sellers/{id}/store/pause").
+		HandlerFunc(
+			m.Apply(handler.PutStorePause, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.StoresWriteOwn},
+			}),
+		).Methods(http.MethodPut)
+
+	router.Path("/sellers/{id}/store/pause").
+		HandlerFunc(
+			m.Apply(handler.DeleteStorePause, middlewares.Options{
+				AuthRequired: true,
+				UserRequired: true,
+				Permissions:  []permissions.Permission{permissions.StoresWriteOwn},
+			}),
+		).Methods(http.MethodDelete)
+
 }
 
 func (h *sellersHandler) PostProduct(w http.ResponseWriter, r *http.Request) {
@@ -88,14 +271,21 @@ func (h *sellersHandler) PostProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	product := &pb.Product{
-		Id:           primitive.NewObjectID().Hex(),
-		SellerId:     sellerId.Hex(),
-		Name:         input.Name,
-		Price:        input.Price,
-		DeliveryCost: input.DeliveryCost,
-		Quantity:     input.Quantity,
-		CreatedAt:    time.Now().Unix(),
-		UpdatedAt:    time.Now().Unix(),
+		Id:                primitive.NewObjectID().Hex(),
+		SellerId:          sellerId.Hex(),
+		Sku:               input.SKU,
+		Name:              input.Name,
+		Description:       input.Description,
+		Category:          input.Category,
+		Tags:              input.Tags,
+		Variants:          input.VariantsToProto(),
+		OptionGroups:      input.OptionGroupsToProto(),
+		Price:             input.Price,
+		DeliveryCost:      input.DeliveryCost,
+		Quantity:          input.Quantity,
+		LowStockThreshold: input.LowStockThreshold,
+		CreatedAt:         time.Now().Unix(),
+		UpdatedAt:         time.Now().Unix(),
 	}
 
 	product, err = h.productsClient.CreateProduct(r.Context(), product)
@@ -121,7 +311,7 @@ func (h *sellersHandler) PutProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	input := new(form.ProductInput)
+	input := new(form.UpdateProductInput)
 	err = json.Unmarshal(body, input)
 	if err != nil {
 		rest.WriteError(w, http.StatusBadRequest, err)
@@ -137,11 +327,17 @@ func (h *sellersHandler) PutProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	update := &pb.UpdateProductRequest{
-		Id:           productId.Hex(),
-		Name:         input.Name,
-		Price:        input.Price,
-		DeliveryCost: input.DeliveryCost,
-		Quantity:     input.Quantity,
+		Id:                productId.Hex(),
+		Sku:               input.SKU,
+		Name:              input.Name,
+		Description:       input.Description,
+		Category:          input.Category,
+		Tags:              input.Tags,
+		Variants:          input.VariantsToProto(),
+		OptionGroups:      input.OptionGroupsToProto(),
+		Price:             input.Price,
+		DeliveryCost:      input.DeliveryCost,
+		LowStockThreshold: input.LowStockThreshold,
 	}
 
 	product, err := h.productsClient.UpdateProduct(r.Context(), update)
@@ -153,6 +349,95 @@ func (h *sellersHandler) PutProduct(w http.ResponseWriter, r *http.Request) {
 	rest.WriteAsJson(w, http.StatusOK, form.FromProduct(product))
 }
 
+func (h *sellersHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	productId, err := primitive.ObjectIDFromHex(vars["product_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := h.productsClient.GetProduct(r.Context(), &pb.GetProductRequest{Id: productId.Hex()})
+	if err != nil || product.SellerId != sellerId.Hex() {
+		rest.WriteError(w, http.StatusNotFound, fmt.Errorf("product not found: id=%v", productId.Hex()))
+		return
+	}
+
+	_, err = h.productsClient.DeleteProduct(r.Context(), &pb.DeleteProductRequest{Id: productId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
+
+func (h *sellersHandler) PostProductRestore(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	productId, err := primitive.ObjectIDFromHex(vars["product_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	product, err := h.productsClient.GetProduct(r.Context(), &pb.GetProductRequest{Id: productId.Hex()})
+	if err != nil || product.SellerId != sellerId.Hex() {
+		rest.WriteError(w, http.StatusNotFound, fmt.Errorf("product not found: id=%v", productId.Hex()))
+		return
+	}
+
+	product, err = h.productsClient.RestoreProduct(r.Context(), &pb.RestoreProductRequest{Id: productId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromProduct(product))
+}
+
+func (h *sellersHandler) GetArchivedProducts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.productsClient.ListArchivedProducts(r.Context(), &pb.ListSellerProductsRequest{SellerId: sellerId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	products := make([]*form.Product, 0)
+
+	for {
+		product, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		products = append(products, form.FromProduct(product))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, products)
+}
+
 func (h *sellersHandler) GetProductsBySeller(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sellerId, err := primitive.ObjectIDFromHex(vars["id"])
@@ -161,7 +446,12 @@ func (h *sellersHandler) GetProductsBySeller(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	get := &pb.ListSellerProductsRequest{SellerId: sellerId.Hex()}
+	includeOutOfStock, _ := strconv.ParseBool(r.URL.Query().Get("include_out_of_stock"))
+
+	get := &pb.ListSellerProductsRequest{
+		SellerId:          sellerId.Hex(),
+		IncludeOutOfStock: includeOutOfStock,
+	}
 
 	stream, err := h.productsClient.ListSellerProducts(r.Context(), get)
 	if err != nil {
@@ -0,0 +1,338 @@
+package wallets
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/api/middlewares"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+func registerPayoutsHandlers(handlers *walletsHandler, m middlewares.Middlewares, router *mux.Router) {
+	router.Path("/users/{id}/bank-account").HandlerFunc(
+		m.Apply(handlers.PutBankAccount, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.PayoutsWriteOwn},
+		}),
+	).Methods(http.MethodPut)
+
+	router.Path("/users/{id}/bank-account").HandlerFunc(
+		m.Apply(handlers.GetBankAccount, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.PayoutsReadOwn},
+		}),
+	).Methods(http.MethodGet)
+
+	router.Path("/users/{id}/payout-schedule").HandlerFunc(
+		m.Apply(handlers.PutPayoutSchedule, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.PayoutsWriteOwn},
+		}),
+	).Methods(http.MethodPut)
+
+	router.Path("/users/{id}/payouts").HandlerFunc(
+		m.Apply(handlers.PostPayout, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.PayoutsWriteOwn},
+		}),
+	).Methods(http.MethodPost)
+
+	router.Path("/users/{id}/payouts").HandlerFunc(
+		m.Apply(handlers.GetUserPayouts, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.PayoutsReadOwn},
+		}),
+	).Methods(http.MethodGet)
+
+	router.Path("/users/{id}/wallets/{wallet_id}/ledger").HandlerFunc(
+		m.Apply(handlers.GetLedgerEntries, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.WalletsReadOwn},
+		}),
+	).Methods(http.MethodGet)
+
+	router.Path("/payouts/admins/{id}").HandlerFunc(
+		m.Apply(handlers.GetPayouts, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.PayoutsReadAny},
+		}),
+	).Methods(http.MethodGet)
+
+	router.Path("/payouts/{payout_id}/admins/{id}/status").HandlerFunc(
+		m.Apply(handlers.PutPayoutStatus, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.PayoutsWriteAny},
+		}),
+	).Methods(http.MethodPut)
+}
+
+func (h *walletsHandler) PutBankAccount(w http.ResponseWriter, r *http.Request) {
+	userId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.BankAccountInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input.Clear()
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	account, err := h.walletsClient.SetBankAccount(r.Context(), &pb.BankAccount{
+		UserId:     userId.Hex(),
+		HolderName: input.HolderName,
+		Iban:       input.Iban,
+		Bic:        input.Bic,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromBankAccount(account))
+}
+
+func (h *walletsHandler) GetBankAccount(w http.ResponseWriter, r *http.Request) {
+	userId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	account, err := h.walletsClient.GetBankAccount(r.Context(), &pb.GetBankAccountRequest{UserId: userId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromBankAccount(account))
+}
+
+func (h *walletsHandler) PutPayoutSchedule(w http.ResponseWriter, r *http.Request) {
+	userId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.PayoutScheduleInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	account, err := h.walletsClient.SetPayoutSchedule(r.Context(), &pb.PayoutScheduleRequest{
+		UserId:    userId.Hex(),
+		Schedule:  form.PayoutSchedules[input.Schedule],
+		Threshold: input.Threshold,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromBankAccount(account))
+}
+
+func (h *walletsHandler) PostPayout(w http.ResponseWriter, r *http.Request) {
+	userId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.PayoutInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payout, err := h.walletsClient.RequestPayout(r.Context(), &pb.Payout{
+		UserId: userId.Hex(),
+		Amount: input.Amount,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromPayout(payout))
+}
+
+func (h *walletsHandler) GetUserPayouts(w http.ResponseWriter, r *http.Request) {
+	userId, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writePayouts(w, r, &pb.ListPayoutsRequest{UserId: userId.Hex()})
+}
+
+// GetPayouts lists the payouts of every user with the ?status query
+// parameter, processing ones by default.
+func (h *walletsHandler) GetPayouts(w http.ResponseWriter, r *http.Request) {
+	status := pb.PayoutStatus_PayoutProcessing
+
+	if value := r.URL.Query().Get("status"); value != "" {
+		var ok bool
+		status, ok = form.PayoutStatuses[value]
+		if !ok {
+			rest.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payout status: status=%v", value))
+			return
+		}
+	}
+
+	h.writePayouts(w, r, &pb.ListPayoutsRequest{Status: status})
+}
+
+func (h *walletsHandler) PutPayoutStatus(w http.ResponseWriter, r *http.Request) {
+	payoutId, err := primitive.ObjectIDFromHex(mux.Vars(r)["payout_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.PayoutStatusInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input.Clear()
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payout, err := h.walletsClient.UpdatePayoutStatus(r.Context(), &pb.UpdatePayoutStatusRequest{
+		Id:     payoutId.Hex(),
+		Status: form.PayoutStatuses[input.Status],
+		Reason: input.Reason,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromPayout(payout))
+}
+
+func (h *walletsHandler) GetLedgerEntries(w http.ResponseWriter, r *http.Request) {
+	walletId, err := primitive.ObjectIDFromHex(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.walletsClient.ListLedgerEntries(r.Context(), &pb.ListLedgerEntriesRequest{WalletId: walletId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	entries := make([]*form.LedgerEntry, 0)
+
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		entries = append(entries, form.FromLedgerEntry(entry))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, entries)
+}
+
+func (h *walletsHandler) writePayouts(w http.ResponseWriter, r *http.Request, req *pb.ListPayoutsRequest) {
+	stream, err := h.walletsClient.ListPayouts(r.Context(), req)
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payouts := make([]*form.Payout, 0)
+
+	for {
+		payout, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		payouts = append(payouts, form.FromPayout(payout))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, payouts)
+}
@@ -0,0 +1,169 @@
+package wallets
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/api/middlewares"
+	"go-delivery/services/api/rest"
+	"go-delivery/services/api/rest/form"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+)
+
+const (
+	paymentSignatureHeader = "X-Payment-Signature"
+	maxWebhookSize         = 64 << 10
+)
+
+func registerTopUpsHandlers(handlers *walletsHandler, m middlewares.Middlewares, router *mux.Router) {
+	router.Path("/users/{id}/wallets/{wallet_id}/top-ups").HandlerFunc(
+		m.Apply(handlers.PostTopUp, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.WalletsWriteOwn},
+		}),
+	).Methods(http.MethodPost)
+
+	router.Path("/users/{id}/wallets/{wallet_id}/top-ups").HandlerFunc(
+		m.Apply(handlers.GetTopUps, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.WalletsReadOwn},
+		}),
+	).Methods(http.MethodGet)
+
+	router.Path("/users/{id}/wallets/{wallet_id}/top-ups/{top_up_id}/confirm").HandlerFunc(
+		m.Apply(handlers.PostConfirmTopUp, middlewares.Options{
+			AuthRequired: true,
+			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.WalletsWriteOwn},
+		}),
+	).Methods(http.MethodPost)
+
+	// the provider authenticates with the payload signature, checked by the
+	// wallets service.
+	router.Path("/payments/webhooks/{provider}").HandlerFunc(
+		m.Apply(handlers.PostPaymentWebhook, middlewares.Options{}),
+	).Methods(http.MethodPost)
+}
+
+func (h *walletsHandler) PostTopUp(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	walletId, err := primitive.ObjectIDFromHex(vars["wallet_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	input := new(form.TopUpInput)
+	err = json.Unmarshal(body, input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.validate.Struct(input)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	topUp, err := h.walletsClient.CreateTopUp(r.Context(), &pb.TopUp{
+		WalletId: walletId.Hex(),
+		Amount:   input.Amount,
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusCreated, form.FromTopUp(topUp))
+}
+
+func (h *walletsHandler) PostConfirmTopUp(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	walletId, err := primitive.ObjectIDFromHex(vars["wallet_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	topUpId, err := primitive.ObjectIDFromHex(vars["top_up_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	topUp, err := h.walletsClient.ConfirmTopUp(r.Context(), &pb.ConfirmTopUpRequest{
+		Id:       topUpId.Hex(),
+		WalletId: walletId.Hex(),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, form.FromTopUp(topUp))
+}
+
+func (h *walletsHandler) GetTopUps(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	walletId, err := primitive.ObjectIDFromHex(vars["wallet_id"])
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stream, err := h.walletsClient.ListTopUps(r.Context(), &pb.ListTopUpsRequest{WalletId: walletId.Hex()})
+	if err != nil {
+		rest.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	topUps := make([]*form.TopUp, 0)
+
+	for {
+		topUp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rest.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		topUps = append(topUps, form.FromTopUp(topUp))
+	}
+
+	rest.WriteAsJson(w, http.StatusOK, topUps)
+}
+
+func (h *walletsHandler) PostPaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxWebhookSize)
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.walletsClient.HandlePaymentWebhook(r.Context(), &pb.PaymentWebhookRequest{
+		Provider:  mux.Vars(r)["provider"],
+		Payload:   payload,
+		Signature: r.Header.Get(paymentSignatureHeader),
+	})
+	if err != nil {
+		rest.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rest.WriteAsJson(w, http.StatusNoContent, nil)
+}
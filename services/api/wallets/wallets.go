@@ -1,10 +1,10 @@
 package wallets
 
 import (
-	"encoding/json"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"go-delivery/pb"
+	"go-delivery/security/permissions"
 	"go-delivery/services/api/middlewares"
 	"go-delivery/services/api/rest"
 	"go-delivery/services/api/rest/form"
@@ -26,36 +26,34 @@ func RegisterWalletsHandlers(walletsClient pb.WalletsServiceClient, m middleware
 		m.Apply(handlers.CreateWallet, middlewares.Options{
 			AuthRequired: true,
 			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.WalletsWriteOwn},
 		}),
 	).Methods(http.MethodPost)
 
-	router.Path("/users/{id}/wallets/{wallet_id}").HandlerFunc(
-		m.Apply(handlers.PostCredit, middlewares.Options{
-			AuthRequired: true,
-			UserRequired: true,
-		}),
-	).Methods(http.MethodPut)
-
 	router.Path("/users/{id}/wallets").HandlerFunc(
 		m.Apply(handlers.GetUserWallet, middlewares.Options{
 			AuthRequired: true,
 			UserRequired: true,
+			Permissions:  []permissions.Permission{permissions.WalletsReadOwn},
 		}),
 	).Methods(http.MethodGet)
 
 	router.Path("/wallets/{id}").HandlerFunc(
 		m.Apply(handlers.GetWallet, middlewares.Options{
 			AuthRequired: true,
-			RoleRequired: pb.Role_Admin,
+			Permissions:  []permissions.Permission{permissions.WalletsReadAny},
 		}),
 	).Methods(http.MethodGet)
 
 	router.Path("/wallets").HandlerFunc(
 		m.Apply(handlers.GetWallets, middlewares.Options{
 			AuthRequired: true,
-			RoleRequired: pb.Role_Admin,
+			Permissions:  []permissions.Permission{permissions.WalletsReadAny},
 		}),
 	).Methods(http.MethodGet)
+
+	registerTopUpsHandlers(&handlers, m, router)
+	registerPayoutsHandlers(&handlers, m, router)
 }
 
 func (h *walletsHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
@@ -83,47 +81,6 @@ func (h *walletsHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
 	rest.WriteAsJson(w, http.StatusCreated, form.FromWallet(wallet))
 }
 
-func (h *walletsHandler) PostCredit(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	walletId, err := primitive.ObjectIDFromHex(vars["wallet_id"])
-	if err != nil {
-		rest.WriteError(w, http.StatusBadRequest, err)
-		return
-	}
-
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		rest.WriteError(w, http.StatusBadRequest, err)
-		return
-	}
-
-	input := new(form.CreditInput)
-	err = json.Unmarshal(body, input)
-	if err != nil {
-		rest.WriteError(w, http.StatusBadRequest, err)
-		return
-	}
-
-	err = h.validate.Struct(input)
-	if err != nil {
-		rest.WriteError(w, http.StatusBadRequest, err)
-		return
-	}
-
-	credit := &pb.CreditRequest{
-		WalletId: walletId.Hex(),
-		Amount:   input.Amount,
-	}
-
-	wallet, err := h.walletsClient.Credit(r.Context(), credit)
-	if err != nil {
-		rest.WriteError(w, http.StatusUnprocessableEntity, err)
-		return
-	}
-
-	rest.WriteAsJson(w, http.StatusOK, form.FromWallet(wallet))
-}
-
 func (h *walletsHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	walletId, err := primitive.ObjectIDFromHex(vars["id"])
@@ -7,20 +7,29 @@ import (
 	"github.com/joho/godotenv"
 	"go-delivery/db"
 	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/security/mtls"
+	"go-delivery/security/permissions"
+	"go-delivery/security/tokens"
 	"go-delivery/services/orders/service"
 	"go-delivery/services/orders/store"
 	"go-delivery/util"
 	"google.golang.org/grpc"
 	"log"
 	"net"
+	"os"
 	"time"
 )
 
 var (
 	port         int
+	tlsConfig    mtls.Config
 	accountsAddr string
 	walletsAddr  string
 	sellersAddr  string
+	platformUser string
+	leadTime     time.Duration
+	releaseDelay time.Duration
 )
 
 func init() {
@@ -32,12 +41,22 @@ func init() {
 	flag.StringVar(&accountsAddr, "accounts_addr", "localhost:7500", "accounts service address")
 	flag.StringVar(&walletsAddr, "wallets_addr", "localhost:7501", "wallets service address")
 	flag.StringVar(&sellersAddr, "sellers_addr", "localhost:7502", "sellers service address")
+	flag.StringVar(&platformUser, "platform_user_id", os.Getenv("PLATFORM_USER_ID"), "user owning the wallet funding platform promotions")
+	flag.DurationVar(&leadTime, "schedule_lead_time", 45*time.Minute, "time before the delivery window scheduled orders are released to the seller")
+	flag.DurationVar(&releaseDelay, "order_scheduler_interval", time.Minute, "interval between scheduled orders checks")
 	flag.IntVar(&port, "port", 7503, "orders service port")
 
+	tlsConfig.RegisterFlags()
+
 	flag.Parse()
 }
 
 func main() {
+	err := tokens.CheckSecrets()
+	if err != nil {
+		log.Panicln(err)
+	}
+
 	cfg := db.NewConfig()
 
 	log.Println("loading db configs: ", cfg.URI())
@@ -48,13 +67,20 @@ func main() {
 	dbConn := db.New(ctx, cfg)
 	defer dbConn.Close(ctx)
 
-	err := dbConn.Ping(ctx)
+	err = dbConn.Ping(ctx)
 	if err != nil {
 		log.Panicln(err)
 	}
 	log.Println("database connected successfully")
 
-	accountsConn, err := grpc.Dial(accountsAddr, grpc.WithInsecure())
+	tlsOption, err := tlsConfig.DialOption()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	dialOptions := append(credentials.DialOptions(credentials.ServiceOrders), tlsOption)
+
+	accountsConn, err := grpc.Dial(accountsAddr, dialOptions...)
 	if err != nil {
 		log.Panicln(err)
 	}
@@ -62,7 +88,7 @@ func main() {
 
 	accountsClient := pb.NewAccountsServiceClient(accountsConn)
 
-	walletsConn, err := grpc.Dial(walletsAddr, grpc.WithInsecure())
+	walletsConn, err := grpc.Dial(walletsAddr, dialOptions...)
 	if err != nil {
 		log.Panicln(err)
 	}
@@ -70,24 +96,79 @@ func main() {
 
 	walletsClient := pb.NewWalletsServiceClient(walletsConn)
 
-	sellersConn, err := grpc.Dial(sellersAddr, grpc.WithInsecure())
+	sellersConn, err := grpc.Dial(sellersAddr, dialOptions...)
 	if err != nil {
 		log.Panicln(err)
 	}
 	defer util.HandleClose(sellersConn)
 
 	productsClient := pb.NewProductsServiceClient(sellersConn)
+	storesClient := pb.NewStoresServiceClient(sellersConn)
 
 	ordersStore := store.NewOrdersStore(dbConn.DB())
-	ordersService := service.NewService(ordersStore, walletsClient, accountsClient, productsClient)
+	promotionsStore := store.NewPromotionsStore(dbConn.DB())
+
+	err = promotionsStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	ordersService := service.NewService(ordersStore, promotionsStore, platformUser, leadTime, walletsClient, accountsClient, productsClient, storesClient)
+	promotionsService := service.NewPromotionsService(promotionsStore)
+
+	go service.NewOrderScheduler(ordersStore, leadTime).Run(context.Background(), releaseDelay)
+
+	reviewsStore := store.NewReviewsStore(dbConn.DB())
+
+	err = reviewsStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	reviewsService := service.NewReviewsService(reviewsStore, ordersStore, productsClient, storesClient)
+
+	disputesStore := store.NewDisputesStore(dbConn.DB())
+
+	err = disputesStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	disputesService := service.NewDisputesService(disputesStore, ordersStore, walletsClient, platformUser)
+
+	favoritesStore := store.NewFavoritesStore(dbConn.DB())
+
+	err = favoritesStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	favoritesService := service.NewFavoritesService(favoritesStore, accountsClient, productsClient, storesClient)
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		log.Panicln(err)
 	}
 
-	grpcServer := grpc.NewServer()
+	permissionsConfig := permissions.NewConfig()
+	rules := service.NewRules(ordersStore, promotionsStore, disputesStore)
+
+	serverOptions, err := tlsConfig.ServerOptions()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	serverOptions = append(serverOptions,
+		grpc.UnaryInterceptor(permissions.UnaryServerInterceptor(permissionsConfig, rules)),
+		grpc.StreamInterceptor(permissions.StreamServerInterceptor(permissionsConfig, rules)),
+	)
+
+	grpcServer := grpc.NewServer(serverOptions...)
 	pb.RegisterOrdersServiceServer(grpcServer, ordersService)
+	pb.RegisterPromotionsServiceServer(grpcServer, promotionsService)
+	pb.RegisterReviewsServiceServer(grpcServer, reviewsService)
+	pb.RegisterDisputesServiceServer(grpcServer, disputesService)
+	pb.RegisterFavoritesServiceServer(grpcServer, favoritesService)
 
 	defer grpcServer.Stop()
 
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	disputeWindow         = 7 * 24 * time.Hour
+	maxDisputeDescription = 1000
+	maxResolutionNote     = 500
+)
+
+type disputesService struct {
+	disputesStore  store.DisputesStore
+	ordersStore    store.OrdersStore
+	walletsClient  pb.WalletsServiceClient
+	platformUserId string
+	pb.UnimplementedDisputesServiceServer
+}
+
+func NewDisputesService(
+	disputesStore store.DisputesStore,
+	ordersStore store.OrdersStore,
+	walletsClient pb.WalletsServiceClient,
+	platformUserId string,
+) pb.DisputesServiceServer {
+
+	return &disputesService{
+		disputesStore:  disputesStore,
+		ordersStore:    ordersStore,
+		walletsClient:  walletsClient,
+		platformUserId: platformUserId,
+	}
+}
+
+// OpenDispute claims a problem with a delivered order, up to seven days
+// after the delivery and once per order.
+func (s *disputesService) OpenDispute(ctx context.Context, req *pb.Dispute) (*pb.Dispute, error) {
+	orderId, err := primitive.ObjectIDFromHex(req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := pb.DisputeReason_name[int32(req.Reason)]; !ok {
+		return nil, fmt.Errorf("invalid dispute reason: reason=%v", req.Reason)
+	}
+
+	description := strings.TrimSpace(req.Description)
+	if description == "" || len(description) > maxDisputeDescription {
+		return nil, fmt.Errorf("invalid dispute, description is required and must have at most %d characters", maxDisputeDescription)
+	}
+
+	order, err := s.ordersStore.Get(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.CustomerId != req.CustomerId {
+		return nil, fmt.Errorf("order not found: orderId=%v", req.OrderId)
+	}
+
+	if order.Status != int32(pb.OrderStatus_Delivered) {
+		return nil, fmt.Errorf("invalid dispute, order not delivered: orderId=%v", req.OrderId)
+	}
+
+	deliveredAt := order.UpdatedAt
+	if order.DeliveredAt != nil {
+		deliveredAt = *order.DeliveredAt
+	}
+
+	if time.Since(deliveredAt) > disputeWindow {
+		return nil, fmt.Errorf("invalid dispute, orders can be disputed up to %v after delivery: orderId=%v", disputeWindow, req.OrderId)
+	}
+
+	dispute := &store.Dispute{
+		Id:          primitive.NewObjectID(),
+		OrderId:     order.Id.Hex(),
+		CustomerId:  order.CustomerId,
+		SellerId:    order.SellerId,
+		DelivererId: order.DeliveryId,
+		Reason:      int32(req.Reason),
+		Description: description,
+		Status:      int32(pb.DisputeStatus_DisputeOpen),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	err = s.disputesStore.Create(ctx, dispute)
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute.ToProto(), nil
+}
+
+func (s *disputesService) GetDispute(ctx context.Context, req *pb.GetDisputeRequest) (*pb.Dispute, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute, err := s.disputesStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute.ToProto(), nil
+}
+
+func (s *disputesService) ListDisputes(req *pb.ListDisputesRequest, stream pb.DisputesService_ListDisputesServer) error {
+	var disputes []*store.Dispute
+	var err error
+
+	if req.CustomerId != "" {
+		disputes, err = s.disputesStore.GetByCustomer(stream.Context(), req.CustomerId)
+	} else {
+		disputes, err = s.disputesStore.GetByStatus(stream.Context(), int32(req.Status))
+	}
+	if err != nil {
+		return err
+	}
+
+	for index := range disputes {
+		err = stream.Send(disputes[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResolveDispute refunds the customer, the seller and deliverer charges are
+// bounded by what they were paid for the order and the platform wallet pays
+// what is left of the refund. The resolution is saved before its transfers,
+// resolving a dispute left resolving applies the missing ones.
+func (s *disputesService) ResolveDispute(ctx context.Context, req *pb.ResolveDisputeRequest) (*pb.Dispute, error) {
+	dispute, order, err := s.disputeToClose(ctx, req.Id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if dispute.Status == int32(pb.DisputeStatus_DisputeResolving) {
+		return s.applyResolution(ctx, dispute, order)
+	}
+
+	adminId, err := callerId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refundable := roundCents(order.Amount - order.Refunded)
+
+	switch {
+	case req.RefundAmount <= 0 || req.RefundAmount > refundable:
+		return nil, fmt.Errorf("invalid refund, amount must be between 0 and %.2f: amount=%v", refundable, req.RefundAmount)
+	case req.SellerCharge < 0 || req.SellerCharge > order.SellerPayout():
+		return nil, fmt.Errorf("invalid refund, seller charge must be between 0 and %.2f", order.SellerPayout())
+	case req.DelivererCharge < 0 || req.DelivererCharge > order.DelivererPayout():
+		return nil, fmt.Errorf("invalid refund, deliverer charge must be between 0 and %.2f", order.DelivererPayout())
+	case req.DelivererCharge > 0 && order.DeliveryId == "":
+		return nil, errors.New("invalid refund, order has no deliverer")
+	case req.SellerCharge+req.DelivererCharge > req.RefundAmount:
+		return nil, errors.New("invalid refund, charges exceed the refund amount")
+	}
+
+	platformCharge := roundCents(req.RefundAmount - req.SellerCharge - req.DelivererCharge)
+	if platformCharge > 0 && s.platformUserId == "" {
+		return nil, errors.New("invalid refund, platform wallet not configured to cover the rest of the refund")
+	}
+
+	now := time.Now()
+
+	dispute.Status = int32(pb.DisputeStatus_DisputeResolving)
+	dispute.RefundAmount = req.RefundAmount
+	dispute.SellerCharge = req.SellerCharge
+	dispute.DelivererCharge = req.DelivererCharge
+	dispute.PlatformCharge = platformCharge
+	dispute.ResolutionNote = strings.TrimSpace(req.Note)
+	dispute.ResolvedBy = adminId
+	dispute.ResolvedAt = &now
+	dispute.UpdatedAt = now
+
+	if len(dispute.ResolutionNote) > maxResolutionNote {
+		return nil, fmt.Errorf("invalid resolution, note must have at most %d characters", maxResolutionNote)
+	}
+
+	err = s.disputesStore.Close(ctx, dispute)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.applyResolution(ctx, dispute, order)
+}
+
+// disputeCharge is a leg of the refund taken back from a wallet.
+type disputeCharge struct {
+	userId string
+	amount float32
+	leg    string
+}
+
+// applyResolution moves the cash of a resolving dispute then marks it as
+// resolved. Every transfer has a reference of the resolution, so a transfer
+// already applied is skipped when the resolution is resumed. A charge the
+// wallet can't cover reopens the dispute instead of leaving it resolving.
+func (s *disputesService) applyResolution(ctx context.Context, dispute *store.Dispute, order *store.Order) (*pb.Dispute, error) {
+	// the resolution time is stored in milliseconds, a resumed resolution
+	// must build the same references.
+	resolution := dispute.ResolvedAt.UnixNano() / int64(time.Millisecond)
+	reference := "dispute:" + dispute.Id.Hex() + ":" + strconv.FormatInt(resolution, 10)
+
+	charges := []disputeCharge{
+		{order.SellerId, dispute.SellerCharge, "seller"},
+		{order.DeliveryId, dispute.DelivererCharge, "deliverer"},
+		{s.platformUserId, dispute.PlatformCharge, "platform"},
+	}
+
+	for index, charge := range charges {
+		if charge.amount <= 0 {
+			continue
+		}
+
+		err := debitUser(ctx, s.walletsClient, charge.userId, charge.amount, reference+":"+charge.leg)
+		if status.Code(err) == codes.FailedPrecondition {
+			return nil, s.reopen(ctx, dispute, charges[:index], reference, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to charge back the refund, dispute left resolving: userId=%v, err=%v", charge.userId, err)
+		}
+	}
+
+	err := creditUser(ctx, s.walletsClient, order.CustomerId, dispute.RefundAmount, reference+":refund")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.ordersStore.AddRefund(ctx, order.Id, dispute.RefundAmount, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	err = s.disputesStore.Finish(ctx, dispute.Id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute.Status = int32(pb.DisputeStatus_DisputeResolved)
+	dispute.UpdatedAt = now
+
+	return dispute.ToProto(), nil
+}
+
+// reopen gives back the charges taken for a resolution that can't be
+// applied, then reopens the dispute. A charge that can't be given back
+// leaves the dispute resolving, resolving it again retries the reopening.
+func (s *disputesService) reopen(ctx context.Context, dispute *store.Dispute, taken []disputeCharge, reference string, cause error) error {
+	for index := len(taken) - 1; index >= 0; index-- {
+		charge := taken[index]
+		if charge.amount <= 0 {
+			continue
+		}
+
+		err := creditUser(ctx, s.walletsClient, charge.userId, charge.amount, reference+":"+charge.leg+":undo")
+		if err != nil {
+			return fmt.Errorf("failed to give back the charge, dispute left resolving: userId=%v, err=%v", charge.userId, err)
+		}
+	}
+
+	err := s.disputesStore.Reopen(ctx, dispute.Id, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return status.Errorf(codes.FailedPrecondition, "failed to charge back the refund, dispute reopened: err=%v", status.Convert(cause).Message())
+}
+
+func (s *disputesService) RejectDispute(ctx context.Context, req *pb.RejectDisputeRequest) (*pb.Dispute, error) {
+	dispute, _, err := s.disputeToClose(ctx, req.Id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	adminId, err := callerId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if note == "" || len(note) > maxResolutionNote {
+		return nil, fmt.Errorf("invalid resolution, note is required and must have at most %d characters", maxResolutionNote)
+	}
+
+	now := time.Now()
+
+	dispute.Status = int32(pb.DisputeStatus_DisputeRejected)
+	dispute.ResolutionNote = note
+	dispute.ResolvedBy = adminId
+	dispute.ResolvedAt = &now
+	dispute.UpdatedAt = now
+
+	err = s.disputesStore.Close(ctx, dispute)
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute.ToProto(), nil
+}
+
+// disputeToClose loads an open dispute and its order, a resolving dispute is
+// also returned when resuming is allowed.
+func (s *disputesService) disputeToClose(ctx context.Context, disputeId string, resuming bool) (*store.Dispute, *store.Order, error) {
+	id, err := primitive.ObjectIDFromHex(disputeId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dispute, err := s.disputesStore.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	open := dispute.Status == int32(pb.DisputeStatus_DisputeOpen)
+	if !open && !(resuming && dispute.Status == int32(pb.DisputeStatus_DisputeResolving)) {
+		return nil, nil, fmt.Errorf("dispute already closed: id=%v", disputeId)
+	}
+
+	orderId, err := primitive.ObjectIDFromHex(dispute.OrderId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	order, err := s.ordersStore.Get(ctx, orderId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dispute, order, nil
+}
+
+// callerId returns the id of the user whose token came with the call, the
+// admin closing a dispute is never taken from the request.
+func callerId(ctx context.Context) (string, error) {
+	caller, ok := credentials.FromContext(ctx)
+	if !ok || caller.User == nil || caller.User.Id == "" {
+		return "", errors.New("invalid caller, a user token is required")
+	}
+
+	return caller.User.Id, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+// walletsClient records the movements of the users whose wallet id is their
+// user id, debits of the failing users return their error.
+type walletsClient struct {
+	pb.WalletsServiceClient
+	failing   map[string]error
+	movements []string
+}
+
+func (c *walletsClient) GetUserWallet(_ context.Context, req *pb.GetUserWalletRequest, _ ...grpc.CallOption) (*pb.Wallet, error) {
+	return &pb.Wallet{Id: req.UserId}, nil
+}
+
+func (c *walletsClient) Debit(_ context.Context, req *pb.DebitRequest, _ ...grpc.CallOption) (*pb.Wallet, error) {
+	if err := c.failing[req.WalletId]; err != nil {
+		return nil, err
+	}
+	c.movements = append(c.movements, "debit "+req.WalletId+" "+req.Reference)
+	return &pb.Wallet{Id: req.WalletId}, nil
+}
+
+func (c *walletsClient) Credit(_ context.Context, req *pb.CreditRequest, _ ...grpc.CallOption) (*pb.Wallet, error) {
+	c.movements = append(c.movements, "credit "+req.WalletId+" "+req.Reference)
+	return &pb.Wallet{Id: req.WalletId}, nil
+}
+
+// disputesStore records the status changes of a resolving dispute.
+type disputesStore struct {
+	store.DisputesStore
+	finished bool
+	reopened bool
+}
+
+func (s *disputesStore) Finish(context.Context, primitive.ObjectID, time.Time) error {
+	s.finished = true
+	return nil
+}
+
+func (s *disputesStore) Reopen(context.Context, primitive.ObjectID, time.Time) error {
+	s.reopened = true
+	return nil
+}
+
+type refundsStore struct {
+	store.OrdersStore
+}
+
+func (s *refundsStore) AddRefund(context.Context, primitive.ObjectID, float32, string) error {
+	return nil
+}
+
+func TestApplyResolution(t *testing.T) {
+	resolvedAt := time.Unix(1700000000, 123000000)
+
+	dispute := &store.Dispute{
+		Id:              primitive.NewObjectID(),
+		Status:          int32(pb.DisputeStatus_DisputeResolving),
+		RefundAmount:    10,
+		SellerCharge:    6,
+		DelivererCharge: 4,
+		ResolvedAt:      &resolvedAt,
+	}
+	order := &store.Order{Id: primitive.NewObjectID(), CustomerId: "customer", SellerId: "seller", DeliveryId: "deliverer"}
+
+	reference := "dispute:" + dispute.Id.Hex() + ":1700000000123"
+
+	t.Run("applied", func(t *testing.T) {
+		wallets := &walletsClient{}
+		disputes := &disputesStore{}
+		s := &disputesService{disputesStore: disputes, ordersStore: &refundsStore{}, walletsClient: wallets}
+
+		res, err := s.applyResolution(context.Background(), dispute, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Status != pb.DisputeStatus_DisputeResolved || !disputes.finished {
+			t.Fatalf("applyResolution() = %v, want the dispute resolved", res.Status)
+		}
+
+		want := []string{
+			"debit seller " + reference + ":seller",
+			"debit deliverer " + reference + ":deliverer",
+			"credit customer " + reference + ":refund",
+		}
+		if len(wallets.movements) != len(want) {
+			t.Fatalf("movements = %v, want %v", wallets.movements, want)
+		}
+		for index := range want {
+			if wallets.movements[index] != want[index] {
+				t.Fatalf("movements = %v, want %v", wallets.movements, want)
+			}
+		}
+	})
+
+	t.Run("charge not covered", func(t *testing.T) {
+		wallets := &walletsClient{failing: map[string]error{"deliverer": status.Error(codes.FailedPrecondition, "insufficient wallet cash")}}
+		disputes := &disputesStore{}
+		s := &disputesService{disputesStore: disputes, ordersStore: &refundsStore{}, walletsClient: wallets}
+
+		_, err := s.applyResolution(context.Background(), dispute, order)
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("applyResolution() = %v, want %v", err, codes.FailedPrecondition)
+		}
+
+		if !disputes.reopened || disputes.finished {
+			t.Fatal("applyResolution() didn't reopen the dispute")
+		}
+
+		want := []string{"debit seller " + reference + ":seller", "credit seller " + reference + ":seller:undo"}
+		if len(wallets.movements) != len(want) || wallets.movements[0] != want[0] || wallets.movements[1] != want[1] {
+			t.Fatalf("movements = %v, want %v", wallets.movements, want)
+		}
+	})
+
+	t.Run("transient failure", func(t *testing.T) {
+		wallets := &walletsClient{failing: map[string]error{"deliverer": errors.New("connection reset")}}
+		disputes := &disputesStore{}
+		s := &disputesService{disputesStore: disputes, ordersStore: &refundsStore{}, walletsClient: wallets}
+
+		_, err := s.applyResolution(context.Background(), dispute, order)
+		if err == nil {
+			t.Fatal("applyResolution() = nil, want an error")
+		}
+
+		if disputes.reopened || disputes.finished || len(wallets.movements) != 1 {
+			t.Fatalf("movements = %v, want the dispute left resolving", wallets.movements)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type favoritesService struct {
+	favoritesStore store.FavoritesStore
+	accountsClient pb.AccountsServiceClient
+	productsClient pb.ProductsServiceClient
+	storesClient   pb.StoresServiceClient
+	pb.UnimplementedFavoritesServiceServer
+}
+
+func NewFavoritesService(
+	favoritesStore store.FavoritesStore,
+	accountsClient pb.AccountsServiceClient,
+	productsClient pb.ProductsServiceClient,
+	storesClient pb.StoresServiceClient,
+) pb.FavoritesServiceServer {
+
+	return &favoritesService{
+		favoritesStore: favoritesStore,
+		accountsClient: accountsClient,
+		productsClient: productsClient,
+		storesClient:   storesClient,
+	}
+}
+
+// AddFavorite saves a product or the store of a seller, archived products
+// can't be saved.
+func (s *favoritesService) AddFavorite(ctx context.Context, req *pb.Favorite) (*pb.Favorite, error) {
+	_, err := primitive.ObjectIDFromHex(req.TargetId)
+	if err != nil {
+		return nil, err
+	}
+
+	favorite := &store.Favorite{
+		Id:         primitive.NewObjectID(),
+		CustomerId: req.CustomerId,
+		Kind:       int32(req.Kind),
+		TargetId:   req.TargetId,
+		CreatedAt:  time.Now(),
+	}
+
+	switch req.Kind {
+	case pb.FavoriteKind_FavoriteProduct:
+		product, err := s.productsClient.GetProduct(ctx, &pb.GetProductRequest{Id: req.TargetId})
+		if err != nil || product.Archived {
+			return nil, fmt.Errorf("product not found: productId=%v", req.TargetId)
+		}
+
+		favorite.SellerId = product.SellerId
+		favorite.Name = product.Name
+	case pb.FavoriteKind_FavoriteStore:
+		seller, err := s.accountsClient.GetUser(ctx, &pb.GetUserRequest{Id: req.TargetId})
+		if err != nil || seller.Role != pb.Role_Seller {
+			return nil, fmt.Errorf("store not found: sellerId=%v", req.TargetId)
+		}
+
+		sellerStore, err := s.storesClient.GetStore(ctx, &pb.GetStoreRequest{SellerId: req.TargetId})
+		if err != nil {
+			return nil, err
+		}
+
+		favorite.SellerId = req.TargetId
+		favorite.Name = sellerStore.Name
+	default:
+		return nil, fmt.Errorf("invalid favorite kind: kind=%v", req.Kind)
+	}
+
+	err = s.favoritesStore.Create(ctx, favorite)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.describe(ctx, favorite), nil
+}
+
+func (s *favoritesService) RemoveFavorite(ctx context.Context, req *pb.RemoveFavoriteRequest) (*empty.Empty, error) {
+	err := s.favoritesStore.Delete(ctx, req.CustomerId, int32(req.Kind), req.TargetId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *favoritesService) ListFavorites(req *pb.ListFavoritesRequest, stream pb.FavoritesService_ListFavoritesServer) error {
+	favorites, err := s.favoritesStore.GetByCustomer(stream.Context(), req.CustomerId, int32(req.Kind))
+	if err != nil {
+		return err
+	}
+
+	for index := range favorites {
+		err = stream.Send(s.describe(stream.Context(), favorites[index]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// describe fills the favorite with the current product or store, one that
+// can't be loaded anymore is listed as unavailable under its saved name.
+func (s *favoritesService) describe(ctx context.Context, favorite *store.Favorite) *pb.Favorite {
+	result := favorite.ToProto()
+
+	switch pb.FavoriteKind(favorite.Kind) {
+	case pb.FavoriteKind_FavoriteProduct:
+		product, err := s.productsClient.GetProduct(ctx, &pb.GetProductRequest{Id: favorite.TargetId})
+		if err != nil {
+			return result
+		}
+
+		result.Name = product.Name
+		result.Price = product.Price
+		result.RatingAverage = product.RatingAverage
+		result.Available = !product.Archived && !product.OutOfStock
+	case pb.FavoriteKind_FavoriteStore:
+		sellerStore, err := s.storesClient.GetStore(ctx, &pb.GetStoreRequest{SellerId: favorite.TargetId})
+		if err != nil {
+			return result
+		}
+
+		if sellerStore.Name != "" {
+			result.Name = sellerStore.Name
+		}
+		result.RatingAverage = sellerStore.RatingAverage
+		result.Available = sellerStore.Open
+	}
+
+	return result
+}
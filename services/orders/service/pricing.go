@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+)
+
+// priceItem resolves the chosen variant and options of the product and
+// computes the unit price, client provided prices are never trusted.
+func priceItem(product *pb.Product, variantId string, optionIds []string, quantity int32) (*store.Item, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("invalid order, quantity must be positive: quantity=%v", quantity)
+	}
+
+	item := &store.Item{
+		ProductId:   product.Id,
+		ProductName: product.Name,
+		UnitPrice:   product.Price,
+		Quantity:    quantity,
+	}
+
+	available := product.Quantity
+
+	if len(product.Variants) > 0 {
+		var variant *pb.ProductVariant
+		for _, candidate := range product.Variants {
+			if candidate.Id == variantId {
+				variant = candidate
+				break
+			}
+		}
+
+		if variant == nil {
+			return nil, fmt.Errorf("invalid order, variant not found: productId=%v, variantId=%v", product.Id, variantId)
+		}
+
+		item.VariantId = variant.Id
+		item.VariantName = variant.Name
+		item.SKU = variant.Sku
+		item.UnitPrice = variant.Price
+		available = variant.Quantity
+	} else if variantId != "" {
+		return nil, fmt.Errorf("invalid order, product has no variants: productId=%v", product.Id)
+	}
+
+	if available < quantity {
+		return nil, fmt.Errorf("invalid order, products insufficient: productId=%v", product.Id)
+	}
+
+	selected := make(map[string]bool)
+	for _, optionId := range optionIds {
+		if selected[optionId] {
+			return nil, fmt.Errorf("invalid order, duplicated option: optionId=%v", optionId)
+		}
+		selected[optionId] = true
+	}
+
+	for _, group := range product.OptionGroups {
+		var count int32
+
+		for _, option := range group.Options {
+			if !selected[option.Id] {
+				continue
+			}
+
+			delete(selected, option.Id)
+			count++
+
+			item.UnitPrice += option.PriceDelta
+			item.Options = append(item.Options, store.ItemOption{
+				GroupId:    group.Id,
+				GroupName:  group.Name,
+				OptionId:   option.Id,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+
+		if count < group.MinSelections || count > group.MaxSelections {
+			return nil, fmt.Errorf("invalid order, option group requires between %d and %d selections: group=%v",
+				group.MinSelections, group.MaxSelections, group.Name)
+		}
+	}
+
+	for optionId := range selected {
+		return nil, fmt.Errorf("invalid order, option not found: optionId=%v", optionId)
+	}
+
+	return item, nil
+}
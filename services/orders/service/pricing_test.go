@@ -0,0 +1,106 @@
+package service
+
+import (
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"reflect"
+	"testing"
+)
+
+func pizza() *pb.Product {
+	return &pb.Product{
+		Id:       "p1",
+		Name:     "Pizza",
+		Price:    10,
+		Quantity: 3,
+		OptionGroups: []*pb.OptionGroup{
+			{
+				Id: "g1", Name: "Crust", MinSelections: 1, MaxSelections: 1,
+				Options: []*pb.ProductOption{{Id: "thin", Name: "Thin"}, {Id: "stuffed", Name: "Stuffed", PriceDelta: 2.5}},
+			},
+			{
+				Id: "g2", Name: "Toppings", MinSelections: 0, MaxSelections: 2,
+				Options: []*pb.ProductOption{{Id: "olives", Name: "Olives", PriceDelta: 1}, {Id: "ham", Name: "Ham", PriceDelta: 1.5}, {Id: "egg", Name: "Egg", PriceDelta: 0.5}},
+			},
+		},
+	}
+}
+
+func TestPriceItem(t *testing.T) {
+	item, err := priceItem(pizza(), "", []string{"ham", "stuffed", "olives"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &store.Item{
+		ProductId:   "p1",
+		ProductName: "Pizza",
+		UnitPrice:   15,
+		Quantity:    2,
+		Options: []store.ItemOption{
+			{GroupId: "g1", GroupName: "Crust", OptionId: "stuffed", Name: "Stuffed", PriceDelta: 2.5},
+			{GroupId: "g2", GroupName: "Toppings", OptionId: "olives", Name: "Olives", PriceDelta: 1},
+			{GroupId: "g2", GroupName: "Toppings", OptionId: "ham", Name: "Ham", PriceDelta: 1.5},
+		},
+	}
+
+	if !reflect.DeepEqual(item, want) {
+		t.Fatalf("priceItem() = %+v, want %+v", item, want)
+	}
+}
+
+func TestPriceItemVariants(t *testing.T) {
+	product := pizza()
+	product.OptionGroups = nil
+	product.Variants = []*pb.ProductVariant{
+		{Id: "small", Sku: "PZ-S", Name: "Small", Price: 8, Quantity: 1},
+		{Id: "large", Sku: "PZ-L", Name: "Large", Price: 14, Quantity: 5},
+	}
+
+	item, err := priceItem(product, "large", nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.VariantId != "large" || item.VariantName != "Large" || item.SKU != "PZ-L" || item.UnitPrice != 14 {
+		t.Fatalf("priceItem() = %+v, want the large variant", item)
+	}
+
+	for _, variantId := range []string{"", "medium"} {
+		if _, err := priceItem(product, variantId, nil, 1); err == nil {
+			t.Errorf("priceItem() accepted the variant %q", variantId)
+		}
+	}
+
+	if _, err := priceItem(product, "small", nil, 2); err == nil {
+		t.Error("priceItem() accepted more than the variant stock")
+	}
+}
+
+func TestPriceItemRejectsInvalidOrders(t *testing.T) {
+	tests := []struct {
+		name      string
+		variantId string
+		optionIds []string
+		quantity  int32
+	}{
+		{"zero quantity", "", []string{"thin"}, 0},
+		{"negative quantity", "", []string{"thin"}, -1},
+		{"insufficient stock", "", []string{"thin"}, 4},
+		{"variant of a product without variants", "small", []string{"thin"}, 1},
+		{"missing required option", "", nil, 1},
+		{"too many options in a group", "", []string{"thin", "stuffed"}, 1},
+		{"too many toppings", "", []string{"thin", "olives", "ham", "egg"}, 1},
+		{"duplicated option", "", []string{"thin", "olives", "olives"}, 1},
+		{"unknown option", "", []string{"thin", "pineapple"}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := priceItem(pizza(), test.variantId, test.optionIds, test.quantity)
+			if err == nil {
+				t.Fatalf("priceItem() = %+v, want an error", item)
+			}
+		})
+	}
+}
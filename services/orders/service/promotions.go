@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	minPromoCode        = 3
+	maxPromoCode        = 32
+	maxPromoDescription = 200
+)
+
+type promotionsService struct {
+	promotionsStore store.PromotionsStore
+	pb.UnimplementedPromotionsServiceServer
+}
+
+func NewPromotionsService(promotionsStore store.PromotionsStore) pb.PromotionsServiceServer {
+	return &promotionsService{promotionsStore: promotionsStore}
+}
+
+func (s *promotionsService) CreatePromotion(ctx context.Context, req *pb.Promotion) (*pb.Promotion, error) {
+	promotion := &store.Promotion{
+		Id:           primitive.NewObjectID(),
+		Code:         normalizePromoCode(req.Code),
+		Description:  strings.TrimSpace(req.Description),
+		Kind:         int32(req.Kind),
+		Value:        req.Value,
+		MaxDiscount:  req.MaxDiscount,
+		MinBasket:    req.MinBasket,
+		SellerId:     req.SellerId,
+		Funding:      int32(req.Funding),
+		UsageLimit:   req.UsageLimit,
+		PerUserLimit: req.PerUserLimit,
+		Active:       true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if req.StartsAt > 0 {
+		promotion.StartsAt = time.Unix(req.StartsAt, 0)
+	}
+	if req.EndsAt > 0 {
+		promotion.EndsAt = time.Unix(req.EndsAt, 0)
+	}
+
+	err := validatePromotion(promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.promotionsStore.Create(ctx, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotion.ToProto(), nil
+}
+
+func (s *promotionsService) GetPromotion(ctx context.Context, req *pb.GetPromotionRequest) (*pb.Promotion, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err := s.promotionsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotion.ToProto(), nil
+}
+
+func (s *promotionsService) ListPromotions(req *pb.ListPromotionsRequest, stream pb.PromotionsService_ListPromotionsServer) error {
+	promotions, err := s.promotionsStore.GetBySeller(stream.Context(), req.SellerId)
+	if err != nil {
+		return err
+	}
+
+	for index := range promotions {
+		err = stream.Send(promotions[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeactivatePromotion stops new redemptions, the orders already placed keep
+// their discount.
+func (s *promotionsService) DeactivatePromotion(ctx context.Context, req *pb.DeactivatePromotionRequest) (*pb.Promotion, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err := s.promotionsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.promotionsStore.Deactivate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion.Active = false
+	promotion.UpdatedAt = time.Now()
+
+	return promotion.ToProto(), nil
+}
+
+// ApplyPromoCode quotes the order with the promo code, nothing is redeemed
+// until the order is created.
+func (s *service) ApplyPromoCode(ctx context.Context, req *pb.ApplyPromoCodeRequest) (*pb.OrderQuote, error) {
+	if strings.TrimSpace(req.PromoCode) == "" {
+		return nil, errors.New("invalid promo code, code is required")
+	}
+
+	window, err := parseDeliveryWindow(req.DeliverFrom, req.DeliverUntil, s.scheduleLeadTime)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.findProduct(ctx, req.SellerId, req.ProductId, window)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := priceItem(product, req.VariantId, req.OptionIds, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := s.quote(ctx, req.CustomerId, req.SellerId, product, item, req.PromoCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return quote.ToProto(), nil
+}
+
+// orderQuote is the checkout breakdown of an order, promotion is set when a
+// promo code applies.
+type orderQuote struct {
+	Subtotal     float32
+	DeliveryCost float32
+	Discount     *store.Discount
+	Promotion    *store.Promotion
+}
+
+func (q *orderQuote) Amount() float32 {
+	amount := q.Subtotal + q.DeliveryCost
+	if q.Discount != nil {
+		amount -= q.Discount.Total()
+	}
+	return roundCents(amount)
+}
+
+func (q *orderQuote) ToProto() *pb.OrderQuote {
+	quote := &pb.OrderQuote{
+		Subtotal:     q.Subtotal,
+		DeliveryCost: q.DeliveryCost,
+		Amount:       q.Amount(),
+	}
+
+	if q.Discount != nil {
+		quote.Discount = q.Discount.ToProto()
+	}
+
+	return quote
+}
+
+func (s *service) quote(ctx context.Context, customerId, sellerId string, product *pb.Product, item *store.Item, code string) (*orderQuote, error) {
+	quote := &orderQuote{
+		Subtotal:     roundCents(item.UnitPrice * float32(item.Quantity)),
+		DeliveryCost: product.DeliveryCost,
+	}
+
+	code = normalizePromoCode(code)
+	if code == "" {
+		return quote, nil
+	}
+
+	promotion, err := s.promotionsStore.GetByCode(ctx, code)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("invalid promo code: code=%v", code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRedeemable(promotion, sellerId, quote.Subtotal, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion.PerUserLimit > 0 {
+		uses, err := s.promotionsStore.CountRedemptions(ctx, promotion.Id.Hex(), customerId)
+		if err != nil {
+			return nil, err
+		}
+
+		if uses >= int64(promotion.PerUserLimit) {
+			return nil, fmt.Errorf("invalid promo code, already used: code=%v", code)
+		}
+	}
+
+	if promotion.Funding == int32(pb.PromotionFunding_PlatformFunded) && s.platformUserId == "" {
+		return nil, fmt.Errorf("invalid promo code, platform wallet not configured: code=%v", code)
+	}
+
+	quote.Discount = computeDiscount(promotion, quote.Subtotal, quote.DeliveryCost)
+	quote.Promotion = promotion
+
+	return quote, nil
+}
+
+// computeDiscount splits the discount between the item and the delivery, a
+// seller funded discount never exceeds what the seller is paid.
+func computeDiscount(promotion *store.Promotion, subtotal, deliveryCost float32) *store.Discount {
+	discount := &store.Discount{
+		PromotionId: promotion.Id.Hex(),
+		Code:        promotion.Code,
+		FundedBy:    store.FundedBySeller,
+	}
+
+	if promotion.Funding == int32(pb.PromotionFunding_PlatformFunded) {
+		discount.FundedBy = store.FundedByPlatform
+	}
+
+	switch pb.PromotionKind(promotion.Kind) {
+	case pb.PromotionKind_PercentageDiscount:
+		discount.ItemDiscount = subtotal * promotion.Value / 100
+		if promotion.MaxDiscount > 0 && discount.ItemDiscount > promotion.MaxDiscount {
+			discount.ItemDiscount = promotion.MaxDiscount
+		}
+	case pb.PromotionKind_FixedDiscount:
+		discount.ItemDiscount = promotion.Value
+		if discount.ItemDiscount > subtotal {
+			discount.ItemDiscount = subtotal
+		}
+	case pb.PromotionKind_FreeDelivery:
+		discount.DeliveryDiscount = deliveryCost
+	}
+
+	if discount.FundedBy == store.FundedBySeller && discount.Total() > subtotal {
+		discount.DeliveryDiscount = subtotal - discount.ItemDiscount
+	}
+
+	discount.ItemDiscount = roundCents(discount.ItemDiscount)
+	discount.DeliveryDiscount = roundCents(discount.DeliveryDiscount)
+
+	return discount
+}
+
+func checkRedeemable(promotion *store.Promotion, sellerId string, subtotal float32, now time.Time) error {
+	switch {
+	case !promotion.Active:
+		return fmt.Errorf("invalid promo code, promotion is not active: code=%v", promotion.Code)
+	case !promotion.StartsAt.IsZero() && now.Before(promotion.StartsAt):
+		return fmt.Errorf("invalid promo code, promotion not started: code=%v", promotion.Code)
+	case !promotion.EndsAt.IsZero() && !now.Before(promotion.EndsAt):
+		return fmt.Errorf("invalid promo code, promotion expired: code=%v", promotion.Code)
+	case promotion.SellerId != "" && promotion.SellerId != sellerId:
+		return fmt.Errorf("invalid promo code, not valid for this seller: code=%v", promotion.Code)
+	case subtotal < promotion.MinBasket:
+		return fmt.Errorf("invalid promo code, minimum basket is %.2f: code=%v", promotion.MinBasket, promotion.Code)
+	case promotion.UsageLimit > 0 && promotion.Uses >= promotion.UsageLimit:
+		return fmt.Errorf("promo code usage limit reached: code=%v", promotion.Code)
+	}
+
+	return nil
+}
+
+func validatePromotion(promotion *store.Promotion) error {
+	if len(promotion.Code) < minPromoCode || len(promotion.Code) > maxPromoCode {
+		return fmt.Errorf("invalid promotion, code must have between %d and %d characters", minPromoCode, maxPromoCode)
+	}
+	if len(promotion.Description) > maxPromoDescription {
+		return fmt.Errorf("invalid promotion, description must have at most %d characters", maxPromoDescription)
+	}
+
+	switch pb.PromotionKind(promotion.Kind) {
+	case pb.PromotionKind_PercentageDiscount:
+		if promotion.Value <= 0 || promotion.Value > 100 {
+			return errors.New("invalid promotion, percentage must be between 0 and 100")
+		}
+	case pb.PromotionKind_FixedDiscount:
+		if promotion.Value <= 0 {
+			return errors.New("invalid promotion, discount must be greater than zero")
+		}
+	case pb.PromotionKind_FreeDelivery:
+		promotion.Value = 0
+	default:
+		return fmt.Errorf("invalid promotion kind: kind=%v", promotion.Kind)
+	}
+
+	switch pb.PromotionFunding(promotion.Funding) {
+	case pb.PromotionFunding_SellerFunded:
+		if promotion.SellerId == "" {
+			return errors.New("invalid promotion, seller funded promotions require a seller")
+		}
+	case pb.PromotionFunding_PlatformFunded:
+	default:
+		return fmt.Errorf("invalid promotion funding: funding=%v", promotion.Funding)
+	}
+
+	if promotion.SellerId != "" {
+		_, err := primitive.ObjectIDFromHex(promotion.SellerId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if promotion.MaxDiscount < 0 || promotion.MinBasket < 0 {
+		return errors.New("invalid promotion, max discount and min basket can't be negative")
+	}
+	if promotion.UsageLimit < 0 || promotion.PerUserLimit < 0 {
+		return errors.New("invalid promotion, usage limits can't be negative")
+	}
+	if !promotion.StartsAt.IsZero() && !promotion.EndsAt.IsZero() && !promotion.EndsAt.After(promotion.StartsAt) {
+		return errors.New("invalid promotion, ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+// releasePromotion gives back the use of the order promotion, failures are
+// only logged since the order itself is already settled.
+func (s *service) releasePromotion(ctx context.Context, order *store.Order) {
+	if order.Discount == nil {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(order.Discount.PromotionId)
+	if err != nil {
+		log.Printf("failed to release promotion: orderId=%v, err=%v\n", order.Id.Hex(), err)
+		return
+	}
+
+	err = s.promotionsStore.Release(ctx, id)
+	if err == nil {
+		err = s.promotionsStore.ReleaseByCustomer(ctx, order.Discount.PromotionId, order.CustomerId)
+	}
+	if err == nil {
+		err = s.promotionsStore.DeleteRedemption(ctx, order.Id.Hex())
+	}
+	if err != nil {
+		log.Printf("failed to release promotion: orderId=%v, err=%v\n", order.Id.Hex(), err)
+	}
+}
+
+func (s *service) debitPlatform(ctx context.Context, amount float32, reference string) error {
+	return debitUser(ctx, s.walletsClient, s.platformUserId, amount, reference)
+}
+
+func (s *service) creditPlatform(ctx context.Context, amount float32, reference string) error {
+	return creditUser(ctx, s.walletsClient, s.platformUserId, amount, reference)
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func roundCents(amount float32) float32 {
+	return float32(math.Round(float64(amount)*100) / 100)
+}
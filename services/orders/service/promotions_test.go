@@ -0,0 +1,163 @@
+package service
+
+import (
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestComputeDiscount(t *testing.T) {
+	promotion := func(kind pb.PromotionKind, funding pb.PromotionFunding, value, maxDiscount float32) *store.Promotion {
+		return &store.Promotion{
+			Id:          primitive.NewObjectID(),
+			Code:        "SAVE",
+			Kind:        int32(kind),
+			Funding:     int32(funding),
+			Value:       value,
+			MaxDiscount: maxDiscount,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		promotion *store.Promotion
+		subtotal  float32
+		delivery  float32
+		item      float32
+		shipping  float32
+		fundedBy  string
+	}{
+		{"percentage", promotion(pb.PromotionKind_PercentageDiscount, pb.PromotionFunding_SellerFunded, 20, 0), 33.33, 5, 6.67, 0, store.FundedBySeller},
+		{"percentage capped", promotion(pb.PromotionKind_PercentageDiscount, pb.PromotionFunding_SellerFunded, 50, 10), 40, 5, 10, 0, store.FundedBySeller},
+		{"fixed", promotion(pb.PromotionKind_FixedDiscount, pb.PromotionFunding_PlatformFunded, 5, 0), 20, 5, 5, 0, store.FundedByPlatform},
+		{"fixed above the subtotal", promotion(pb.PromotionKind_FixedDiscount, pb.PromotionFunding_PlatformFunded, 30, 0), 20, 5, 20, 0, store.FundedByPlatform},
+		{"free delivery", promotion(pb.PromotionKind_FreeDelivery, pb.PromotionFunding_PlatformFunded, 0, 0), 8, 12, 0, 12, store.FundedByPlatform},
+		{"seller funded free delivery above the subtotal", promotion(pb.PromotionKind_FreeDelivery, pb.PromotionFunding_SellerFunded, 0, 0), 8, 12, 0, 8, store.FundedBySeller},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			discount := computeDiscount(test.promotion, test.subtotal, test.delivery)
+
+			if discount.ItemDiscount != test.item || discount.DeliveryDiscount != test.shipping || discount.FundedBy != test.fundedBy {
+				t.Fatalf("computeDiscount() = %+v, want item %v, delivery %v, funded by %v", discount, test.item, test.shipping, test.fundedBy)
+			}
+
+			if discount.PromotionId != test.promotion.Id.Hex() || discount.Code != test.promotion.Code {
+				t.Fatalf("computeDiscount() = %+v, want the promotion reference", discount)
+			}
+		})
+	}
+}
+
+func TestOrderQuoteAmount(t *testing.T) {
+	quote := &orderQuote{Subtotal: 20, DeliveryCost: 4.99}
+	if quote.Amount() != 24.99 {
+		t.Fatalf("Amount() = %v, want 24.99", quote.Amount())
+	}
+
+	quote.Discount = &store.Discount{ItemDiscount: 2.5, DeliveryDiscount: 4.99}
+	if quote.Amount() != 17.5 {
+		t.Fatalf("Amount() = %v, want 17.5", quote.Amount())
+	}
+}
+
+func TestCheckRedeemable(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	sellerId := primitive.NewObjectID().Hex()
+
+	valid := func(change func(p *store.Promotion)) *store.Promotion {
+		promotion := &store.Promotion{
+			Code:       "SAVE",
+			Active:     true,
+			StartsAt:   now.Add(-time.Hour),
+			EndsAt:     now.Add(time.Hour),
+			SellerId:   sellerId,
+			MinBasket:  10,
+			UsageLimit: 5,
+			Uses:       4,
+		}
+		if change != nil {
+			change(promotion)
+		}
+		return promotion
+	}
+
+	tests := []struct {
+		name      string
+		promotion *store.Promotion
+		subtotal  float32
+		ok        bool
+	}{
+		{"redeemable", valid(nil), 10, true},
+		{"any seller", valid(func(p *store.Promotion) { p.SellerId = "" }), 10, true},
+		{"no dates", valid(func(p *store.Promotion) { p.StartsAt, p.EndsAt = time.Time{}, time.Time{} }), 10, true},
+		{"inactive", valid(func(p *store.Promotion) { p.Active = false }), 10, false},
+		{"not started", valid(func(p *store.Promotion) { p.StartsAt = now.Add(time.Minute) }), 10, false},
+		{"expired", valid(func(p *store.Promotion) { p.EndsAt = now }), 10, false},
+		{"other seller", valid(func(p *store.Promotion) { p.SellerId = primitive.NewObjectID().Hex() }), 10, false},
+		{"below the minimum basket", valid(nil), 9.99, false},
+		{"usage limit reached", valid(func(p *store.Promotion) { p.Uses = 5 }), 10, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkRedeemable(test.promotion, sellerId, test.subtotal, now)
+			if (err == nil) != test.ok {
+				t.Fatalf("checkRedeemable() = %v, want ok %v", err, test.ok)
+			}
+		})
+	}
+}
+
+func TestValidatePromotion(t *testing.T) {
+	sellerId := primitive.NewObjectID().Hex()
+
+	valid := func(change func(p *store.Promotion)) *store.Promotion {
+		promotion := &store.Promotion{
+			Code:     "SAVE10",
+			Kind:     int32(pb.PromotionKind_PercentageDiscount),
+			Value:    10,
+			Funding:  int32(pb.PromotionFunding_SellerFunded),
+			SellerId: sellerId,
+		}
+		if change != nil {
+			change(promotion)
+		}
+		return promotion
+	}
+
+	tests := []struct {
+		name      string
+		promotion *store.Promotion
+		ok        bool
+	}{
+		{"valid", valid(nil), true},
+		{"platform funded", valid(func(p *store.Promotion) { p.Funding, p.SellerId = int32(pb.PromotionFunding_PlatformFunded), "" }), true},
+		{"short code", valid(func(p *store.Promotion) { p.Code = "AB" }), false},
+		{"percentage above 100", valid(func(p *store.Promotion) { p.Value = 101 }), false},
+		{"fixed without value", valid(func(p *store.Promotion) { p.Kind, p.Value = int32(pb.PromotionKind_FixedDiscount), 0 }), false},
+		{"unknown kind", valid(func(p *store.Promotion) { p.Kind = 42 }), false},
+		{"seller funded without seller", valid(func(p *store.Promotion) { p.SellerId = "" }), false},
+		{"invalid seller", valid(func(p *store.Promotion) { p.SellerId = "seller" }), false},
+		{"negative max discount", valid(func(p *store.Promotion) { p.MaxDiscount = -1 }), false},
+		{"negative usage limit", valid(func(p *store.Promotion) { p.UsageLimit = -1 }), false},
+		{"ends before it starts", valid(func(p *store.Promotion) { p.StartsAt, p.EndsAt = time.Unix(2, 0), time.Unix(1, 0) }), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePromotion(test.promotion)
+			if (err == nil) != test.ok {
+				t.Fatalf("validatePromotion() = %v, want ok %v", err, test.ok)
+			}
+		})
+	}
+
+	free := valid(func(p *store.Promotion) { p.Kind, p.Value = int32(pb.PromotionKind_FreeDelivery), 5 })
+	if validatePromotion(free) != nil || free.Value != 0 {
+		t.Fatalf("validatePromotion() kept the value of a free delivery: value=%v", free.Value)
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Reorder places the product configuration of a past order again. It goes
+// through CreateOrder, so prices, stock and the store hours are checked as
+// for a new order.
+func (s *service) Reorder(ctx context.Context, req *pb.ReorderRequest) (*pb.Order, error) {
+	id, err := primitive.ObjectIDFromHex(req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	past, err := s.ordersStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if past.CustomerId != req.CustomerId {
+		return nil, fmt.Errorf("order not found: orderId=%v", req.OrderId)
+	}
+
+	addressId := req.AddressId
+	if addressId == "" {
+		addressId = s.pastAddress(ctx, past)
+	}
+
+	var variantId string
+	var optionIds []string
+
+	if past.Item != nil {
+		variantId = past.Item.VariantId
+		for _, option := range past.Item.Options {
+			optionIds = append(optionIds, option.OptionId)
+		}
+	}
+
+	now := time.Now()
+
+	return s.CreateOrder(ctx, &pb.Order{
+		Id:         primitive.NewObjectID().Hex(),
+		CustomerId: past.CustomerId,
+		SellerId:   past.SellerId,
+		ProductId:  past.ProductId,
+		Quantity:   past.Quantity,
+		AddressId:  addressId,
+		VariantId:  variantId,
+		OptionIds:  optionIds,
+		PromoCode:  req.PromoCode,
+		CreatedAt:  now.Unix(),
+		UpdatedAt:  now.Unix(),
+	})
+}
+
+// pastAddress returns the address of the past order while the customer
+// still has it, otherwise the default address is used.
+func (s *service) pastAddress(ctx context.Context, past *store.Order) string {
+	if past.AddressId == "" {
+		return ""
+	}
+
+	_, err := s.accountsClient.GetAddress(ctx, &pb.GetAddressRequest{Id: past.AddressId, UserId: past.CustomerId})
+	if err != nil {
+		return ""
+	}
+
+	return past.AddressId
+}
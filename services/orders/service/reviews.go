@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	minRating         = 1
+	maxRating         = 5
+	maxReviewComment  = 1000
+	maxModerationNote = 200
+)
+
+type reviewsService struct {
+	reviewsStore   store.ReviewsStore
+	ordersStore    store.OrdersStore
+	productsClient pb.ProductsServiceClient
+	storesClient   pb.StoresServiceClient
+	pb.UnimplementedReviewsServiceServer
+}
+
+func NewReviewsService(
+	reviewsStore store.ReviewsStore,
+	ordersStore store.OrdersStore,
+	productsClient pb.ProductsServiceClient,
+	storesClient pb.StoresServiceClient,
+) pb.ReviewsServiceServer {
+
+	return &reviewsService{
+		reviewsStore:   reviewsStore,
+		ordersStore:    ordersStore,
+		productsClient: productsClient,
+		storesClient:   storesClient,
+	}
+}
+
+// CreateReview rates the seller, the product and the deliverer of a
+// delivered order, each order is reviewed once.
+func (s *reviewsService) CreateReview(ctx context.Context, req *pb.Review) (*pb.Review, error) {
+	orderId, err := primitive.ObjectIDFromHex(req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.ordersStore.Get(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.CustomerId != req.CustomerId {
+		return nil, fmt.Errorf("order not found: orderId=%v", req.OrderId)
+	}
+
+	if order.Status != int32(pb.OrderStatus_Delivered) {
+		return nil, fmt.Errorf("invalid review, order not delivered: orderId=%v", req.OrderId)
+	}
+
+	review := &store.Review{
+		Id:              primitive.NewObjectID(),
+		OrderId:         order.Id.Hex(),
+		CustomerId:      order.CustomerId,
+		SellerId:        order.SellerId,
+		ProductId:       order.ProductId,
+		DelivererId:     order.DeliveryId,
+		SellerRating:    req.SellerRating,
+		ProductRating:   req.ProductRating,
+		DelivererRating: req.DelivererRating,
+		Comment:         strings.TrimSpace(req.Comment),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	err = validateReview(review)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.reviewsStore.Create(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+
+	s.refreshRatings(ctx, review)
+
+	return review.ToProto(), nil
+}
+
+func (s *reviewsService) ListReviews(req *pb.ListReviewsRequest, stream pb.ReviewsService_ListReviewsServer) error {
+	var subject, id string
+
+	switch {
+	case req.ProductId != "" && req.SellerId == "" && req.DelivererId == "":
+		subject, id = store.RatingProduct, req.ProductId
+	case req.SellerId != "" && req.ProductId == "" && req.DelivererId == "":
+		subject, id = store.RatingSeller, req.SellerId
+	case req.DelivererId != "" && req.ProductId == "" && req.SellerId == "":
+		subject, id = store.RatingDeliverer, req.DelivererId
+	default:
+		return errors.New("invalid reviews filter, one of product, seller or deliverer is required")
+	}
+
+	reviews, err := s.reviewsStore.GetVisible(stream.Context(), subject, id)
+	if err != nil {
+		return err
+	}
+
+	for index := range reviews {
+		err = stream.Send(reviews[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *reviewsService) ListModerationQueue(req *pb.ListModerationQueueRequest, stream pb.ReviewsService_ListModerationQueueServer) error {
+	reviews, err := s.reviewsStore.GetByHidden(stream.Context(), req.Hidden)
+	if err != nil {
+		return err
+	}
+
+	for index := range reviews {
+		err = stream.Send(reviews[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ModerateReview hides or restores the review, the ratings it counts for are
+// recomputed either way.
+func (s *reviewsService) ModerateReview(ctx context.Context, req *pb.ModerateReviewRequest) (*pb.Review, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if req.Hidden && reason == "" {
+		return nil, errors.New("invalid moderation, reason is required to hide a review")
+	}
+	if len(reason) > maxModerationNote {
+		return nil, fmt.Errorf("invalid moderation, reason must have at most %d characters", maxModerationNote)
+	}
+
+	review, err := s.reviewsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	review.Hidden = req.Hidden
+	review.ModerationReason = reason
+	review.ModeratedBy = req.ModeratorId
+	review.ModeratedAt = &now
+	review.UpdatedAt = now
+
+	err = s.reviewsStore.Moderate(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+
+	s.refreshRatings(ctx, review)
+
+	return review.ToProto(), nil
+}
+
+func (s *reviewsService) GetDelivererRating(ctx context.Context, req *pb.GetDelivererRatingRequest) (*pb.Rating, error) {
+	_, err := primitive.ObjectIDFromHex(req.DelivererId)
+	if err != nil {
+		return nil, err
+	}
+
+	average, count, err := s.reviewsStore.Rating(ctx, store.RatingDeliverer, req.DelivererId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Rating{Average: roundRating(average), Count: count}, nil
+}
+
+// refreshRatings pushes the product and store averages to the sellers
+// service, failures are only logged since the next review fixes them.
+func (s *reviewsService) refreshRatings(ctx context.Context, review *store.Review) {
+	average, count, err := s.reviewsStore.Rating(ctx, store.RatingProduct, review.ProductId)
+	if err == nil {
+		_, err = s.productsClient.UpdateProductRating(ctx, &pb.UpdateProductRatingRequest{
+			Id:      review.ProductId,
+			Average: roundRating(average),
+			Count:   count,
+		})
+	}
+	if err != nil {
+		log.Printf("failed to update product rating: productId=%v, err=%v\n", review.ProductId, err)
+	}
+
+	average, count, err = s.reviewsStore.Rating(ctx, store.RatingSeller, review.SellerId)
+	if err == nil {
+		_, err = s.storesClient.UpdateStoreRating(ctx, &pb.UpdateStoreRatingRequest{
+			SellerId: review.SellerId,
+			Average:  roundRating(average),
+			Count:    count,
+		})
+	}
+	if err != nil {
+		log.Printf("failed to update store rating: sellerId=%v, err=%v\n", review.SellerId, err)
+	}
+}
+
+func validateReview(review *store.Review) error {
+	ratings := map[string]int32{
+		"seller":  review.SellerRating,
+		"product": review.ProductRating,
+	}
+
+	if review.DelivererId != "" {
+		ratings["deliverer"] = review.DelivererRating
+	} else {
+		review.DelivererRating = 0
+	}
+
+	for name, rating := range ratings {
+		if rating < minRating || rating > maxRating {
+			return fmt.Errorf("invalid review, %s rating must be between %d and %d", name, minRating, maxRating)
+		}
+	}
+
+	if len(review.Comment) > maxReviewComment {
+		return fmt.Errorf("invalid review, comment must have at most %d characters", maxReviewComment)
+	}
+
+	return nil
+}
+
+func roundRating(average float32) float32 {
+	return roundCents(average)
+}
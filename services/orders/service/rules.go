@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go-delivery/services/orders/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewRules(ordersStore store.OrdersStore, promotionsStore store.PromotionsStore, disputesStore store.DisputesStore) permissions.Rules {
+	orderCustomer := func(ctx context.Context, orderId string) (string, error) {
+		id, err := primitive.ObjectIDFromHex(orderId)
+		if err != nil {
+			return "", err
+		}
+
+		order, err := ordersStore.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		return order.CustomerId, nil
+	}
+
+	orderSeller := func(ctx context.Context, orderId string) (string, error) {
+		id, err := primitive.ObjectIDFromHex(orderId)
+		if err != nil {
+			return "", err
+		}
+
+		order, err := ordersStore.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		return order.SellerId, nil
+	}
+
+	// platform funded promotions have no owner, only the any scope manages them.
+	promotionOwner := func(ctx context.Context, promotionId string) (string, error) {
+		id, err := primitive.ObjectIDFromHex(promotionId)
+		if err != nil {
+			return "", err
+		}
+
+		promotion, err := promotionsStore.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		if promotion.Funding == int32(pb.PromotionFunding_PlatformFunded) {
+			return "", nil
+		}
+
+		return promotion.SellerId, nil
+	}
+
+	disputeCustomer := func(ctx context.Context, disputeId string) (string, error) {
+		id, err := primitive.ObjectIDFromHex(disputeId)
+		if err != nil {
+			return "", err
+		}
+
+		dispute, err := disputesStore.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		return dispute.CustomerId, nil
+	}
+
+	return permissions.Rules{
+		"/pb.OrdersService/CreateOrder": {
+			Permissions: []permissions.Permission{permissions.OrdersCreateOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Order).CustomerId, nil
+			},
+		},
+		"/pb.OrdersService/ApplyPromoCode": {
+			Permissions: []permissions.Permission{permissions.OrdersCreateOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ApplyPromoCodeRequest).CustomerId, nil
+			},
+		},
+		"/pb.OrdersService/Reorder": {
+			Permissions: []permissions.Permission{permissions.OrdersCreateOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ReorderRequest).CustomerId, nil
+			},
+		},
+		"/pb.OrdersService/GetOrder": {
+			Permissions: []permissions.Permission{permissions.OrdersReadAny},
+		},
+		"/pb.OrdersService/ListOrders": {
+			Permissions: []permissions.Permission{permissions.OrdersReadAny},
+		},
+		"/pb.OrdersService/ListOrdersBySeller": {
+			Permissions: []permissions.Permission{permissions.OrdersReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListOrdersBySellerRequest).SellerId, nil
+			},
+		},
+		"/pb.OrdersService/ListOrdersByStatus": {
+			Permissions: []permissions.Permission{permissions.OrdersReadAny, permissions.DeliveriesReadAny},
+			// deliverers only see the orders waiting for a deliverer.
+			Limits: map[permissions.Permission]permissions.LimitFunc{
+				permissions.DeliveriesReadAny: func(req interface{}) error {
+					status := req.(*pb.ListOrdersByStatusRequest).Status
+					if status != pb.OrderStatus_Accepted {
+						return fmt.Errorf("deliverers can only list accepted orders: status=%v", status)
+					}
+					return nil
+				},
+			},
+		},
+		"/pb.OrdersService/ApproveOrder": {
+			Permissions: []permissions.Permission{permissions.OrdersApproveOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return orderSeller(ctx, req.(*pb.ApproveOrderRequest).Id)
+			},
+		},
+		"/pb.OrdersService/DeliverOrder": {
+			Permissions: []permissions.Permission{permissions.DeliveriesWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.DeliverOrderRequest).DeliveryId, nil
+			},
+		},
+		"/pb.OrdersService/ConfirmOrderDelivered": {
+			Permissions: []permissions.Permission{permissions.OrdersConfirmOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return orderCustomer(ctx, req.(*pb.ConfirmOrderDeliveredRequest).Id)
+			},
+		},
+		"/pb.OrdersService/CancelOrder": {
+			Permissions: []permissions.Permission{permissions.OrdersCancelOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return orderCustomer(ctx, req.(*pb.CancelOrderRequest).Id)
+			},
+		},
+		"/pb.OrdersService/DeleteOrder": {
+			Permissions: []permissions.Permission{permissions.OrdersDeleteAny},
+		},
+		"/pb.ReviewsService/ListReviews": {
+			Public: true,
+		},
+		"/pb.ReviewsService/GetDelivererRating": {
+			Public: true,
+		},
+		"/pb.ReviewsService/CreateReview": {
+			Permissions: []permissions.Permission{permissions.ReviewsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Review).CustomerId, nil
+			},
+		},
+		"/pb.ReviewsService/ListModerationQueue": {
+			Permissions: []permissions.Permission{permissions.ReviewsModerateAny},
+		},
+		"/pb.ReviewsService/ModerateReview": {
+			Permissions: []permissions.Permission{permissions.ReviewsModerateAny},
+		},
+		"/pb.OrdersService/AddTip": {
+			Permissions: []permissions.Permission{permissions.OrdersConfirmOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return orderCustomer(ctx, req.(*pb.AddTipRequest).OrderId)
+			},
+		},
+		"/pb.OrdersService/GetDelivererEarnings": {
+			Permissions: []permissions.Permission{permissions.EarningsReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.GetDelivererEarningsRequest).DelivererId, nil
+			},
+		},
+		"/pb.DisputesService/OpenDispute": {
+			Permissions: []permissions.Permission{permissions.DisputesWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Dispute).CustomerId, nil
+			},
+		},
+		"/pb.DisputesService/GetDispute": {
+			Permissions: []permissions.Permission{permissions.DisputesWriteOwn, permissions.DisputesResolveAny},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return disputeCustomer(ctx, req.(*pb.GetDisputeRequest).Id)
+			},
+		},
+		"/pb.DisputesService/ListDisputes": {
+			Permissions: []permissions.Permission{permissions.DisputesWriteOwn, permissions.DisputesResolveAny},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListDisputesRequest).CustomerId, nil
+			},
+		},
+		"/pb.DisputesService/ResolveDispute": {
+			Permissions: []permissions.Permission{permissions.DisputesResolveAny},
+		},
+		"/pb.DisputesService/RejectDispute": {
+			Permissions: []permissions.Permission{permissions.DisputesResolveAny},
+		},
+		"/pb.FavoritesService/AddFavorite": {
+			Permissions: []permissions.Permission{permissions.FavoritesWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Favorite).CustomerId, nil
+			},
+		},
+		"/pb.FavoritesService/RemoveFavorite": {
+			Permissions: []permissions.Permission{permissions.FavoritesWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.RemoveFavoriteRequest).CustomerId, nil
+			},
+		},
+		"/pb.FavoritesService/ListFavorites": {
+			Permissions: []permissions.Permission{permissions.FavoritesWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListFavoritesRequest).CustomerId, nil
+			},
+		},
+		"/pb.PromotionsService/CreatePromotion": {
+			Permissions: []permissions.Permission{permissions.PromotionsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				promotion := req.(*pb.Promotion)
+				if promotion.Funding == pb.PromotionFunding_PlatformFunded {
+					return "", nil
+				}
+				return promotion.SellerId, nil
+			},
+		},
+		"/pb.PromotionsService/GetPromotion": {
+			Permissions: []permissions.Permission{permissions.PromotionsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return promotionOwner(ctx, req.(*pb.GetPromotionRequest).Id)
+			},
+		},
+		"/pb.PromotionsService/ListPromotions": {
+			Permissions: []permissions.Permission{permissions.PromotionsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListPromotionsRequest).SellerId, nil
+			},
+		},
+		"/pb.PromotionsService/DeactivatePromotion": {
+			Permissions: []permissions.Permission{permissions.PromotionsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return promotionOwner(ctx, req.(*pb.DeactivatePromotionRequest).Id)
+			},
+		},
+	}
+}
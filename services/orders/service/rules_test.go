@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestListOrdersByStatusRule(t *testing.T) {
+	rule := NewRules(nil, nil, nil)["/pb.OrdersService/ListOrdersByStatus"]
+	cfg := permissions.NewConfig()
+
+	userId := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name    string
+		role    pb.Role
+		status  pb.OrderStatus
+		allowed bool
+	}{
+		{"deliverer accepted orders", pb.Role_Delivery, pb.OrderStatus_Accepted, true},
+		{"deliverer placed orders", pb.Role_Delivery, pb.OrderStatus_Placed, false},
+		{"deliverer canceled orders", pb.Role_Delivery, pb.OrderStatus_Canceled, false},
+		{"deliverer delivered orders", pb.Role_Delivery, pb.OrderStatus_Delivered, false},
+		{"admin placed orders", pb.Role_Admin, pb.OrderStatus_Placed, true},
+		{"customer accepted orders", pb.Role_Customer, pb.OrderStatus_Accepted, false},
+		{"seller accepted orders", pb.Role_Seller, pb.OrderStatus_Accepted, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &pb.ListOrdersByStatusRequest{Status: test.status}
+
+			err := permissions.Authorize(context.Background(), cfg, rule, test.role.String(), userId, nil, req)
+			if allowed := err == nil; allowed != test.allowed {
+				t.Errorf("allowed = %v, want %v, err=%v", allowed, test.allowed, err)
+			}
+		})
+	}
+}
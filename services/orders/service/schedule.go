@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/orders/store"
+	"log"
+	"time"
+)
+
+const (
+	defaultDeliveryWindow = 30 * time.Minute
+	maxDeliveryWindow     = 2 * time.Hour
+	maxScheduleAhead      = 7 * 24 * time.Hour
+)
+
+// deliveryWindow is the delivery time requested for a scheduled order.
+type deliveryWindow struct {
+	From  time.Time
+	Until time.Time
+}
+
+// parseDeliveryWindow returns nil for immediate orders. A window without an
+// end lasts thirty minutes, it must start after the lead time so the seller
+// gets the order in time to prepare it.
+func parseDeliveryWindow(from, until int64, leadTime time.Duration) (*deliveryWindow, error) {
+	if from == 0 {
+		if until != 0 {
+			return nil, errors.New("invalid delivery window, start is required")
+		}
+		return nil, nil
+	}
+
+	window := &deliveryWindow{From: time.Unix(from, 0)}
+
+	if until == 0 {
+		window.Until = window.From.Add(defaultDeliveryWindow)
+	} else {
+		window.Until = time.Unix(until, 0)
+	}
+
+	now := time.Now()
+
+	switch {
+	case !window.Until.After(window.From):
+		return nil, errors.New("invalid delivery window, end must be after start")
+	case window.Until.Sub(window.From) > maxDeliveryWindow:
+		return nil, fmt.Errorf("invalid delivery window, must last at most %v", maxDeliveryWindow)
+	case window.From.Before(now.Add(leadTime)):
+		return nil, fmt.Errorf("invalid delivery window, must start at least %v from now", leadTime)
+	case window.From.After(now.Add(maxScheduleAhead)):
+		return nil, fmt.Errorf("invalid delivery window, must start within %v", maxScheduleAhead)
+	}
+
+	return window, nil
+}
+
+// checkStoreOpen requires the seller store to be open now for immediate
+// orders, or during the whole delivery window of scheduled ones.
+func (s *service) checkStoreOpen(ctx context.Context, sellerId string, window *deliveryWindow) error {
+	req := &pb.GetStoreRequest{SellerId: sellerId, At: time.Now().Unix()}
+	if window != nil {
+		req.At = window.From.Unix()
+		req.Until = window.Until.Unix()
+	}
+
+	sellerStore, err := s.storesClient.GetStore(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if sellerStore.Open {
+		return nil
+	}
+
+	if window != nil {
+		return fmt.Errorf("invalid order, store closed during the delivery window: sellerId=%v, reason=%v", sellerId, sellerStore.ClosedReason)
+	}
+	return fmt.Errorf("invalid order, store closed: sellerId=%v, reason=%v", sellerId, sellerStore.ClosedReason)
+}
+
+// OrderScheduler releases the scheduled orders to their seller once their
+// delivery window starts within the lead time.
+type OrderScheduler struct {
+	ordersStore store.OrdersStore
+	leadTime    time.Duration
+}
+
+func NewOrderScheduler(ordersStore store.OrdersStore, leadTime time.Duration) *OrderScheduler {
+	return &OrderScheduler{ordersStore: ordersStore, leadTime: leadTime}
+}
+
+func (o *OrderScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		o.ReleaseDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *OrderScheduler) ReleaseDue(ctx context.Context) {
+	orders, err := o.ordersStore.GetScheduledDue(ctx, time.Now().Add(o.leadTime))
+	if err != nil {
+		log.Printf("failed to load scheduled orders: err=%v\n", err)
+		return
+	}
+
+	for _, order := range orders {
+		err = o.ordersStore.Release(ctx, order.Id, time.Now())
+		if err != nil {
+			log.Printf("failed to release scheduled order: id=%v, err=%v\n", order.Id.Hex(), err)
+		}
+	}
+}
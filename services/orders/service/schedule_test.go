@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"go-delivery/pb"
+	"google.golang.org/grpc"
+	"testing"
+	"time"
+)
+
+func TestParseDeliveryWindow(t *testing.T) {
+	leadTime := 45 * time.Minute
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	from := start.Unix()
+
+	tests := []struct {
+		name  string
+		from  int64
+		until int64
+		want  *deliveryWindow
+		ok    bool
+	}{
+		{"immediate", 0, 0, nil, true},
+		{"default length", from, 0, &deliveryWindow{From: start, Until: start.Add(defaultDeliveryWindow)}, true},
+		{"explicit end", from, start.Add(time.Hour).Unix(), &deliveryWindow{From: start, Until: start.Add(time.Hour)}, true},
+		{"longest window", from, start.Add(maxDeliveryWindow).Unix(), &deliveryWindow{From: start, Until: start.Add(maxDeliveryWindow)}, true},
+		{"end without start", 0, from, nil, false},
+		{"end before start", from, start.Add(-time.Minute).Unix(), nil, false},
+		{"empty window", from, from, nil, false},
+		{"too long", from, start.Add(maxDeliveryWindow + time.Second).Unix(), nil, false},
+		{"within the lead time", time.Now().Add(leadTime - time.Minute).Unix(), 0, nil, false},
+		{"in the past", time.Now().Add(-time.Hour).Unix(), 0, nil, false},
+		{"too far ahead", time.Now().Add(maxScheduleAhead + time.Hour).Unix(), 0, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := parseDeliveryWindow(test.from, test.until, leadTime)
+			if (err == nil) != test.ok {
+				t.Fatalf("parseDeliveryWindow() = %v, want ok %v", err, test.ok)
+			}
+
+			if (window == nil) != (test.want == nil) || window != nil && (!window.From.Equal(test.want.From) || !window.Until.Equal(test.want.Until)) {
+				t.Fatalf("parseDeliveryWindow() = %+v, want %+v", window, test.want)
+			}
+		})
+	}
+}
+
+// storesClient answers GetStore with the given store and records the request.
+type storesClient struct {
+	pb.StoresServiceClient
+	store *pb.Store
+	req   *pb.GetStoreRequest
+}
+
+func (c *storesClient) GetStore(_ context.Context, req *pb.GetStoreRequest, _ ...grpc.CallOption) (*pb.Store, error) {
+	c.req = req
+	return c.store, nil
+}
+
+func TestCheckStoreOpen(t *testing.T) {
+	window := &deliveryWindow{From: time.Unix(1700000000, 0), Until: time.Unix(1700001800, 0)}
+
+	t.Run("whole delivery window", func(t *testing.T) {
+		client := &storesClient{store: &pb.Store{Open: true}}
+		s := &service{storesClient: client}
+
+		err := s.checkStoreOpen(context.Background(), "s1", window)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if client.req.SellerId != "s1" || client.req.At != window.From.Unix() || client.req.Until != window.Until.Unix() {
+			t.Fatalf("GetStore() request = %+v, want the whole delivery window", client.req)
+		}
+	})
+
+	t.Run("immediate order", func(t *testing.T) {
+		client := &storesClient{store: &pb.Store{Open: true}}
+		s := &service{storesClient: client}
+
+		err := s.checkStoreOpen(context.Background(), "s1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if client.req.Until != 0 || time.Since(time.Unix(client.req.At, 0)) > time.Minute {
+			t.Fatalf("GetStore() request = %+v, want the current time", client.req)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		s := &service{storesClient: &storesClient{store: &pb.Store{ClosedReason: "outside opening hours"}}}
+
+		for _, window := range []*deliveryWindow{window, nil} {
+			if err := s.checkStoreOpen(context.Background(), "s1", window); err == nil {
+				t.Fatalf("checkStoreOpen(%+v) accepted a closed store", window)
+			}
+		}
+	})
+}
@@ -8,29 +8,42 @@ import (
 	"go-delivery/services/orders/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"io"
+	"log"
 	"time"
 )
 
 type service struct {
-	ordersStore    store.OrdersStore
-	walletsClient  pb.WalletsServiceClient
-	accountsClient pb.AccountsServiceClient
-	productsClient pb.ProductsServiceClient
+	ordersStore      store.OrdersStore
+	promotionsStore  store.PromotionsStore
+	platformUserId   string
+	scheduleLeadTime time.Duration
+	walletsClient    pb.WalletsServiceClient
+	accountsClient   pb.AccountsServiceClient
+	productsClient   pb.ProductsServiceClient
+	storesClient     pb.StoresServiceClient
 	pb.UnimplementedOrdersServiceServer
 }
 
 func NewService(
 	ordersStore store.OrdersStore,
+	promotionsStore store.PromotionsStore,
+	platformUserId string,
+	scheduleLeadTime time.Duration,
 	walletsClient pb.WalletsServiceClient,
 	accountsClient pb.AccountsServiceClient,
 	productsClient pb.ProductsServiceClient,
+	storesClient pb.StoresServiceClient,
 ) pb.OrdersServiceServer {
 
 	return &service{
-		ordersStore:    ordersStore,
-		walletsClient:  walletsClient,
-		accountsClient: accountsClient,
-		productsClient: productsClient,
+		ordersStore:      ordersStore,
+		promotionsStore:  promotionsStore,
+		platformUserId:   platformUserId,
+		scheduleLeadTime: scheduleLeadTime,
+		walletsClient:    walletsClient,
+		accountsClient:   accountsClient,
+		productsClient:   productsClient,
+		storesClient:     storesClient,
 	}
 }
 
@@ -40,86 +53,174 @@ func (s *service) CreateOrder(ctx context.Context, req *pb.Order) (*pb.Order, er
 		return nil, err
 	}
 
-	stream, err := s.productsClient.ListSellerProducts(ctx, &pb.ListSellerProductsRequest{SellerId: req.SellerId})
+	err = validateTip(req.Tip)
 	if err != nil {
 		return nil, err
 	}
 
-	var product *pb.Product
+	window, err := parseDeliveryWindow(req.DeliverFrom, req.DeliverUntil, s.scheduleLeadTime)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		received, err := stream.Recv()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
+	product, err := s.findProduct(ctx, req.SellerId, req.ProductId, window)
+	if err != nil {
+		return nil, err
+	}
 
-		if received.Id == req.ProductId {
-			product = received
-			break
-		}
+	item, err := priceItem(product, req.VariantId, req.OptionIds, req.Quantity)
+	if err != nil {
+		return nil, err
 	}
 
-	if product == nil {
-		return nil, fmt.Errorf("invalid order, product not found: productId=%v, sellerId=%s", req.ProductId, req.SellerId)
+	quote, err := s.quote(ctx, req.CustomerId, req.SellerId, product, item, req.PromoCode)
+	if err != nil {
+		return nil, err
 	}
 
-	if product.Quantity < req.Quantity {
-		return nil, fmt.Errorf("invalid order, products insufficient: productId=%v", req.ProductId)
+	address, err := s.getDeliveryAddress(ctx, req.CustomerId, req.AddressId)
+	if err != nil {
+		return nil, err
 	}
 
-	amount := (product.Price * float32(req.Quantity)) + product.DeliveryCost
+	amount := quote.Amount()
 
 	wallet, err := s.walletsClient.GetUserWallet(ctx, &pb.GetUserWalletRequest{UserId: req.CustomerId})
 	if err != nil {
 		return nil, err
 	}
 
-	if wallet.Cash < amount {
+	if wallet.Cash < amount+req.Tip {
 		return nil, fmt.Errorf("invalid order, amount insufficient: customerId=%v", req.CustomerId)
 	}
 
 	order := &store.Order{
-		Id:           id,
-		CustomerId:   req.CustomerId,
-		SellerId:     req.SellerId,
-		ProductId:    req.ProductId,
-		Status:       int32(pb.OrderStatus_Placed),
-		Quantity:     req.Quantity,
-		UnitPrice:    product.Price,
-		DeliveryCost: product.DeliveryCost,
-		Amount:       amount,
-		CreatedAt:    time.Unix(req.CreatedAt, 0),
-		UpdatedAt:    time.Unix(req.UpdatedAt, 0),
+		Id:             id,
+		CustomerId:     req.CustomerId,
+		SellerId:       req.SellerId,
+		ProductId:      req.ProductId,
+		Status:         int32(pb.OrderStatus_Placed),
+		Quantity:       req.Quantity,
+		UnitPrice:      item.UnitPrice,
+		DeliveryCost:   product.DeliveryCost,
+		Amount:         amount,
+		Subtotal:       quote.Subtotal,
+		Discount:       quote.Discount,
+		Tip:            req.Tip,
+		AddressId:      address.Id,
+		Address:        store.AddressFromProto(address),
+		Item:           item,
+		ProductVersion: product.Version,
+		CreatedAt:      time.Unix(req.CreatedAt, 0),
+		UpdatedAt:      time.Unix(req.UpdatedAt, 0),
+	}
+
+	if order.Tip > 0 {
+		tippedAt := order.CreatedAt
+		order.TippedAt = &tippedAt
+	}
+
+	// scheduled orders are paid now and released to the seller later.
+	if window != nil {
+		order.Status = int32(pb.OrderStatus_Scheduled)
+		order.DeliverFrom = &window.From
+		order.DeliverUntil = &window.Until
+	}
+
+	// every step that succeeds registers how to undo it, the order is only
+	// stored once paid and a failing step undoes the previous ones.
+	var undo []func()
+	rollback := func() {
+		for index := len(undo) - 1; index >= 0; index-- {
+			undo[index]()
+		}
 	}
 
-	err = s.ordersStore.Create(ctx, order)
-	if err != nil {
-		return nil, err
-	}
+	if quote.Promotion != nil {
+		// the per user limit checked by the quote is only advisory, the
+		// counters enforce both limits against concurrent checkouts.
+		err = s.promotionsStore.RedeemByCustomer(ctx, quote.Promotion, req.CustomerId)
+		if err != nil {
+			return nil, err
+		}
 
-	product.Quantity -= req.Quantity
+		err = s.promotionsStore.Redeem(ctx, quote.Promotion)
+		if err != nil {
+			releaseErr := s.promotionsStore.ReleaseByCustomer(ctx, quote.Promotion.Id.Hex(), req.CustomerId)
+			if releaseErr != nil {
+				log.Printf("failed to release promotion: orderId=%v, err=%v\n", id.Hex(), releaseErr)
+			}
+			return nil, err
+		}
+
+		undo = append(undo, func() { s.releasePromotion(ctx, order) })
+
+		err = s.promotionsStore.CreateRedemption(ctx, &store.Redemption{
+			Id:          primitive.NewObjectID(),
+			PromotionId: quote.Promotion.Id.Hex(),
+			CustomerId:  req.CustomerId,
+			OrderId:     id.Hex(),
+			Discount:    quote.Discount.Total(),
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+	}
 
-	_, err = s.productsClient.UpdateProduct(ctx, &pb.UpdateProductRequest{
-		Id:           product.Id,
-		Name:         product.Name,
-		Price:        product.Price,
-		DeliveryCost: product.DeliveryCost,
-		Quantity:     product.Quantity,
+	_, err = s.productsClient.AdjustInventory(ctx, &pb.AdjustInventoryRequest{
+		ProductId: product.Id,
+		VariantId: item.VariantId,
+		Reason:    pb.InventoryReason_InventoryOrder,
+		Delta:     -req.Quantity,
+		OrderId:   id.Hex(),
 	})
 	if err != nil {
+		rollback()
 		return nil, err
 	}
 
+	undo = append(undo, func() { s.restoreInventory(ctx, order) })
+
+	// the tip is held with the order amount until the delivery is confirmed.
 	debit := &pb.DebitRequest{
-		WalletId: wallet.Id,
-		Amount:   amount,
+		WalletId:  wallet.Id,
+		Amount:    amount + req.Tip,
+		Reference: "order:" + id.Hex(),
 	}
 
 	_, err = s.walletsClient.Debit(ctx, debit)
 	if err != nil {
+		rollback()
+		return nil, err
+	}
+
+	undo = append(undo, func() {
+		err := creditUser(ctx, s.walletsClient, order.CustomerId, debit.Amount, "order-rollback:"+id.Hex())
+		if err != nil {
+			log.Printf("failed to refund order rollback: orderId=%v, err=%v\n", id.Hex(), err)
+		}
+	})
+
+	if order.PlatformDiscount() > 0 {
+		err = s.debitPlatform(ctx, order.PlatformDiscount(), "order:"+id.Hex()+":platform")
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+
+		undo = append(undo, func() {
+			err := s.creditPlatform(ctx, order.PlatformDiscount(), "order-rollback:"+id.Hex()+":platform")
+			if err != nil {
+				log.Printf("failed to refund platform rollback: orderId=%v, err=%v\n", id.Hex(), err)
+			}
+		})
+	}
+
+	err = s.ordersStore.Create(ctx, order)
+	if err != nil {
+		rollback()
 		return nil, err
 	}
 
@@ -204,6 +305,10 @@ func (s *service) ApproveOrder(ctx context.Context, req *pb.ApproveOrderRequest)
 		return nil, err
 	}
 
+	if order.SellerId != req.SellerId {
+		return nil, fmt.Errorf("order not found: orderId=%v", req.Id)
+	}
+
 	if order.Status != int32(pb.OrderStatus_Placed) {
 		return nil, fmt.Errorf("can't change order status to accepted: orderId=%v", order.Id.Hex())
 	}
@@ -264,6 +369,9 @@ func (s *service) DeliverOrder(ctx context.Context, req *pb.DeliverOrderRequest)
 	return order.ToProto(), nil
 }
 
+// ConfirmOrderDelivered marks the order as delivered before paying the
+// seller and the deliverer. The payouts carry a reference, confirming a
+// delivered order again retries them only.
 func (s *service) ConfirmOrderDelivered(ctx context.Context, req *pb.ConfirmOrderDeliveredRequest) (*pb.Order, error) {
 	id, err := primitive.ObjectIDFromHex(req.Id)
 	if err != nil {
@@ -275,7 +383,11 @@ func (s *service) ConfirmOrderDelivered(ctx context.Context, req *pb.ConfirmOrde
 		return nil, err
 	}
 
-	if order.Status != int32(pb.OrderStatus_Delivering) {
+	if order.CustomerId != req.CustomerId {
+		return nil, fmt.Errorf("order not found: orderId=%v", req.Id)
+	}
+
+	if order.Status != int32(pb.OrderStatus_Delivering) && order.Status != int32(pb.OrderStatus_Delivered) {
 		return nil, fmt.Errorf("can't change order status to delivered: orderId=%v", order.Id.Hex())
 	}
 
@@ -288,48 +400,76 @@ func (s *service) ConfirmOrderDelivered(ctx context.Context, req *pb.ConfirmOrde
 		return nil, fmt.Errorf("can't change order status to delivered, role is not allowed: customerId=%v", customer.Id)
 	}
 
-	walletSeller, err := s.walletsClient.GetUserWallet(ctx, &pb.GetUserWalletRequest{UserId: order.SellerId})
+	if order.Status == int32(pb.OrderStatus_Delivering) {
+		now := time.Now()
+
+		err = s.ordersStore.Deliver(ctx, id, now)
+		if err != nil {
+			return nil, err
+		}
+
+		order.Status = int32(pb.OrderStatus_Delivered)
+		order.DeliveredAt = &now
+		order.UpdatedAt = now
+	}
+
+	err = creditUser(ctx, s.walletsClient, order.SellerId, order.SellerPayout(), "order-delivered:"+order.Id.Hex()+":seller")
 	if err != nil {
 		return nil, err
 	}
 
-	sellerAmount := order.UnitPrice * float32(order.Quantity)
-
-	_, err = s.walletsClient.Credit(ctx, &pb.CreditRequest{
-		WalletId: walletSeller.Id,
-		Amount:   sellerAmount,
-	})
+	err = creditUser(ctx, s.walletsClient, order.DeliveryId, order.DelivererPayout(), "order-delivered:"+order.Id.Hex()+":deliverer")
 	if err != nil {
 		return nil, err
 	}
 
-	walletDeliverer, err := s.walletsClient.GetUserWallet(ctx, &pb.GetUserWalletRequest{UserId: order.DeliveryId})
+	return order.ToProto(), nil
+}
+
+// CancelOrder marks the order as canceled before any side effect, so only
+// the first call gives the stock and the promotion back. The refunds carry
+// a reference, calling it again on a canceled order retries them only.
+func (s *service) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*empty.Empty, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	delivererAmount := order.Amount - sellerAmount
-
-	_, err = s.walletsClient.Credit(ctx, &pb.CreditRequest{
-		WalletId: walletDeliverer.Id,
-		Amount:   delivererAmount,
-	})
+	order, err := s.ordersStore.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	order.Status = int32(pb.OrderStatus_Delivered)
-	order.UpdatedAt = time.Now()
+	if order.Status != int32(pb.OrderStatus_Canceled) {
+		if !order.CanCancel() {
+			return nil, fmt.Errorf("order can't be canceled: orderId=%v", order.Id.Hex())
+		}
 
-	err = s.ordersStore.Update(ctx, order)
+		err = s.ordersStore.Cancel(ctx, id, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		s.releasePromotion(ctx, order)
+		s.restoreInventory(ctx, order)
+	}
+
+	err = creditUser(ctx, s.walletsClient, order.CustomerId, order.Amount+order.Tip, "order-cancel:"+order.Id.Hex())
 	if err != nil {
 		return nil, err
 	}
 
-	return order.ToProto(), nil
+	if order.PlatformDiscount() > 0 {
+		err = s.creditPlatform(ctx, order.PlatformDiscount(), "order-cancel:"+order.Id.Hex()+":platform")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &empty.Empty{}, nil
 }
 
-func (s *service) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*empty.Empty, error) {
+func (s *service) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*empty.Empty, error) {
 	id, err := primitive.ObjectIDFromHex(req.Id)
 	if err != nil {
 		return nil, err
@@ -340,65 +480,121 @@ func (s *service) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (
 		return nil, err
 	}
 
-	if !order.CanCancel() {
-		return nil, fmt.Errorf("order can't be canceled: orderId=%v", order.Id)
-	}
-
-	customerWallet, err := s.walletsClient.GetUserWallet(ctx, &pb.GetUserWalletRequest{UserId: order.CustomerId})
-	if err != nil {
-		return nil, err
+	if order.CanCancel() {
+		_, err = s.CancelOrder(ctx, &pb.CancelOrderRequest{Id: id.Hex()})
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	product, err := s.productsClient.GetProduct(ctx, &pb.GetProductRequest{Id: order.ProductId})
+	err = s.ordersStore.Delete(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	credit := &pb.CreditRequest{
-		WalletId: customerWallet.Id,
-		Amount:   order.Amount,
-	}
+	return &empty.Empty{}, nil
+}
 
-	_, err = s.walletsClient.Credit(ctx, credit)
+// findProduct returns the product of the seller, the seller store must be
+// open to take orders, or during the delivery window of scheduled ones.
+func (s *service) findProduct(ctx context.Context, sellerId, productId string, window *deliveryWindow) (*pb.Product, error) {
+	err := s.checkStoreOpen(ctx, sellerId, window)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = s.productsClient.UpdateProduct(ctx, &pb.UpdateProductRequest{
-		Id:           product.Id,
-		Name:         product.Name,
-		Price:        product.Price,
-		DeliveryCost: product.DeliveryCost,
-		Quantity:     product.Quantity + order.Quantity,
+	stream, err := s.productsClient.ListSellerProducts(ctx, &pb.ListSellerProductsRequest{
+		SellerId:          sellerId,
+		IncludeOutOfStock: true,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &empty.Empty{}, nil
+	for {
+		product, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if product.Id == productId {
+			return product, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid order, product not found: productId=%v, sellerId=%s", productId, sellerId)
 }
-func (s *service) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*empty.Empty, error) {
-	id, err := primitive.ObjectIDFromHex(req.Id)
-	if err != nil {
-		return nil, err
+
+// getDeliveryAddress returns the given customer address, or the default one
+// when no address is given.
+func (s *service) getDeliveryAddress(ctx context.Context, customerId, addressId string) (*pb.Address, error) {
+	if addressId != "" {
+		return s.accountsClient.GetAddress(ctx, &pb.GetAddressRequest{Id: addressId, UserId: customerId})
 	}
 
-	order, err := s.ordersStore.Get(ctx, id)
+	stream, err := s.accountsClient.ListAddresses(ctx, &pb.ListAddressesRequest{UserId: customerId})
 	if err != nil {
 		return nil, err
 	}
 
-	if order.CanCancel() {
-		_, err = s.CancelOrder(ctx, &pb.CancelOrderRequest{Id: id.Hex()})
+	for {
+		address, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return nil, err
 		}
+
+		if address.IsDefault {
+			return address, nil
+		}
 	}
 
-	err = s.ordersStore.Delete(ctx, id)
+	return nil, fmt.Errorf("invalid order, delivery address required: customerId=%v", customerId)
+}
+
+// restoreInventory puts back the stock taken by an order that is not
+// placed after all, failures are only logged.
+func (s *service) restoreInventory(ctx context.Context, order *store.Order) {
+	var variantId string
+	if order.Item != nil {
+		variantId = order.Item.VariantId
+	}
+
+	_, err := s.productsClient.AdjustInventory(ctx, &pb.AdjustInventoryRequest{
+		ProductId: order.ProductId,
+		VariantId: variantId,
+		Reason:    pb.InventoryReason_InventoryCancel,
+		Delta:     order.Quantity,
+		OrderId:   order.Id.Hex(),
+	})
 	if err != nil {
-		return nil, err
+		log.Printf("failed to restore inventory: orderId=%v, err=%v\n", order.Id.Hex(), err)
 	}
+}
 
-	return &empty.Empty{}, nil
+// debitUser and creditUser move the wallet cash of a user, a movement with
+// a reference is applied once however many times it is retried.
+func debitUser(ctx context.Context, walletsClient pb.WalletsServiceClient, userId string, amount float32, reference string) error {
+	wallet, err := walletsClient.GetUserWallet(ctx, &pb.GetUserWalletRequest{UserId: userId})
+	if err != nil {
+		return err
+	}
+
+	_, err = walletsClient.Debit(ctx, &pb.DebitRequest{WalletId: wallet.Id, Amount: amount, Reference: reference})
+	return err
+}
+
+func creditUser(ctx context.Context, walletsClient pb.WalletsServiceClient, userId string, amount float32, reference string) error {
+	wallet, err := walletsClient.GetUserWallet(ctx, &pb.GetUserWalletRequest{UserId: userId})
+	if err != nil {
+		return err
+	}
+
+	_, err = walletsClient.Credit(ctx, &pb.CreditRequest{WalletId: wallet.Id, Amount: amount, Reference: reference})
+	return err
 }
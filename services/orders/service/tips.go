@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	maxTip        = 500
+	tipWindow     = 24 * time.Hour
+	earningsRange = 366 * 24 * time.Hour
+)
+
+// AddTip debits the customer and credits the whole tip to the deliverer
+// right away, the order keeps the tip for the earnings.
+func (s *service) AddTip(ctx context.Context, req *pb.AddTipRequest) (*pb.Order, error) {
+	id, err := primitive.ObjectIDFromHex(req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("invalid tip, amount must be greater than zero: amount=%v", req.Amount)
+	}
+
+	err = validateTip(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.ordersStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.CustomerId != req.CustomerId {
+		return nil, fmt.Errorf("order not found: orderId=%v", req.OrderId)
+	}
+
+	if order.Status != int32(pb.OrderStatus_Delivered) {
+		return nil, fmt.Errorf("invalid tip, order not delivered: orderId=%v", req.OrderId)
+	}
+
+	deliveredAt := order.UpdatedAt
+	if order.DeliveredAt != nil {
+		deliveredAt = *order.DeliveredAt
+	}
+
+	now := time.Now()
+
+	if now.Sub(deliveredAt) > tipWindow {
+		return nil, fmt.Errorf("invalid tip, orders can be tipped up to %v after delivery: orderId=%v", tipWindow, req.OrderId)
+	}
+
+	customerWallet, err := s.walletsClient.GetUserWallet(ctx, &pb.GetUserWalletRequest{UserId: order.CustomerId})
+	if err != nil {
+		return nil, err
+	}
+
+	if customerWallet.Cash < req.Amount {
+		return nil, fmt.Errorf("invalid tip, amount insufficient: customerId=%v", order.CustomerId)
+	}
+
+	err = s.ordersStore.SetTip(ctx, id, req.Amount, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// a tip undone on failure can be given again, so the reference of the
+	// transfers names the attempt and not only the order.
+	reference := "tip:" + id.Hex() + ":" + strconv.FormatInt(now.UnixNano(), 10)
+
+	var undo []func()
+	rollback := func() {
+		for index := len(undo) - 1; index >= 0; index-- {
+			undo[index]()
+		}
+	}
+
+	undo = append(undo, func() {
+		err := s.ordersStore.ClearTip(ctx, id)
+		if err != nil {
+			log.Printf("failed to clear tip: orderId=%v, err=%v\n", req.OrderId, err)
+		}
+	})
+
+	err = debitUser(ctx, s.walletsClient, order.CustomerId, req.Amount, reference)
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+
+	undo = append(undo, func() {
+		err := creditUser(ctx, s.walletsClient, order.CustomerId, req.Amount, reference+":refund")
+		if err != nil {
+			log.Printf("failed to refund tip: orderId=%v, err=%v\n", req.OrderId, err)
+		}
+	})
+
+	err = creditUser(ctx, s.walletsClient, order.DeliveryId, req.Amount, reference)
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+
+	order.Tip = req.Amount
+	order.TippedAt = &now
+
+	return order.ToProto(), nil
+}
+
+func (s *service) GetDelivererEarnings(ctx context.Context, req *pb.GetDelivererEarningsRequest) (*pb.DelivererEarnings, error) {
+	_, err := primitive.ObjectIDFromHex(req.DelivererId)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to time.Time
+	if req.From > 0 {
+		from = time.Unix(req.From, 0)
+	}
+	if req.To > 0 {
+		to = time.Unix(req.To, 0)
+	}
+
+	if !from.IsZero() && !to.IsZero() && (!to.After(from) || to.Sub(from) > earningsRange) {
+		return nil, fmt.Errorf("invalid earnings range: from=%v, to=%v", req.From, req.To)
+	}
+
+	orders, err := s.ordersStore.GetDelivered(ctx, req.DelivererId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	earnings := &pb.DelivererEarnings{DelivererId: req.DelivererId}
+
+	for _, order := range orders {
+		earning := &pb.DeliveryEarning{
+			OrderId:     order.Id.Hex(),
+			DeliveryFee: order.DeliveryCost,
+			Tip:         order.Tip,
+		}
+
+		if order.DeliveredAt != nil {
+			earning.DeliveredAt = order.DeliveredAt.Unix()
+		}
+
+		earnings.Deliveries++
+		earnings.DeliveryFees += order.DeliveryCost
+		earnings.Tips += order.Tip
+		earnings.Orders = append(earnings.Orders, earning)
+	}
+
+	earnings.DeliveryFees = roundCents(earnings.DeliveryFees)
+	earnings.Tips = roundCents(earnings.Tips)
+	earnings.Total = roundCents(earnings.DeliveryFees + earnings.Tips)
+
+	return earnings, nil
+}
+
+func validateTip(tip float32) error {
+	if tip < 0 || tip > maxTip {
+		return fmt.Errorf("invalid tip, amount must be between 0 and %d: amount=%v", maxTip, tip)
+	}
+	return nil
+}
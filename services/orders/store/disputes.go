@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const DisputesCollection = "disputes"
+
+type DisputesStore interface {
+	Create(ctx context.Context, dispute *Dispute) error
+	Get(ctx context.Context, id primitive.ObjectID) (*Dispute, error)
+	GetByCustomer(ctx context.Context, customerId string) ([]*Dispute, error)
+	GetByStatus(ctx context.Context, status int32) ([]*Dispute, error)
+	Close(ctx context.Context, dispute *Dispute) error
+	Finish(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Reopen(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	CreateIndexes(ctx context.Context) error
+}
+
+type disputesStore struct {
+	conn *mongo.Collection
+}
+
+func NewDisputesStore(dbConn *mongo.Database) DisputesStore {
+	return &disputesStore{conn: dbConn.Collection(DisputesCollection)}
+}
+
+func (s *disputesStore) Create(ctx context.Context, dispute *Dispute) error {
+	result, err := s.conn.InsertOne(ctx, dispute)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("order already disputed: orderId=%v", dispute.OrderId)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("dispute opened: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *disputesStore) Get(ctx context.Context, id primitive.ObjectID) (*Dispute, error) {
+	var dispute Dispute
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(&dispute)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dispute, nil
+}
+
+func (s *disputesStore) GetByCustomer(ctx context.Context, customerId string) ([]*Dispute, error) {
+	return s.find(ctx, bson.M{"customer_id": customerId})
+}
+
+func (s *disputesStore) GetByStatus(ctx context.Context, status int32) ([]*Dispute, error) {
+	return s.find(ctx, bson.M{"status": status})
+}
+
+func (s *disputesStore) find(ctx context.Context, filter bson.M) ([]*Dispute, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var disputes []*Dispute
+
+	err = cursor.All(ctx, &disputes)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+// Close saves the resolution of an open dispute, a dispute already closed
+// by another admin is left untouched.
+func (s *disputesStore) Close(ctx context.Context, dispute *Dispute) error {
+	filter := bson.M{"_id": dispute.Id, "status": int32(pb.DisputeStatus_DisputeOpen)}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           dispute.Status,
+			"refund_amount":    dispute.RefundAmount,
+			"seller_charge":    dispute.SellerCharge,
+			"deliverer_charge": dispute.DelivererCharge,
+			"platform_charge":  dispute.PlatformCharge,
+			"resolution_note":  dispute.ResolutionNote,
+			"resolved_by":      dispute.ResolvedBy,
+			"resolved_at":      dispute.ResolvedAt,
+			"updated_at":       dispute.UpdatedAt,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("dispute already closed: id=%v", dispute.Id.Hex())
+	}
+
+	log.Printf("dispute closed: id=%v, status=%v\n", dispute.Id.Hex(), dispute.Status)
+	return nil
+}
+
+// Finish marks a resolving dispute as resolved once all its transfers are
+// applied.
+func (s *disputesStore) Finish(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "status": int32(pb.DisputeStatus_DisputeResolving)}
+	update := bson.M{"$set": bson.M{"status": int32(pb.DisputeStatus_DisputeResolved), "updated_at": at}}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("dispute not resolving: id=%v", id.Hex())
+	}
+
+	log.Printf("dispute resolved: id=%v\n", id.Hex())
+	return nil
+}
+
+// Reopen returns a resolving dispute whose transfers can't be applied to the
+// open disputes, the resolution is cleared so another one can be saved.
+func (s *disputesStore) Reopen(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "status": int32(pb.DisputeStatus_DisputeResolving)}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           int32(pb.DisputeStatus_DisputeOpen),
+			"refund_amount":    float32(0),
+			"seller_charge":    float32(0),
+			"deliverer_charge": float32(0),
+			"platform_charge":  float32(0),
+			"resolution_note":  "",
+			"resolved_by":      "",
+			"resolved_at":      nil,
+			"updated_at":       at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("dispute not resolving: id=%v", id.Hex())
+	}
+
+	log.Printf("dispute reopened: id=%v\n", id.Hex())
+	return nil
+}
+
+func (s *disputesStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetName("disputes_order").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "customer_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("disputes_customer"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("disputes_status"),
+		},
+	})
+	return err
+}
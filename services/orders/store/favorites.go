@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const FavoritesCollection = "favorites"
+
+type FavoritesStore interface {
+	Create(ctx context.Context, favorite *Favorite) error
+	Delete(ctx context.Context, customerId string, kind int32, targetId string) error
+	GetByCustomer(ctx context.Context, customerId string, kind int32) ([]*Favorite, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type favoritesStore struct {
+	conn *mongo.Collection
+}
+
+func NewFavoritesStore(dbConn *mongo.Database) FavoritesStore {
+	return &favoritesStore{conn: dbConn.Collection(FavoritesCollection)}
+}
+
+func (s *favoritesStore) Create(ctx context.Context, favorite *Favorite) error {
+	result, err := s.conn.InsertOne(ctx, favorite)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("already a favorite: targetId=%v", favorite.TargetId)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("favorite created: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *favoritesStore) Delete(ctx context.Context, customerId string, kind int32, targetId string) error {
+	filter := bson.M{"customer_id": customerId, "kind": kind, "target_id": targetId}
+
+	result, err := s.conn.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("favorite not found: targetId=%v", targetId)
+	}
+
+	log.Printf("favorite deleted: customerId=%v, targetId=%v\n", customerId, targetId)
+	return nil
+}
+
+// GetByCustomer returns the favorites of the kind, the latest saved first.
+func (s *favoritesStore) GetByCustomer(ctx context.Context, customerId string, kind int32) ([]*Favorite, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, bson.M{"customer_id": customerId, "kind": kind}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var favorites []*Favorite
+
+	err = cursor.All(ctx, &favorites)
+	if err != nil {
+		return nil, err
+	}
+
+	return favorites, nil
+}
+
+func (s *favoritesStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "customer_id", Value: 1}, {Key: "kind", Value: 1}, {Key: "target_id", Value: 1}},
+		Options: options.Index().SetName("favorites_customer_target").SetUnique(true),
+	})
+	return err
+}
@@ -6,40 +6,227 @@ import (
 	"time"
 )
 
+// Order documents also hold the references of the refunds already added,
+// they are not loaded.
 type Order struct {
-	Id           primitive.ObjectID `bson:"_id"`
-	CustomerId   string             `bson:"customer_id"`
-	SellerId     string             `bson:"seller_id"`
-	ProductId    string             `bson:"product_id"`
-	DeliveryId   string             `bson:"delivery_id"`
-	Status       int32              `bson:"status"`
-	Quantity     int32              `bson:"quantity"`
-	UnitPrice    float32            `bson:"unit_price"`
-	DeliveryCost float32            `bson:"delivery_cost"`
-	Amount       float32            `bson:"amount"`
-	CreatedAt    time.Time          `bson:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at"`
+	Id             primitive.ObjectID `bson:"_id"`
+	CustomerId     string             `bson:"customer_id"`
+	SellerId       string             `bson:"seller_id"`
+	ProductId      string             `bson:"product_id"`
+	DeliveryId     string             `bson:"delivery_id"`
+	Status         int32              `bson:"status"`
+	Quantity       int32              `bson:"quantity"`
+	UnitPrice      float32            `bson:"unit_price"`
+	DeliveryCost   float32            `bson:"delivery_cost"`
+	Amount         float32            `bson:"amount"`
+	AddressId      string             `bson:"address_id"`
+	Address        *Address           `bson:"address"`
+	Item           *Item              `bson:"item"`
+	ProductVersion int32              `bson:"product_version"`
+	Subtotal       float32            `bson:"subtotal"`
+	Discount       *Discount          `bson:"discount"`
+	Tip            float32            `bson:"tip"`
+	TippedAt       *time.Time         `bson:"tipped_at"`
+	DeliveredAt    *time.Time         `bson:"delivered_at"`
+	Refunded       float32            `bson:"refunded"`
+	DeliverFrom    *time.Time         `bson:"deliver_from"`
+	DeliverUntil   *time.Time         `bson:"deliver_until"`
+	ReleasedAt     *time.Time         `bson:"released_at"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at"`
 }
 
 func (o *Order) ToProto() *pb.Order {
-	return &pb.Order{
-		Id:           o.Id.Hex(),
-		CustomerId:   o.CustomerId,
-		SellerId:     o.SellerId,
-		ProductId:    o.ProductId,
-		DelivererId:  o.DeliveryId,
-		Status:       pb.OrderStatus(o.Status),
-		Quantity:     o.Quantity,
-		UnitPrice:    o.UnitPrice,
-		DeliveryCost: o.DeliveryCost,
-		Amount:       o.Amount,
-		CreatedAt:    o.CreatedAt.Unix(),
-		UpdatedAt:    o.UpdatedAt.Unix(),
+	order := &pb.Order{
+		Id:             o.Id.Hex(),
+		CustomerId:     o.CustomerId,
+		SellerId:       o.SellerId,
+		ProductId:      o.ProductId,
+		DelivererId:    o.DeliveryId,
+		Status:         pb.OrderStatus(o.Status),
+		Quantity:       o.Quantity,
+		UnitPrice:      o.UnitPrice,
+		DeliveryCost:   o.DeliveryCost,
+		Amount:         o.Amount,
+		AddressId:      o.AddressId,
+		ProductVersion: o.ProductVersion,
+		Subtotal:       o.Subtotal,
+		Tip:            o.Tip,
+		Refunded:       o.Refunded,
+		CreatedAt:      o.CreatedAt.Unix(),
+		UpdatedAt:      o.UpdatedAt.Unix(),
+	}
+
+	if o.Address != nil {
+		order.DeliveryAddress = o.Address.ToProto()
 	}
+
+	if o.Item != nil {
+		order.Item = o.Item.ToProto()
+		order.VariantId = o.Item.VariantId
+	}
+
+	if o.Discount != nil {
+		order.Discount = o.Discount.ToProto()
+		order.PromoCode = o.Discount.Code
+	}
+
+	if o.TippedAt != nil {
+		order.TippedAt = o.TippedAt.Unix()
+	}
+
+	if o.DeliveredAt != nil {
+		order.DeliveredAt = o.DeliveredAt.Unix()
+	}
+
+	if o.DeliverFrom != nil {
+		order.DeliverFrom = o.DeliverFrom.Unix()
+	}
+
+	if o.DeliverUntil != nil {
+		order.DeliverUntil = o.DeliverUntil.Unix()
+	}
+
+	if o.ReleasedAt != nil {
+		order.ReleasedAt = o.ReleasedAt.Unix()
+	}
+
+	return order
+}
+
+// SellerPayout is what the seller is credited on delivery, a seller funded
+// discount is taken from it.
+func (o *Order) SellerPayout() float32 {
+	payout := o.UnitPrice * float32(o.Quantity)
+	if o.Discount != nil && o.Discount.FundedBy == FundedBySeller {
+		payout -= o.Discount.Total()
+	}
+	return payout
+}
+
+// DelivererPayout is what the deliverer is credited on delivery, the tip
+// given at checkout included. Tips given after the delivery are credited
+// on their own.
+func (o *Order) DelivererPayout() float32 {
+	if o.TippedAt == nil || o.DeliveredAt != nil && o.TippedAt.After(*o.DeliveredAt) {
+		return o.DeliveryCost
+	}
+	return o.DeliveryCost + o.Tip
+}
+
+// PlatformDiscount is the part of the discount paid by the platform wallet.
+func (o *Order) PlatformDiscount() float32 {
+	if o.Discount == nil || o.Discount.FundedBy != FundedByPlatform {
+		return 0
+	}
+	return o.Discount.Total()
+}
+
+const (
+	FundedBySeller   = "seller"
+	FundedByPlatform = "platform"
+)
+
+// Discount is the promotion breakdown of the order, the platform funded
+// part is debited from the platform wallet at checkout.
+type Discount struct {
+	PromotionId      string  `bson:"promotion_id"`
+	Code             string  `bson:"code"`
+	ItemDiscount     float32 `bson:"item_discount"`
+	DeliveryDiscount float32 `bson:"delivery_discount"`
+	FundedBy         string  `bson:"funded_by"`
+}
+
+func (d *Discount) Total() float32 {
+	return d.ItemDiscount + d.DeliveryDiscount
+}
+
+func (d *Discount) ToProto() *pb.OrderDiscount {
+	return &pb.OrderDiscount{
+		PromotionId:      d.PromotionId,
+		Code:             d.Code,
+		ItemDiscount:     d.ItemDiscount,
+		DeliveryDiscount: d.DeliveryDiscount,
+		FundedBy:         d.FundedBy,
+	}
+}
+
+// Address is the delivery address snapshot taken when the order is created.
+type Address struct {
+	Label        string  `bson:"label"`
+	Street       string  `bson:"street"`
+	Latitude     float64 `bson:"latitude"`
+	Longitude    float64 `bson:"longitude"`
+	Instructions string  `bson:"instructions"`
+}
+
+func (a *Address) ToProto() *pb.DeliveryAddress {
+	return &pb.DeliveryAddress{
+		Label:        a.Label,
+		Street:       a.Street,
+		Latitude:     a.Latitude,
+		Longitude:    a.Longitude,
+		Instructions: a.Instructions,
+	}
+}
+
+func AddressFromProto(a *pb.Address) *Address {
+	return &Address{
+		Label:        a.Label,
+		Street:       a.Street,
+		Latitude:     a.Latitude,
+		Longitude:    a.Longitude,
+		Instructions: a.Instructions,
+	}
+}
+
+type ItemOption struct {
+	GroupId    string  `bson:"group_id"`
+	GroupName  string  `bson:"group_name"`
+	OptionId   string  `bson:"option_id"`
+	Name       string  `bson:"name"`
+	PriceDelta float32 `bson:"price_delta"`
+}
+
+// Item is the priced product configuration of the order.
+type Item struct {
+	ProductId   string       `bson:"product_id"`
+	ProductName string       `bson:"product_name"`
+	VariantId   string       `bson:"variant_id"`
+	VariantName string       `bson:"variant_name"`
+	SKU         string       `bson:"sku"`
+	Options     []ItemOption `bson:"options"`
+	UnitPrice   float32      `bson:"unit_price"`
+	Quantity    int32        `bson:"quantity"`
+}
+
+func (i *Item) ToProto() *pb.OrderItem {
+	item := &pb.OrderItem{
+		ProductId:   i.ProductId,
+		ProductName: i.ProductName,
+		VariantId:   i.VariantId,
+		VariantName: i.VariantName,
+		Sku:         i.SKU,
+		UnitPrice:   i.UnitPrice,
+		Quantity:    i.Quantity,
+	}
+
+	for _, option := range i.Options {
+		item.Options = append(item.Options, &pb.OrderItemOption{
+			GroupId:    option.GroupId,
+			GroupName:  option.GroupName,
+			OptionId:   option.OptionId,
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+		})
+	}
+
+	return item
 }
 
 func (o *Order) CanCancel() bool {
-	return pb.OrderStatus(o.Status) != pb.OrderStatus_Delivered
+	status := pb.OrderStatus(o.Status)
+	return status != pb.OrderStatus_Delivered && status != pb.OrderStatus_Canceled
 }
 
 func FromProto(o *pb.Order) (*Order, error) {
@@ -80,7 +267,184 @@ func FromProto(o *pb.Order) (*Order, error) {
 		UnitPrice:    o.UnitPrice,
 		DeliveryCost: o.DeliveryCost,
 		Amount:       o.Amount,
+		AddressId:    o.AddressId,
 		CreatedAt:    time.Unix(o.CreatedAt, 0),
 		UpdatedAt:    time.Unix(o.UpdatedAt, 0),
 	}, nil
 }
+
+type Promotion struct {
+	Id           primitive.ObjectID `bson:"_id"`
+	Code         string             `bson:"code"`
+	Description  string             `bson:"description"`
+	Kind         int32              `bson:"kind"`
+	Value        float32            `bson:"value"`
+	MaxDiscount  float32            `bson:"max_discount"`
+	MinBasket    float32            `bson:"min_basket"`
+	SellerId     string             `bson:"seller_id"`
+	Funding      int32              `bson:"funding"`
+	UsageLimit   int32              `bson:"usage_limit"`
+	PerUserLimit int32              `bson:"per_user_limit"`
+	Uses         int32              `bson:"uses"`
+	StartsAt     time.Time          `bson:"starts_at"`
+	EndsAt       time.Time          `bson:"ends_at"`
+	Active       bool               `bson:"active"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+}
+
+func (p *Promotion) ToProto() *pb.Promotion {
+	promotion := &pb.Promotion{
+		Id:           p.Id.Hex(),
+		Code:         p.Code,
+		Description:  p.Description,
+		Kind:         pb.PromotionKind(p.Kind),
+		Value:        p.Value,
+		MaxDiscount:  p.MaxDiscount,
+		MinBasket:    p.MinBasket,
+		SellerId:     p.SellerId,
+		Funding:      pb.PromotionFunding(p.Funding),
+		UsageLimit:   p.UsageLimit,
+		PerUserLimit: p.PerUserLimit,
+		Uses:         p.Uses,
+		Active:       p.Active,
+		CreatedAt:    p.CreatedAt.Unix(),
+		UpdatedAt:    p.UpdatedAt.Unix(),
+	}
+
+	if !p.StartsAt.IsZero() {
+		promotion.StartsAt = p.StartsAt.Unix()
+	}
+	if !p.EndsAt.IsZero() {
+		promotion.EndsAt = p.EndsAt.Unix()
+	}
+
+	return promotion
+}
+
+// Redemption is a use of a promotion by a customer, one per order.
+type Redemption struct {
+	Id          primitive.ObjectID `bson:"_id"`
+	PromotionId string             `bson:"promotion_id"`
+	CustomerId  string             `bson:"customer_id"`
+	OrderId     string             `bson:"order_id"`
+	Discount    float32            `bson:"discount"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+type Review struct {
+	Id               primitive.ObjectID `bson:"_id"`
+	OrderId          string             `bson:"order_id"`
+	CustomerId       string             `bson:"customer_id"`
+	SellerId         string             `bson:"seller_id"`
+	ProductId        string             `bson:"product_id"`
+	DelivererId      string             `bson:"deliverer_id"`
+	SellerRating     int32              `bson:"seller_rating"`
+	ProductRating    int32              `bson:"product_rating"`
+	DelivererRating  int32              `bson:"deliverer_rating"`
+	Comment          string             `bson:"comment"`
+	Hidden           bool               `bson:"hidden"`
+	ModerationReason string             `bson:"moderation_reason"`
+	ModeratedBy      string             `bson:"moderated_by"`
+	ModeratedAt      *time.Time         `bson:"moderated_at"`
+	CreatedAt        time.Time          `bson:"created_at"`
+	UpdatedAt        time.Time          `bson:"updated_at"`
+}
+
+func (r *Review) ToProto() *pb.Review {
+	review := &pb.Review{
+		Id:               r.Id.Hex(),
+		OrderId:          r.OrderId,
+		CustomerId:       r.CustomerId,
+		SellerId:         r.SellerId,
+		ProductId:        r.ProductId,
+		DelivererId:      r.DelivererId,
+		SellerRating:     r.SellerRating,
+		ProductRating:    r.ProductRating,
+		DelivererRating:  r.DelivererRating,
+		Comment:          r.Comment,
+		Hidden:           r.Hidden,
+		ModerationReason: r.ModerationReason,
+		ModeratedBy:      r.ModeratedBy,
+		CreatedAt:        r.CreatedAt.Unix(),
+		UpdatedAt:        r.UpdatedAt.Unix(),
+	}
+
+	if r.ModeratedAt != nil {
+		review.ModeratedAt = r.ModeratedAt.Unix()
+	}
+
+	return review
+}
+
+type Dispute struct {
+	Id              primitive.ObjectID `bson:"_id"`
+	OrderId         string             `bson:"order_id"`
+	CustomerId      string             `bson:"customer_id"`
+	SellerId        string             `bson:"seller_id"`
+	DelivererId     string             `bson:"deliverer_id"`
+	Reason          int32              `bson:"reason"`
+	Description     string             `bson:"description"`
+	Status          int32              `bson:"status"`
+	RefundAmount    float32            `bson:"refund_amount"`
+	SellerCharge    float32            `bson:"seller_charge"`
+	DelivererCharge float32            `bson:"deliverer_charge"`
+	PlatformCharge  float32            `bson:"platform_charge"`
+	ResolutionNote  string             `bson:"resolution_note"`
+	ResolvedBy      string             `bson:"resolved_by"`
+	ResolvedAt      *time.Time         `bson:"resolved_at"`
+	CreatedAt       time.Time          `bson:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at"`
+}
+
+func (d *Dispute) ToProto() *pb.Dispute {
+	dispute := &pb.Dispute{
+		Id:              d.Id.Hex(),
+		OrderId:         d.OrderId,
+		CustomerId:      d.CustomerId,
+		SellerId:        d.SellerId,
+		DelivererId:     d.DelivererId,
+		Reason:          pb.DisputeReason(d.Reason),
+		Description:     d.Description,
+		Status:          pb.DisputeStatus(d.Status),
+		RefundAmount:    d.RefundAmount,
+		SellerCharge:    d.SellerCharge,
+		DelivererCharge: d.DelivererCharge,
+		PlatformCharge:  d.PlatformCharge,
+		ResolutionNote:  d.ResolutionNote,
+		ResolvedBy:      d.ResolvedBy,
+		CreatedAt:       d.CreatedAt.Unix(),
+		UpdatedAt:       d.UpdatedAt.Unix(),
+	}
+
+	if d.ResolvedAt != nil {
+		dispute.ResolvedAt = d.ResolvedAt.Unix()
+	}
+
+	return dispute
+}
+
+// Favorite is a product or a store saved by a customer, TargetId is the
+// product id or the seller id of the store. Name is kept from when it was
+// saved, in case the target is gone.
+type Favorite struct {
+	Id         primitive.ObjectID `bson:"_id"`
+	CustomerId string             `bson:"customer_id"`
+	Kind       int32              `bson:"kind"`
+	TargetId   string             `bson:"target_id"`
+	SellerId   string             `bson:"seller_id"`
+	Name       string             `bson:"name"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
+
+func (f *Favorite) ToProto() *pb.Favorite {
+	return &pb.Favorite{
+		Id:         f.Id.Hex(),
+		CustomerId: f.CustomerId,
+		Kind:       pb.FavoriteKind(f.Kind),
+		TargetId:   f.TargetId,
+		SellerId:   f.SellerId,
+		Name:       f.Name,
+		CreatedAt:  f.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const (
+	PromotionsCollection   = "promotions"
+	RedemptionsCollection  = "redemptions"
+	CustomerUsesCollection = "promotion_customer_uses"
+)
+
+type PromotionsStore interface {
+	Create(ctx context.Context, promotion *Promotion) error
+	Get(ctx context.Context, id primitive.ObjectID) (*Promotion, error)
+	GetByCode(ctx context.Context, code string) (*Promotion, error)
+	GetBySeller(ctx context.Context, sellerId string) ([]*Promotion, error)
+	Deactivate(ctx context.Context, id primitive.ObjectID) error
+	Redeem(ctx context.Context, promotion *Promotion) error
+	Release(ctx context.Context, id primitive.ObjectID) error
+	RedeemByCustomer(ctx context.Context, promotion *Promotion, customerId string) error
+	ReleaseByCustomer(ctx context.Context, promotionId, customerId string) error
+	CountRedemptions(ctx context.Context, promotionId, customerId string) (int64, error)
+	CreateRedemption(ctx context.Context, redemption *Redemption) error
+	DeleteRedemption(ctx context.Context, orderId string) error
+	CreateIndexes(ctx context.Context) error
+}
+
+type promotionsStore struct {
+	conn         *mongo.Collection
+	redemptions  *mongo.Collection
+	customerUses *mongo.Collection
+}
+
+func NewPromotionsStore(dbConn *mongo.Database) PromotionsStore {
+	return &promotionsStore{
+		conn:         dbConn.Collection(PromotionsCollection),
+		redemptions:  dbConn.Collection(RedemptionsCollection),
+		customerUses: dbConn.Collection(CustomerUsesCollection),
+	}
+}
+
+func (s *promotionsStore) Create(ctx context.Context, promotion *Promotion) error {
+	result, err := s.conn.InsertOne(ctx, promotion)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("duplicated promo code: code=%v", promotion.Code)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("promotion created: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *promotionsStore) Get(ctx context.Context, id primitive.ObjectID) (*Promotion, error) {
+	var promotion Promotion
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(&promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (s *promotionsStore) GetByCode(ctx context.Context, code string) (*Promotion, error) {
+	var promotion Promotion
+
+	err := s.conn.FindOne(ctx, bson.M{"code": code}).Decode(&promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (s *promotionsStore) GetBySeller(ctx context.Context, sellerId string) ([]*Promotion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, bson.M{"seller_id": sellerId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promotions []*Promotion
+
+	err = cursor.All(ctx, &promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (s *promotionsStore) Deactivate(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{"active": false, "updated_at": time.Now()},
+	}
+
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("promotion deactivated: id=%v\n", id.Hex())
+	return nil
+}
+
+// Redeem counts a use of the promotion, the global usage limit is checked
+// in the same update so concurrent checkouts can't exceed it.
+func (s *promotionsStore) Redeem(ctx context.Context, promotion *Promotion) error {
+	filter := bson.M{"_id": promotion.Id, "active": true}
+	if promotion.UsageLimit > 0 {
+		filter["uses"] = bson.M{"$lt": promotion.UsageLimit}
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"uses": 1}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("promo code usage limit reached: code=%v", promotion.Code)
+	}
+
+	return nil
+}
+
+func (s *promotionsStore) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": id, "uses": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"uses": -1}})
+	return err
+}
+
+// RedeemByCustomer counts a use of the promotion by the customer, the per
+// user limit is checked in the same update. The counter is created on the
+// first use, once it reached the limit the upsert collides with it on the
+// unique index and the use is refused.
+func (s *promotionsStore) RedeemByCustomer(ctx context.Context, promotion *Promotion, customerId string) error {
+	if promotion.PerUserLimit <= 0 {
+		return nil
+	}
+
+	filter := bson.M{
+		"promotion_id": promotion.Id.Hex(),
+		"customer_id":  customerId,
+		"count":        bson.M{"$lt": promotion.PerUserLimit},
+	}
+
+	_, err := s.customerUses.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"count": 1}}, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("invalid promo code, already used: code=%v", promotion.Code)
+	}
+
+	return err
+}
+
+func (s *promotionsStore) ReleaseByCustomer(ctx context.Context, promotionId, customerId string) error {
+	filter := bson.M{"promotion_id": promotionId, "customer_id": customerId, "count": bson.M{"$gt": 0}}
+
+	_, err := s.customerUses.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"count": -1}})
+	return err
+}
+
+func (s *promotionsStore) CountRedemptions(ctx context.Context, promotionId, customerId string) (int64, error) {
+	return s.redemptions.CountDocuments(ctx, bson.M{"promotion_id": promotionId, "customer_id": customerId})
+}
+
+func (s *promotionsStore) CreateRedemption(ctx context.Context, redemption *Redemption) error {
+	_, err := s.redemptions.InsertOne(ctx, redemption)
+	if err != nil {
+		return err
+	}
+	log.Printf("promotion redeemed: promotionId=%v, orderId=%v\n", redemption.PromotionId, redemption.OrderId)
+	return nil
+}
+
+func (s *promotionsStore) DeleteRedemption(ctx context.Context, orderId string) error {
+	_, err := s.redemptions.DeleteOne(ctx, bson.M{"order_id": orderId})
+	return err
+}
+
+func (s *promotionsStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetName("promotions_code").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "seller_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("promotions_seller"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.redemptions.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "promotion_id", Value: 1}, {Key: "customer_id", Value: 1}},
+			Options: options.Index().SetName("redemptions_customer"),
+		},
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetName("redemptions_order").SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.customerUses.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "promotion_id", Value: 1}, {Key: "customer_id", Value: 1}},
+		Options: options.Index().SetName("customer_uses_customer").SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const ReviewsCollection = "reviews"
+
+// Rating subjects, each one is averaged from its own review rating.
+const (
+	RatingProduct   = "product"
+	RatingSeller    = "seller"
+	RatingDeliverer = "deliverer"
+)
+
+var ratingFields = map[string][2]string{
+	RatingProduct:   {"product_id", "product_rating"},
+	RatingSeller:    {"seller_id", "seller_rating"},
+	RatingDeliverer: {"deliverer_id", "deliverer_rating"},
+}
+
+type ReviewsStore interface {
+	Create(ctx context.Context, review *Review) error
+	Get(ctx context.Context, id primitive.ObjectID) (*Review, error)
+	GetVisible(ctx context.Context, subject, id string) ([]*Review, error)
+	GetByHidden(ctx context.Context, hidden bool) ([]*Review, error)
+	Moderate(ctx context.Context, review *Review) error
+	Rating(ctx context.Context, subject, id string) (float32, int32, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type reviewsStore struct {
+	conn *mongo.Collection
+}
+
+func NewReviewsStore(dbConn *mongo.Database) ReviewsStore {
+	return &reviewsStore{conn: dbConn.Collection(ReviewsCollection)}
+}
+
+func (s *reviewsStore) Create(ctx context.Context, review *Review) error {
+	result, err := s.conn.InsertOne(ctx, review)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("order already reviewed: orderId=%v", review.OrderId)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("review created: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *reviewsStore) Get(ctx context.Context, id primitive.ObjectID) (*Review, error) {
+	var review Review
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (s *reviewsStore) GetVisible(ctx context.Context, subject, id string) ([]*Review, error) {
+	fields, ok := ratingFields[subject]
+	if !ok {
+		return nil, fmt.Errorf("invalid rating subject: subject=%v", subject)
+	}
+
+	return s.find(ctx, bson.M{fields[0]: id, "hidden": false})
+}
+
+func (s *reviewsStore) GetByHidden(ctx context.Context, hidden bool) ([]*Review, error) {
+	return s.find(ctx, bson.M{"hidden": hidden})
+}
+
+func (s *reviewsStore) find(ctx context.Context, filter bson.M) ([]*Review, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reviews []*Review
+
+	err = cursor.All(ctx, &reviews)
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (s *reviewsStore) Moderate(ctx context.Context, review *Review) error {
+	update := bson.M{
+		"$set": bson.M{
+			"hidden":            review.Hidden,
+			"moderation_reason": review.ModerationReason,
+			"moderated_by":      review.ModeratedBy,
+			"moderated_at":      review.ModeratedAt,
+			"updated_at":        review.UpdatedAt,
+		},
+	}
+
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": review.Id}, update)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("review moderated: id=%v, hidden=%v\n", review.Id.Hex(), review.Hidden)
+	return nil
+}
+
+// Rating averages the visible reviews of the subject, reviews without a
+// rating for it are skipped.
+func (s *reviewsStore) Rating(ctx context.Context, subject, id string) (float32, int32, error) {
+	fields, ok := ratingFields[subject]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid rating subject: subject=%v", subject)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{fields[0]: id, "hidden": false, fields[1]: bson.M{"$gt": 0}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$" + fields[1]},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := s.conn.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Average float64 `bson:"average"`
+		Count   int32   `bson:"count"`
+	}
+
+	if !cursor.Next(ctx) {
+		return 0, 0, cursor.Err()
+	}
+
+	err = cursor.Decode(&result)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return float32(result.Average), result.Count, nil
+}
+
+func (s *reviewsStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetName("reviews_order").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "hidden", Value: 1}},
+			Options: options.Index().SetName("reviews_product"),
+		},
+		{
+			Keys:    bson.D{{Key: "seller_id", Value: 1}, {Key: "hidden", Value: 1}},
+			Options: options.Index().SetName("reviews_seller"),
+		},
+		{
+			Keys:    bson.D{{Key: "deliverer_id", Value: 1}, {Key: "hidden", Value: 1}},
+			Options: options.Index().SetName("reviews_deliverer"),
+		},
+	})
+	return err
+}
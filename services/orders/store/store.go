@@ -2,10 +2,14 @@ package store
 
 import (
 	"context"
+	"fmt"
+	"go-delivery/pb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"time"
 )
 
 const OrdersCollection = "orders"
@@ -17,6 +21,14 @@ type OrdersStore interface {
 	GetAll(ctx context.Context) ([]*Order, error)
 	GetBySeller(ctx context.Context, sellerId primitive.ObjectID) ([]*Order, error)
 	GetByStatus(ctx context.Context, status int32) ([]*Order, error)
+	GetDelivered(ctx context.Context, delivererId string, from, to time.Time) ([]*Order, error)
+	SetTip(ctx context.Context, id primitive.ObjectID, tip float32, at time.Time) error
+	ClearTip(ctx context.Context, id primitive.ObjectID) error
+	AddRefund(ctx context.Context, id primitive.ObjectID, amount float32, reference string) error
+	GetScheduledDue(ctx context.Context, at time.Time) ([]*Order, error)
+	Release(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Cancel(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Deliver(ctx context.Context, id primitive.ObjectID, at time.Time) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 }
 
@@ -40,18 +52,25 @@ func (s *store) Create(ctx context.Context, order *Order) error {
 func (s *store) Update(ctx context.Context, order *Order) error {
 	update := bson.M{
 		"$set": bson.M{
-			"delivery_id": order.DeliveryId,
-			"status":      order.Status,
-			"updated_at":  order.UpdatedAt,
+			"delivery_id":  order.DeliveryId,
+			"status":       order.Status,
+			"delivered_at": order.DeliveredAt,
+			"updated_at":   order.UpdatedAt,
 		},
 	}
 
-	filter := bson.M{"_id": bson.M{"$eq": order.Id}}
+	// a canceled order is refunded, it never moves to another status.
+	filter := bson.M{"_id": bson.M{"$eq": order.Id}, "status": bson.M{"$ne": int32(pb.OrderStatus_Canceled)}}
 
 	result, err := s.conn.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("order not found or canceled: orderId=%v", order.Id.Hex())
+	}
+
 	log.Printf("order updated: total=%v\n", result.ModifiedCount)
 	return nil
 }
@@ -119,6 +138,184 @@ func (s *store) GetByStatus(ctx context.Context, status int32) ([]*Order, error)
 	return orders, nil
 }
 
+// GetDelivered returns the orders delivered by the deliverer, zero bounds
+// leave the range open.
+func (s *store) GetDelivered(ctx context.Context, delivererId string, from, to time.Time) ([]*Order, error) {
+	filter := bson.M{"delivery_id": delivererId, "status": int32(pb.OrderStatus_Delivered)}
+
+	deliveredAt := bson.M{}
+	if !from.IsZero() {
+		deliveredAt["$gte"] = from
+	}
+	if !to.IsZero() {
+		deliveredAt["$lt"] = to
+	}
+	if len(deliveredAt) > 0 {
+		filter["delivered_at"] = deliveredAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "delivered_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*Order
+
+	err = cursor.All(ctx, &orders)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("list delivered orders: delivererId=%v, total=%v\n", delivererId, len(orders))
+	return orders, nil
+}
+
+// SetTip only tips an order without a tip, so a tip can't be given twice.
+func (s *store) SetTip(ctx context.Context, id primitive.ObjectID, tip float32, at time.Time) error {
+	filter := bson.M{"_id": id, "tip": bson.M{"$in": bson.A{0, nil}}}
+	update := bson.M{"$set": bson.M{"tip": tip, "tipped_at": at}}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("order already tipped: orderId=%v", id.Hex())
+	}
+
+	log.Printf("order tipped: id=%v, tip=%v\n", id.Hex(), tip)
+	return nil
+}
+
+func (s *store) ClearTip(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"tip": 0, "tipped_at": nil}})
+	return err
+}
+
+// AddRefund adds a refund to the order once per reference, adding it again
+// leaves the order untouched.
+func (s *store) AddRefund(ctx context.Context, id primitive.ObjectID, amount float32, reference string) error {
+	filter := bson.M{"_id": id, "refund_references": bson.M{"$ne": reference}}
+	update := bson.M{
+		"$inc":  bson.M{"refunded": amount},
+		"$push": bson.M{"refund_references": reference},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return nil
+	}
+	log.Printf("order refunded: id=%v, amount=%v\n", id.Hex(), amount)
+	return nil
+}
+
+// GetScheduledDue returns the scheduled orders to deliver from the given
+// time or before, earliest first.
+func (s *store) GetScheduledDue(ctx context.Context, at time.Time) ([]*Order, error) {
+	filter := bson.M{"status": int32(pb.OrderStatus_Scheduled), "deliver_from": bson.M{"$lte": at}}
+	opts := options.Find().SetSort(bson.D{{Key: "deliver_from", Value: 1}})
+
+	cursor, err := s.conn.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*Order
+
+	err = cursor.All(ctx, &orders)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("list scheduled orders: total=%v\n", len(orders))
+	return orders, nil
+}
+
+// Release places a scheduled order, so it is only released once.
+func (s *store) Release(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "status": int32(pb.OrderStatus_Scheduled)}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      int32(pb.OrderStatus_Placed),
+			"released_at": at,
+			"updated_at":  at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("order not scheduled: orderId=%v", id.Hex())
+	}
+
+	log.Printf("scheduled order released: id=%v\n", id.Hex())
+	return nil
+}
+
+// Cancel marks an order as canceled, so it is only canceled once and never
+// after being delivered.
+func (s *store) Cancel(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{
+		"_id":    id,
+		"status": bson.M{"$nin": bson.A{int32(pb.OrderStatus_Delivered), int32(pb.OrderStatus_Canceled)}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     int32(pb.OrderStatus_Canceled),
+			"updated_at": at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("order can't be canceled: orderId=%v", id.Hex())
+	}
+
+	log.Printf("order canceled: id=%v\n", id.Hex())
+	return nil
+}
+
+// Deliver marks a delivering order as delivered, so it is only delivered
+// and paid out once.
+func (s *store) Deliver(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "status": int32(pb.OrderStatus_Delivering)}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       int32(pb.OrderStatus_Delivered),
+			"delivered_at": at,
+			"updated_at":   at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("order not delivering: orderId=%v", id.Hex())
+	}
+
+	log.Printf("order delivered: id=%v\n", id.Hex())
+	return nil
+}
+
 func (s *store) Delete(ctx context.Context, id primitive.ObjectID) error {
 	result, err := s.conn.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
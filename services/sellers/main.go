@@ -7,6 +7,9 @@ import (
 	"github.com/joho/godotenv"
 	"go-delivery/db"
 	"go-delivery/pb"
+	"go-delivery/security/mtls"
+	"go-delivery/security/permissions"
+	"go-delivery/security/tokens"
 	"go-delivery/services/sellers/service"
 	"go-delivery/services/sellers/store"
 	"go-delivery/util"
@@ -14,9 +17,14 @@ import (
 	"log"
 	"net"
 	"time"
+	_ "time/tzdata"
 )
 
-var port int
+var (
+	port           int
+	tlsConfig      mtls.Config
+	schedulerDelay time.Duration
+)
 
 func init() {
 	err := godotenv.Load(util.GetEnvFile())
@@ -25,11 +33,19 @@ func init() {
 	}
 
 	flag.IntVar(&port, "port", 7502, "grpc port")
+	flag.DurationVar(&schedulerDelay, "price_scheduler_interval", time.Minute, "interval between scheduled prices checks")
+
+	tlsConfig.RegisterFlags()
 
 	flag.Parse()
 }
 
 func main() {
+	err := tokens.CheckSecrets()
+	if err != nil {
+		log.Panicln(err)
+	}
+
 	cfg := db.NewConfig()
 
 	log.Println("loading db configs: ", cfg.URI())
@@ -40,22 +56,67 @@ func main() {
 	dbConn := db.New(ctx, cfg)
 	defer dbConn.Close(ctx)
 
-	err := dbConn.Ping(ctx)
+	err = dbConn.Ping(ctx)
 	if err != nil {
 		log.Panicln(err)
 	}
 	log.Println("database connected successfully")
 
 	productsStore := store.NewProductsStore(dbConn.DB())
-	productsService := service.NewService(productsStore)
+
+	err = productsStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	importJobsStore := store.NewImportJobsStore(dbConn.DB())
+
+	err = importJobsStore.FailInterrupted(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	priceChangesStore := store.NewPriceChangesStore(dbConn.DB())
+
+	err = priceChangesStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	go service.NewPriceScheduler(productsStore, priceChangesStore).Run(context.Background(), schedulerDelay)
+
+	inventoryStore := store.NewInventoryStore(dbConn.DB())
+
+	err = inventoryStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	productsService := service.NewService(productsStore, importJobsStore, priceChangesStore, inventoryStore)
+	storesStore := store.NewStoresStore(dbConn.DB())
+	storesService := service.NewStoresService(storesStore)
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		log.Panicln(err)
 	}
 
-	grpcServer := grpc.NewServer()
+	permissionsConfig := permissions.NewConfig()
+	rules := service.NewRules(productsStore)
+
+	serverOptions, err := tlsConfig.ServerOptions()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	serverOptions = append(serverOptions,
+		grpc.UnaryInterceptor(permissions.UnaryServerInterceptor(permissionsConfig, rules)),
+		grpc.StreamInterceptor(permissions.StreamServerInterceptor(permissionsConfig, rules)),
+	)
+
+	grpcServer := grpc.NewServer(serverOptions...)
 	pb.RegisterProductsServiceServer(grpcServer, productsService)
+	pb.RegisterStoresServiceServer(grpcServer, storesService)
 
 	defer grpcServer.Stop()
 
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/sellers/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxProductImages = 10
+
+func (s *service) AddProductImage(ctx context.Context, req *pb.AddProductImageRequest) (*pb.Product, error) {
+	id, err := primitive.ObjectIDFromHex(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Image == nil || req.Image.Id == "" || req.Image.Key == "" {
+		return nil, errors.New("invalid product image")
+	}
+
+	err = s.productsStore.AddImage(ctx, id, store.ImageFromProto(req.Image), maxProductImages)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return product.ToProto(), nil
+}
+
+// RemoveProductImage returns the removed image so the caller can delete its
+// files from the blob store.
+func (s *service) RemoveProductImage(ctx context.Context, req *pb.RemoveProductImageRequest) (*pb.ProductImage, error) {
+	id, err := primitive.ObjectIDFromHex(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range product.Images {
+		if product.Images[index].Id != req.ImageId {
+			continue
+		}
+
+		err = s.productsStore.RemoveImage(ctx, id, req.ImageId)
+		if err != nil {
+			return nil, err
+		}
+
+		return product.Images[index].ToProto(), nil
+	}
+
+	return nil, fmt.Errorf("product image not found: productId=%v, imageId=%v", req.ProductId, req.ImageId)
+}
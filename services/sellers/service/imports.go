@@ -0,0 +1,338 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/sellers/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxImportSize      = 2 << 20
+	maxImportRows      = 5000
+	maxImportErrors    = 100
+	importProgressStep = 50
+)
+
+// importRow is a catalog row, csv files use the json names as header.
+type importRow struct {
+	SKU          string   `json:"sku"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Category     string   `json:"category"`
+	Tags         []string `json:"tags"`
+	Price        float32  `json:"price"`
+	DeliveryCost float32  `json:"delivery_cost"`
+	Quantity     int32    `json:"quantity"`
+}
+
+// StartImport validates the file shape and creates the job, the rows are
+// upserted by sku in the background and the progress is kept on the job.
+func (s *service) StartImport(ctx context.Context, req *pb.StartImportRequest) (*pb.ImportJob, error) {
+	sellerId, err := primitive.ObjectIDFromHex(req.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Data) == 0 || len(req.Data) > maxImportSize {
+		return nil, fmt.Errorf("invalid import, file must have between 1 and %d bytes", maxImportSize)
+	}
+
+	format := strings.ToLower(req.Format)
+
+	var rows []importRow
+
+	switch format {
+	case store.ImportFormatCSV:
+		rows, err = parseCSVRows(req.Data)
+	case store.ImportFormatJSON:
+		rows, err = parseJSONRows(req.Data)
+	default:
+		err = fmt.Errorf("invalid import format: format=%v", req.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 || len(rows) > maxImportRows {
+		return nil, fmt.Errorf("invalid import, file must have between 1 and %d rows", maxImportRows)
+	}
+
+	job := &store.ImportJob{
+		Id:        primitive.NewObjectID(),
+		SellerId:  sellerId.Hex(),
+		Format:    format,
+		Status:    int32(pb.ImportStatus_ImportPending),
+		Total:     int32(len(rows)),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err = s.importJobsStore.Create(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.runImport(*job, rows)
+
+	return job.ToProto(), nil
+}
+
+func (s *service) GetImportJob(ctx context.Context, req *pb.GetImportJobRequest) (*pb.ImportJob, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.importJobsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.SellerId != req.SellerId {
+		return nil, fmt.Errorf("import job not found: id=%v", req.Id)
+	}
+
+	return job.ToProto(), nil
+}
+
+func (s *service) runImport(job store.ImportJob, rows []importRow) {
+	ctx := context.Background()
+
+	job.Status = int32(pb.ImportStatus_ImportRunning)
+	s.saveImportJob(ctx, &job)
+
+	for index, row := range rows {
+		created, err := s.importRow(ctx, job.SellerId, row)
+
+		switch {
+		case err != nil:
+			job.Failed++
+			if len(job.Errors) < maxImportErrors {
+				job.Errors = append(job.Errors, store.ImportRowError{
+					Row:     int32(index + 1),
+					SKU:     row.SKU,
+					Message: err.Error(),
+				})
+			}
+		case created:
+			job.Created++
+		default:
+			job.Updated++
+		}
+
+		job.Processed++
+
+		if job.Processed%importProgressStep == 0 {
+			s.saveImportJob(ctx, &job)
+		}
+	}
+
+	job.Status = int32(pb.ImportStatus_ImportCompleted)
+	if job.Failed > maxImportErrors {
+		job.Message = fmt.Sprintf("only the first %d row errors are reported", maxImportErrors)
+	}
+
+	s.saveImportJob(ctx, &job)
+
+	log.Printf("import job completed: id=%v, created=%v, updated=%v, failed=%v\n",
+		job.Id.Hex(), job.Created, job.Updated, job.Failed)
+}
+
+func (s *service) saveImportJob(ctx context.Context, job *store.ImportJob) {
+	job.UpdatedAt = time.Now()
+
+	err := s.importJobsStore.Update(ctx, job)
+	if err != nil {
+		log.Printf("failed to update import job: id=%v, err=%v\n", job.Id.Hex(), err)
+	}
+}
+
+// importRow upserts the row by sku, the variants, option groups and images
+// of an existing product are kept.
+func (s *service) importRow(ctx context.Context, sellerId string, row importRow) (bool, error) {
+	err := row.validate()
+	if err != nil {
+		return false, err
+	}
+
+	product, err := s.productsStore.GetBySKU(ctx, sellerId, row.SKU)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+
+	var previous *store.Product
+
+	if product == nil {
+		product = &store.Product{
+			Id:        primitive.NewObjectID(),
+			SellerId:  sellerId,
+			SKU:       row.SKU,
+			CreatedAt: time.Now(),
+		}
+	} else {
+		snapshot := *product
+		previous = &snapshot
+	}
+
+	product.Name = row.Name
+	product.Description = row.Description
+	product.Category = store.NormalizeCategory(row.Category)
+	product.Tags = store.NormalizeTags(row.Tags)
+	product.Price = row.Price
+	product.DeliveryCost = row.DeliveryCost
+	product.Quantity = row.Quantity
+	product.UpdatedAt = time.Now()
+
+	err = s.checkDuplicated(ctx, product)
+	if err != nil {
+		return false, err
+	}
+
+	err = prepareConfiguration(product)
+	if err != nil {
+		return false, err
+	}
+
+	return previous == nil, s.saveProduct(ctx, product, previous)
+}
+
+func (r *importRow) validate() error {
+	r.SKU = strings.TrimSpace(r.SKU)
+	r.Name = strings.TrimSpace(r.Name)
+
+	if r.SKU == "" || len(r.SKU) > 64 {
+		return errors.New("sku is required and must have at most 64 characters")
+	}
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.Price <= 0 {
+		return errors.New("price must be greater than zero")
+	}
+	if r.DeliveryCost < 0 {
+		return errors.New("delivery_cost can't be negative")
+	}
+	if r.Quantity < 0 {
+		return errors.New("quantity can't be negative")
+	}
+
+	return nil
+}
+
+func parseJSONRows(data []byte) ([]importRow, error) {
+	var rows []importRow
+
+	err := json.Unmarshal(data, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json import: %v", err)
+	}
+
+	return rows, nil
+}
+
+// parseCSVRows reads the rows by header name, a malformed value is kept as
+// a row error instead of failing the whole file.
+func parseCSVRows(data []byte) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv import, header is required: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for index, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+
+	for _, name := range []string{"sku", "name", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("invalid csv import, missing column: column=%v", name)
+		}
+	}
+
+	var rows []importRow
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv import: %v", err)
+		}
+
+		value := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		row := importRow{
+			SKU:         value("sku"),
+			Name:        value("name"),
+			Description: value("description"),
+			Category:    value("category"),
+		}
+
+		if tags := value("tags"); tags != "" {
+			row.Tags = strings.Split(tags, ";")
+		}
+
+		row.Price = parseFloat(value("price"), -1)
+		row.DeliveryCost = parseFloat(value("delivery_cost"), 0)
+		row.Quantity = parseInt(value("quantity"), 0)
+
+		rows = append(rows, row)
+
+		if len(rows) > maxImportRows {
+			break
+		}
+	}
+
+	return rows, nil
+}
+
+// parseFloat returns fallback for an empty value and -1 for a malformed
+// one so the row fails validation.
+func parseFloat(value string, fallback float32) float32 {
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return -1
+	}
+
+	return float32(number)
+}
+
+func parseInt(value string, fallback int32) int32 {
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return -1
+	}
+
+	return int32(number)
+}
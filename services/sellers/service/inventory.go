@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/services/sellers/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"strings"
+	"time"
+)
+
+const maxInventoryNote = 200
+
+// AdjustInventory changes the stock of the product atomically, order and
+// cancel adjustments are reserved to the orders service.
+func (s *service) AdjustInventory(ctx context.Context, req *pb.AdjustInventoryRequest) (*pb.Product, error) {
+	id, err := primitive.ObjectIDFromHex(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateAdjustment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(product.Variants) > 0 && req.VariantId == "" {
+		return nil, fmt.Errorf("invalid adjustment, variant is required: productId=%v", req.ProductId)
+	}
+	if len(product.Variants) == 0 && req.VariantId != "" {
+		return nil, fmt.Errorf("invalid adjustment, product has no variants: productId=%v", req.ProductId)
+	}
+
+	product, err = s.productsStore.AdjustQuantity(ctx, id, req.VariantId, req.Delta)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recordAdjustment(ctx, product, product.Quantity-req.Delta, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return product.ToProto(), nil
+}
+
+func (s *service) ListInventoryLog(req *pb.ListInventoryLogRequest, stream pb.ProductsService_ListInventoryLogServer) error {
+	adjustments, err := s.inventoryStore.GetAdjustments(stream.Context(), req.ProductId)
+	if err != nil {
+		return err
+	}
+
+	for index := range adjustments {
+		err = stream.Send(adjustments[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) ListStockAlerts(req *pb.ListStockAlertsRequest, stream pb.ProductsService_ListStockAlertsServer) error {
+	alerts, err := s.inventoryStore.GetAlerts(stream.Context(), req.SellerId)
+	if err != nil {
+		return err
+	}
+
+	for index := range alerts {
+		err = stream.Send(alerts[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordAdjustment logs the stock change and alerts the seller when the
+// product stock crossed its low stock threshold or ran out.
+func (s *service) recordAdjustment(ctx context.Context, product *store.Product, previousQuantity int32, req *pb.AdjustInventoryRequest) error {
+	err := s.inventoryStore.LogAdjustment(ctx, &store.InventoryAdjustment{
+		Id:        primitive.NewObjectID(),
+		ProductId: product.Id.Hex(),
+		SellerId:  product.SellerId,
+		VariantId: req.VariantId,
+		Reason:    int32(req.Reason),
+		Delta:     req.Delta,
+		Quantity:  product.Quantity,
+		OrderId:   req.OrderId,
+		Note:      strings.TrimSpace(req.Note),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	alert := &store.StockAlert{
+		Id:          primitive.NewObjectID(),
+		SellerId:    product.SellerId,
+		ProductId:   product.Id.Hex(),
+		ProductName: product.Name,
+		Quantity:    product.Quantity,
+		Threshold:   product.LowStockThreshold,
+		CreatedAt:   time.Now(),
+	}
+
+	switch {
+	case product.Quantity <= 0 && previousQuantity > 0:
+		alert.Kind = int32(pb.StockAlertKind_OutOfStock)
+	case product.Quantity <= product.LowStockThreshold && previousQuantity > product.LowStockThreshold:
+		alert.Kind = int32(pb.StockAlertKind_LowStock)
+	default:
+		return nil
+	}
+
+	// alerts are notifications only, the adjustment already happened.
+	err = s.inventoryStore.CreateAlert(ctx, alert)
+	if err != nil {
+		log.Printf("failed to create stock alert: productId=%v, err=%v\n", alert.ProductId, err)
+	}
+
+	return nil
+}
+
+func validateAdjustment(ctx context.Context, req *pb.AdjustInventoryRequest) error {
+	if len(req.Note) > maxInventoryNote {
+		return fmt.Errorf("invalid adjustment, note must have at most %d characters", maxInventoryNote)
+	}
+
+	switch req.Reason {
+	case pb.InventoryReason_InventoryRestock:
+		if req.Delta <= 0 {
+			return errors.New("invalid adjustment, restock must increase the stock")
+		}
+	case pb.InventoryReason_InventorySpoilage:
+		if req.Delta >= 0 {
+			return errors.New("invalid adjustment, spoilage must decrease the stock")
+		}
+	case pb.InventoryReason_InventoryCorrection:
+		if req.Delta == 0 {
+			return errors.New("invalid adjustment, delta is required")
+		}
+	case pb.InventoryReason_InventoryOrder, pb.InventoryReason_InventoryCancel:
+		caller, ok := credentials.FromContext(ctx)
+		if !ok || caller.User != nil || caller.Service != credentials.ServiceOrders {
+			return fmt.Errorf("invalid adjustment, reason reserved to orders: reason=%v", req.Reason)
+		}
+		if req.OrderId == "" {
+			return errors.New("invalid adjustment, order id is required")
+		}
+		if (req.Reason == pb.InventoryReason_InventoryOrder) != (req.Delta < 0) || req.Delta == 0 {
+			return fmt.Errorf("invalid adjustment, delta doesn't match the reason: reason=%v", req.Reason)
+		}
+	default:
+		return fmt.Errorf("invalid adjustment reason: reason=%v", req.Reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go-delivery/pb"
+	"go-delivery/services/sellers/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"time"
+)
+
+func (s *service) SchedulePrice(ctx context.Context, req *pb.SchedulePriceRequest) (*pb.PriceChange, error) {
+	id, err := primitive.ObjectIDFromHex(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.productsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Price <= 0 || req.DeliveryCost < 0 {
+		return nil, fmt.Errorf("invalid scheduled price: price=%v, delivery_cost=%v", req.Price, req.DeliveryCost)
+	}
+
+	effectiveFrom := time.Unix(req.EffectiveFrom, 0)
+	if !effectiveFrom.After(time.Now()) {
+		return nil, fmt.Errorf("invalid scheduled price, effective_from must be in the future: effective_from=%v", req.EffectiveFrom)
+	}
+
+	change := &store.PriceChange{
+		Id:            primitive.NewObjectID(),
+		ProductId:     id.Hex(),
+		Price:         req.Price,
+		DeliveryCost:  req.DeliveryCost,
+		EffectiveFrom: effectiveFrom,
+		CreatedAt:     time.Now(),
+	}
+
+	err = s.priceChangesStore.Create(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+
+	return change.ToProto(), nil
+}
+
+func (s *service) CancelScheduledPrice(ctx context.Context, req *pb.CancelScheduledPriceRequest) (*empty.Empty, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.priceChangesStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.ProductId != req.ProductId {
+		return nil, fmt.Errorf("scheduled price not found: id=%v", req.Id)
+	}
+
+	err = s.priceChangesStore.DeletePending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *service) ListPriceHistory(req *pb.ListPriceHistoryRequest, stream pb.ProductsService_ListPriceHistoryServer) error {
+	changes, err := s.priceChangesStore.GetByProduct(stream.Context(), req.ProductId)
+	if err != nil {
+		return err
+	}
+
+	for index := range changes {
+		err = stream.Send(changes[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PriceScheduler applies the scheduled prices once they are effective, each
+// one becomes a new version of its product.
+type PriceScheduler struct {
+	productsStore     store.ProductsStore
+	priceChangesStore store.PriceChangesStore
+}
+
+func NewPriceScheduler(productsStore store.ProductsStore, priceChangesStore store.PriceChangesStore) *PriceScheduler {
+	return &PriceScheduler{productsStore: productsStore, priceChangesStore: priceChangesStore}
+}
+
+func (p *PriceScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.ApplyDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *PriceScheduler) ApplyDue(ctx context.Context) {
+	changes, err := p.priceChangesStore.GetDue(ctx, time.Now())
+	if err != nil {
+		log.Printf("failed to load scheduled prices: err=%v\n", err)
+		return
+	}
+
+	for _, change := range changes {
+		err = p.apply(ctx, change)
+		if err != nil {
+			log.Printf("failed to apply scheduled price: id=%v, err=%v\n", change.Id.Hex(), err)
+		}
+	}
+}
+
+func (p *PriceScheduler) apply(ctx context.Context, change *store.PriceChange) error {
+	productId, err := primitive.ObjectIDFromHex(change.ProductId)
+	if err != nil {
+		return err
+	}
+
+	product, err := p.productsStore.Get(ctx, productId)
+	if err == mongo.ErrNoDocuments {
+		return p.priceChangesStore.DeletePending(ctx, change.Id)
+	}
+	if err != nil {
+		return err
+	}
+
+	readAt := product.UpdatedAt
+
+	product.Price = change.Price
+	product.DeliveryCost = change.DeliveryCost
+	product.Version++
+	product.UpdatedAt = time.Now()
+
+	err = p.productsStore.Update(ctx, product, readAt)
+	if err != nil {
+		return err
+	}
+
+	return p.priceChangesStore.MarkApplied(ctx, change.Id, product.Version)
+}
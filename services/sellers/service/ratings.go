@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go-delivery/pb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+func (s *service) UpdateProductRating(ctx context.Context, req *pb.UpdateProductRatingRequest) (*empty.Empty, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateRating(req.Average, req.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.productsStore.SetRating(ctx, id, req.Average, req.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *storesService) UpdateStoreRating(ctx context.Context, req *pb.UpdateStoreRatingRequest) (*pb.Store, error) {
+	_, err := primitive.ObjectIDFromHex(req.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateRating(req.Average, req.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.storesStore.SetRating(ctx, req.SellerId, req.Average, req.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	sellerStore, err := s.getStore(ctx, req.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return sellerStore.ToProto(time.Now()), nil
+}
+
+func validateRating(average float32, count int32) error {
+	if count < 0 || average < 0 || average > 5 || (count == 0 && average != 0) {
+		return fmt.Errorf("invalid rating: average=%v, count=%v", average, count)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/security/permissions"
+	"go-delivery/services/sellers/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewRules(productsStore store.ProductsStore) permissions.Rules {
+	productOwner := func(ctx context.Context, productId string) (string, error) {
+		id, err := primitive.ObjectIDFromHex(productId)
+		if err != nil {
+			return "", err
+		}
+
+		product, err := productsStore.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		return product.SellerId, nil
+	}
+
+	return permissions.Rules{
+		"/pb.ProductsService/GetProduct": {
+			Public: true,
+		},
+		"/pb.ProductsService/ListSellerProducts": {
+			Public: true,
+		},
+		"/pb.ProductsService/ListProducts": {
+			Public: true,
+		},
+		"/pb.ProductsService/SearchProducts": {
+			Public: true,
+		},
+		"/pb.StoresService/GetStore": {
+			Public: true,
+		},
+		"/pb.ProductsService/CreateProduct": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Product).SellerId, nil
+			},
+		},
+		"/pb.ProductsService/UpdateProduct": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.UpdateProductRequest).Id)
+			},
+		},
+		"/pb.ProductsService/DeleteProduct": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.DeleteProductRequest).Id)
+			},
+		},
+		"/pb.ProductsService/RestoreProduct": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.RestoreProductRequest).Id)
+			},
+		},
+		"/pb.ProductsService/ListArchivedProducts": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListSellerProductsRequest).SellerId, nil
+			},
+		},
+		"/pb.ProductsService/SchedulePrice": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.SchedulePriceRequest).ProductId)
+			},
+		},
+		"/pb.ProductsService/CancelScheduledPrice": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.CancelScheduledPriceRequest).ProductId)
+			},
+		},
+		"/pb.ProductsService/ListPriceHistory": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.ListPriceHistoryRequest).ProductId)
+			},
+		},
+		"/pb.ProductsService/AdjustInventory": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.AdjustInventoryRequest).ProductId)
+			},
+			Services: []string{credentials.ServiceOrders},
+		},
+		"/pb.ProductsService/ListInventoryLog": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.ListInventoryLogRequest).ProductId)
+			},
+		},
+		"/pb.ProductsService/ListStockAlerts": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListStockAlertsRequest).SellerId, nil
+			},
+		},
+		"/pb.ProductsService/AddProductImage": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.AddProductImageRequest).ProductId)
+			},
+		},
+		"/pb.ProductsService/RemoveProductImage": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return productOwner(ctx, req.(*pb.RemoveProductImageRequest).ProductId)
+			},
+		},
+		"/pb.ProductsService/StartImport": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.StartImportRequest).SellerId, nil
+			},
+		},
+		"/pb.ProductsService/GetImportJob": {
+			Permissions: []permissions.Permission{permissions.ProductsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.GetImportJobRequest).SellerId, nil
+			},
+		},
+		"/pb.ProductsService/UpdateProductRating": {
+			Services: []string{credentials.ServiceOrders},
+		},
+		"/pb.StoresService/UpdateStoreRating": {
+			Services: []string{credentials.ServiceOrders},
+		},
+		"/pb.StoresService/UpdateStore": {
+			Permissions: []permissions.Permission{permissions.StoresWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Store).SellerId, nil
+			},
+		},
+		"/pb.StoresService/PauseStore": {
+			Permissions: []permissions.Permission{permissions.StoresWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.PauseStoreRequest).SellerId, nil
+			},
+		},
+	}
+}
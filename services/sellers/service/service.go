@@ -2,43 +2,61 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/golang/protobuf/ptypes/empty"
 	"go-delivery/pb"
 	"go-delivery/services/sellers/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
 	"time"
 )
 
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
 type service struct {
-	productsStore store.ProductsStore
+	productsStore     store.ProductsStore
+	importJobsStore   store.ImportJobsStore
+	priceChangesStore store.PriceChangesStore
+	inventoryStore    store.InventoryStore
 	pb.UnimplementedProductsServiceServer
 }
 
-func NewService(productsStore store.ProductsStore) pb.ProductsServiceServer {
-	return &service{productsStore: productsStore}
+func NewService(
+	productsStore store.ProductsStore,
+	importJobsStore store.ImportJobsStore,
+	priceChangesStore store.PriceChangesStore,
+	inventoryStore store.InventoryStore,
+) pb.ProductsServiceServer {
+	return &service{
+		productsStore:     productsStore,
+		importJobsStore:   importJobsStore,
+		priceChangesStore: priceChangesStore,
+		inventoryStore:    inventoryStore,
+	}
 }
 
 func (s *service) CreateProduct(ctx context.Context, req *pb.Product) (*pb.Product, error) {
-	sellerId, err := primitive.ObjectIDFromHex(req.SellerId)
+	product, err := store.FromProto(req)
 	if err != nil {
 		return nil, err
 	}
 
-	items, err := s.productsStore.GetBySeller(ctx, sellerId)
+	err = s.checkDuplicated(ctx, product)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, item := range items {
-		if item.Name == req.Name {
-			return nil, fmt.Errorf("duplicated product: name=%s, seller_id=%s", req.Name, req.SellerId)
-		}
+	err = prepareConfiguration(product)
+	if err != nil {
+		return nil, err
 	}
 
-	product, err := store.FromProto(req)
-
-	err = s.productsStore.Create(ctx, product)
+	err = s.saveProduct(ctx, product, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -57,12 +75,46 @@ func (s *service) UpdateProduct(ctx context.Context, req *pb.UpdateProductReques
 		return nil, err
 	}
 
+	previous := *product
+
+	// the stock is kept, the variants are matched by id and new ones start
+	// out of stock.
+	stock := make(map[string]int32)
+	for _, variant := range product.Variants {
+		stock[variant.Id] = variant.Quantity
+	}
+
+	product.SKU = strings.TrimSpace(req.Sku)
 	product.Name = req.Name
+	product.Description = req.Description
+	product.Category = store.NormalizeCategory(req.Category)
+	product.Tags = store.NormalizeTags(req.Tags)
 	product.Price = req.Price
-	product.Quantity = req.Quantity
+	product.DeliveryCost = req.DeliveryCost
+	product.LowStockThreshold = req.LowStockThreshold
+	product.Variants = store.VariantsFromProto(req.Variants)
+	product.OptionGroups = store.OptionGroupsFromProto(req.OptionGroups)
 	product.UpdatedAt = time.Now()
 
-	err = s.productsStore.Update(ctx, product)
+	for index := range product.Variants {
+		product.Variants[index].Quantity = stock[product.Variants[index].Id]
+	}
+
+	err = s.checkDuplicated(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+
+	err = prepareConfiguration(product)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Quantity != previous.Quantity {
+		return nil, errors.New("invalid product update, adjust the stock of the removed variants to 0 first")
+	}
+
+	err = s.saveProduct(ctx, product, &previous)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +142,28 @@ func (s *service) ListSellerProducts(req *pb.ListSellerProductsRequest, stream p
 		return err
 	}
 
-	items, err := s.productsStore.GetBySeller(context.Background(), sellerId)
+	items, err := s.productsStore.GetBySeller(stream.Context(), sellerId, req.IncludeOutOfStock)
+	if err != nil {
+		return err
+	}
+
+	for index := range items {
+		err = stream.Send(items[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) ListArchivedProducts(req *pb.ListSellerProductsRequest, stream pb.ProductsService_ListArchivedProductsServer) error {
+	sellerId, err := primitive.ObjectIDFromHex(req.SellerId)
+	if err != nil {
+		return err
+	}
+
+	items, err := s.productsStore.GetArchivedBySeller(stream.Context(), sellerId)
 	if err != nil {
 		return err
 	}
@@ -121,16 +194,177 @@ func (s *service) ListProducts(_ *pb.ListProductsRequest, stream pb.ProductsServ
 	return nil
 }
 
+func (s *service) SearchProducts(req *pb.SearchProductsRequest, stream pb.ProductsService_SearchProductsServer) error {
+	if req.MinPrice < 0 || req.MaxPrice < 0 || (req.MaxPrice > 0 && req.MinPrice > req.MaxPrice) {
+		return fmt.Errorf("invalid price range: min=%v, max=%v", req.MinPrice, req.MaxPrice)
+	}
+
+	if req.Sort != "" && req.Sort != store.SortRecent && req.Sort != store.SortRating {
+		return fmt.Errorf("invalid search sort: sort=%v", req.Sort)
+	}
+
+	limit := int64(req.Limit)
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	offset := int64(req.Offset)
+	if offset < 0 {
+		offset = 0
+	}
+
+	items, err := s.productsStore.Search(stream.Context(), store.ProductFilter{
+		Query:    strings.TrimSpace(req.Query),
+		Category: store.NormalizeCategory(req.Category),
+		Tag:      strings.ToLower(strings.TrimSpace(req.Tag)),
+		SellerId: req.SellerId,
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+		InStock:  req.InStock,
+		Sort:     req.Sort,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	for index := range items {
+		err = stream.Send(items[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *service) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*empty.Empty, error) {
 	id, err := primitive.ObjectIDFromHex(req.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.productsStore.Delete(ctx, id)
+	err = s.productsStore.Archive(ctx, id, time.Now())
 	if err != nil {
 		return nil, err
 	}
 
 	return &empty.Empty{}, nil
 }
+
+func (s *service) RestoreProduct(ctx context.Context, req *pb.RestoreProductRequest) (*pb.Product, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.productsStore.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return product.ToProto(), nil
+}
+
+// saveProduct stores the product as a new version, previous is nil for a
+// new product. The price history and the inventory log are recorded from
+// the differences with the previous version.
+func (s *service) saveProduct(ctx context.Context, product *store.Product, previous *store.Product) error {
+	product.Version++
+
+	var delta int32
+	var err error
+	if previous == nil {
+		product.OutOfStock = product.Quantity <= 0
+		err = s.productsStore.Create(ctx, product)
+	} else {
+		// the stock is not saved with the product, a changed quantity is
+		// applied as an inventory correction.
+		delta = product.Quantity - previous.Quantity
+		product.Quantity = previous.Quantity
+		err = s.productsStore.Update(ctx, product, previous.UpdatedAt)
+	}
+	if err != nil {
+		return err
+	}
+
+	if previous == nil || previous.Price != product.Price || previous.DeliveryCost != product.DeliveryCost {
+		err = s.priceChangesStore.Create(ctx, &store.PriceChange{
+			Id:            primitive.NewObjectID(),
+			ProductId:     product.Id.Hex(),
+			Version:       product.Version,
+			Price:         product.Price,
+			DeliveryCost:  product.DeliveryCost,
+			EffectiveFrom: product.UpdatedAt,
+			Applied:       true,
+			CreatedAt:     time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if previous == nil {
+		if product.Quantity == 0 {
+			return nil
+		}
+		return s.recordAdjustment(ctx, product, 0, &pb.AdjustInventoryRequest{
+			Reason: pb.InventoryReason_InventoryRestock,
+			Delta:  product.Quantity,
+			Note:   "initial stock",
+		})
+	}
+
+	if delta == 0 {
+		return nil
+	}
+
+	adjusted, err := s.AdjustInventory(ctx, &pb.AdjustInventoryRequest{
+		ProductId: product.Id.Hex(),
+		Reason:    pb.InventoryReason_InventoryCorrection,
+		Delta:     delta,
+		Note:      "product import",
+	})
+	if err != nil {
+		return err
+	}
+
+	product.Quantity = adjusted.Quantity
+	product.OutOfStock = adjusted.OutOfStock
+
+	return nil
+}
+
+// checkDuplicated rejects a product whose name or sku is already used by
+// another product of the same seller.
+func (s *service) checkDuplicated(ctx context.Context, product *store.Product) error {
+	found, err := s.productsStore.GetByName(ctx, product.SellerId, product.Name)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	if found != nil && found.Id != product.Id {
+		return fmt.Errorf("duplicated product: name=%s, seller_id=%s", product.Name, product.SellerId)
+	}
+
+	if product.SKU == "" {
+		return nil
+	}
+
+	found, err = s.productsStore.GetBySKU(ctx, product.SellerId, product.SKU)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	if found != nil && found.Id != product.Id {
+		return fmt.Errorf("duplicated product: sku=%s, seller_id=%s", product.SKU, product.SellerId)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/sellers/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"time"
+)
+
+type storesService struct {
+	storesStore store.StoresStore
+	pb.UnimplementedStoresServiceServer
+}
+
+func NewStoresService(storesStore store.StoresStore) pb.StoresServiceServer {
+	return &storesService{storesStore: storesStore}
+}
+
+// GetStore returns the seller storefront, sellers that never configured it
+// get an empty store that is always open.
+func (s *storesService) GetStore(ctx context.Context, req *pb.GetStoreRequest) (*pb.Store, error) {
+	sellerStore, err := s.getStore(ctx, req.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	if req.At > 0 {
+		at = time.Unix(req.At, 0)
+	}
+
+	storefront := sellerStore.ToProto(at)
+	if until := time.Unix(req.Until, 0); req.Until > 0 && until.After(at) {
+		storefront.Open, storefront.ClosedReason = sellerStore.IsOpenDuring(at, until)
+	}
+
+	return storefront, nil
+}
+
+func (s *storesService) UpdateStore(ctx context.Context, req *pb.Store) (*pb.Store, error) {
+	sellerStore, err := s.getStore(ctx, req.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timezone != "" {
+		_, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid store timezone: timezone=%v", req.Timezone)
+		}
+	}
+
+	sellerStore.OpeningHours = nil
+	for _, hours := range req.OpeningHours {
+		if hours.Weekday < 0 || hours.Weekday > 6 {
+			return nil, fmt.Errorf("invalid opening hours weekday: weekday=%v", hours.Weekday)
+		}
+
+		opens, err := store.ParseClock(hours.Opens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid opening hours: opens=%v", hours.Opens)
+		}
+
+		closes, err := store.ParseClock(hours.Closes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid opening hours: closes=%v", hours.Closes)
+		}
+
+		if opens == closes {
+			return nil, fmt.Errorf("invalid opening hours, empty range: weekday=%v", hours.Weekday)
+		}
+
+		sellerStore.OpeningHours = append(sellerStore.OpeningHours, store.OpeningHours{
+			Weekday: hours.Weekday,
+			Opens:   hours.Opens,
+			Closes:  hours.Closes,
+		})
+	}
+
+	sellerStore.Holidays = nil
+	for _, holiday := range req.Holidays {
+		_, err = time.Parse(store.DateLayout, holiday.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday date: date=%v", holiday.Date)
+		}
+
+		sellerStore.Holidays = append(sellerStore.Holidays, store.Holiday{
+			Date: holiday.Date,
+			Name: strings.TrimSpace(holiday.Name),
+		})
+	}
+
+	sellerStore.Name = strings.TrimSpace(req.Name)
+	sellerStore.Description = strings.TrimSpace(req.Description)
+	sellerStore.Cuisine = strings.TrimSpace(req.Cuisine)
+	sellerStore.LogoURL = strings.TrimSpace(req.LogoUrl)
+	sellerStore.Timezone = req.Timezone
+	sellerStore.UpdatedAt = time.Now()
+
+	err = s.storesStore.Save(ctx, sellerStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return sellerStore.ToProto(time.Now()), nil
+}
+
+// PauseStore stops accepting orders until the given time, a zero or past
+// time resumes the store.
+func (s *storesService) PauseStore(ctx context.Context, req *pb.PauseStoreRequest) (*pb.Store, error) {
+	sellerStore, err := s.getStore(ctx, req.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	sellerStore.PausedUntil = time.Time{}
+	sellerStore.PauseReason = ""
+
+	if until := time.Unix(req.Until, 0); req.Until > 0 && until.After(time.Now()) {
+		sellerStore.PausedUntil = until
+		sellerStore.PauseReason = strings.TrimSpace(req.Reason)
+	}
+
+	sellerStore.UpdatedAt = time.Now()
+
+	err = s.storesStore.Save(ctx, sellerStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return sellerStore.ToProto(time.Now()), nil
+}
+
+func (s *storesService) getStore(ctx context.Context, sellerId string) (*store.Store, error) {
+	id, err := primitive.ObjectIDFromHex(sellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	sellerStore, err := s.storesStore.Get(ctx, id.Hex())
+	if err == mongo.ErrNoDocuments {
+		return &store.Store{SellerId: id.Hex(), CreatedAt: time.Now(), UpdatedAt: time.Now()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sellerStore, nil
+}
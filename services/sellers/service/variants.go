@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-delivery/services/sellers/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// prepareConfiguration validates the variants and option groups of the
+// product, assigns the missing ids and sums the variants stock.
+func prepareConfiguration(product *store.Product) error {
+	if product.LowStockThreshold < 0 {
+		return fmt.Errorf("invalid low stock threshold: threshold=%v", product.LowStockThreshold)
+	}
+
+	skus := make(map[string]bool)
+	var quantity int32
+
+	for index := range product.Variants {
+		variant := &product.Variants[index]
+
+		if variant.Name == "" || variant.SKU == "" {
+			return errors.New("invalid variant, name and sku are required")
+		}
+		if skus[variant.SKU] {
+			return fmt.Errorf("duplicated variant sku: sku=%v", variant.SKU)
+		}
+		if variant.Price <= 0 || variant.Quantity < 0 {
+			return fmt.Errorf("invalid variant price or quantity: sku=%v", variant.SKU)
+		}
+
+		if variant.Id == "" {
+			variant.Id = primitive.NewObjectID().Hex()
+		}
+
+		skus[variant.SKU] = true
+		quantity += variant.Quantity
+	}
+
+	if len(product.Variants) > 0 {
+		product.Quantity = quantity
+	}
+
+	for index := range product.OptionGroups {
+		group := &product.OptionGroups[index]
+
+		if group.Name == "" || len(group.Options) == 0 {
+			return errors.New("invalid option group, name and options are required")
+		}
+		if group.MinSelections < 0 || group.MaxSelections < 1 || group.MinSelections > group.MaxSelections {
+			return fmt.Errorf("invalid option group selections: group=%v", group.Name)
+		}
+		if int(group.MinSelections) > len(group.Options) {
+			return fmt.Errorf("invalid option group, not enough options: group=%v", group.Name)
+		}
+
+		if group.Id == "" {
+			group.Id = primitive.NewObjectID().Hex()
+		}
+
+		for optionIndex := range group.Options {
+			option := &group.Options[optionIndex]
+
+			if option.Name == "" || option.PriceDelta < 0 {
+				return fmt.Errorf("invalid option: group=%v", group.Name)
+			}
+
+			if option.Id == "" {
+				option.Id = primitive.NewObjectID().Hex()
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"go-delivery/pb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"time"
+)
+
+const ImportJobsCollection = "import_jobs"
+
+type ImportJobsStore interface {
+	Create(ctx context.Context, job *ImportJob) error
+	Update(ctx context.Context, job *ImportJob) error
+	Get(ctx context.Context, id primitive.ObjectID) (*ImportJob, error)
+	FailInterrupted(ctx context.Context) error
+}
+
+type importJobsStore struct {
+	conn *mongo.Collection
+}
+
+func NewImportJobsStore(dbConn *mongo.Database) ImportJobsStore {
+	return &importJobsStore{conn: dbConn.Collection(ImportJobsCollection)}
+}
+
+func (s *importJobsStore) Create(ctx context.Context, job *ImportJob) error {
+	result, err := s.conn.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	log.Printf("import job created: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *importJobsStore) Update(ctx context.Context, job *ImportJob) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":     job.Status,
+			"total":      job.Total,
+			"processed":  job.Processed,
+			"created":    job.Created,
+			"updated":    job.Updated,
+			"failed":     job.Failed,
+			"errors":     job.Errors,
+			"message":    job.Message,
+			"updated_at": job.UpdatedAt,
+		},
+	}
+
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": job.Id}, update)
+	return err
+}
+
+func (s *importJobsStore) Get(ctx context.Context, id primitive.ObjectID) (*ImportJob, error) {
+	var job ImportJob
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// FailInterrupted marks the jobs left unfinished by a previous process as
+// failed, jobs only run in the process that started them.
+func (s *importJobsStore) FailInterrupted(ctx context.Context) error {
+	filter := bson.M{"status": bson.M{"$in": []int32{int32(pb.ImportStatus_ImportPending), int32(pb.ImportStatus_ImportRunning)}}}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     int32(pb.ImportStatus_ImportFailed),
+			"message":    "import interrupted",
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := s.conn.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	log.Printf("import jobs interrupted: total=%v\n", result.ModifiedCount)
+	return nil
+}
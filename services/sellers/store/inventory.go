@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const (
+	InventoryLogCollection = "inventory_log"
+	StockAlertsCollection  = "stock_alerts"
+	maxStockAlerts         = 100
+)
+
+type InventoryStore interface {
+	LogAdjustment(ctx context.Context, adjustment *InventoryAdjustment) error
+	GetAdjustments(ctx context.Context, productId string) ([]*InventoryAdjustment, error)
+	CreateAlert(ctx context.Context, alert *StockAlert) error
+	GetAlerts(ctx context.Context, sellerId string) ([]*StockAlert, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type inventoryStore struct {
+	log    *mongo.Collection
+	alerts *mongo.Collection
+}
+
+func NewInventoryStore(dbConn *mongo.Database) InventoryStore {
+	return &inventoryStore{
+		log:    dbConn.Collection(InventoryLogCollection),
+		alerts: dbConn.Collection(StockAlertsCollection),
+	}
+}
+
+func (s *inventoryStore) LogAdjustment(ctx context.Context, adjustment *InventoryAdjustment) error {
+	result, err := s.log.InsertOne(ctx, adjustment)
+	if err != nil {
+		return err
+	}
+	log.Printf("inventory adjusted: id=%v, productId=%v, delta=%v\n", result.InsertedID, adjustment.ProductId, adjustment.Delta)
+	return nil
+}
+
+func (s *inventoryStore) GetAdjustments(ctx context.Context, productId string) ([]*InventoryAdjustment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := s.log.Find(ctx, bson.M{"product_id": productId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var adjustments []*InventoryAdjustment
+
+	err = cursor.All(ctx, &adjustments)
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
+
+func (s *inventoryStore) CreateAlert(ctx context.Context, alert *StockAlert) error {
+	result, err := s.alerts.InsertOne(ctx, alert)
+	if err != nil {
+		return err
+	}
+	log.Printf("stock alert created: id=%v, sellerId=%v, productId=%v\n", result.InsertedID, alert.SellerId, alert.ProductId)
+	return nil
+}
+
+// GetAlerts returns the most recent alerts of the seller.
+func (s *inventoryStore) GetAlerts(ctx context.Context, sellerId string) ([]*StockAlert, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(maxStockAlerts)
+
+	cursor, err := s.alerts.Find(ctx, bson.M{"seller_id": sellerId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var alerts []*StockAlert
+
+	err = cursor.All(ctx, &alerts)
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func (s *inventoryStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.log.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}},
+		Options: options.Index().SetName("inventory_log_product"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.alerts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "seller_id", Value: 1}, {Key: "created_at", Value: -1}},
+		Options: options.Index().SetName("stock_alerts_seller"),
+	})
+	return err
+}
@@ -3,31 +3,137 @@ package store
 import (
 	"go-delivery/pb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
 	"time"
 )
 
 type Product struct {
-	Id           primitive.ObjectID `bson:"_id"`
-	SellerId     string             `bson:"seller_id"`
-	Name         string             `bson:"name"`
-	Price        float32            `bson:"price"`
-	DeliveryCost float32            `bson:"delivery_cost"`
-	Quantity     int32              `bson:"quantity"`
-	CreatedAt    time.Time          `bson:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at"`
+	Id                primitive.ObjectID `bson:"_id"`
+	SellerId          string             `bson:"seller_id"`
+	SKU               string             `bson:"sku"`
+	Name              string             `bson:"name"`
+	Description       string             `bson:"description"`
+	Category          string             `bson:"category"`
+	Tags              []string           `bson:"tags"`
+	Variants          []Variant          `bson:"variants"`
+	OptionGroups      []OptionGroup      `bson:"option_groups"`
+	Images            []Image            `bson:"images"`
+	Price             float32            `bson:"price"`
+	DeliveryCost      float32            `bson:"delivery_cost"`
+	Quantity          int32              `bson:"quantity"`
+	LowStockThreshold int32              `bson:"low_stock_threshold"`
+	OutOfStock        bool               `bson:"out_of_stock"`
+	ArchivedAt        *time.Time         `bson:"archived_at"`
+	Version           int32              `bson:"version"`
+	RatingAverage     float32            `bson:"rating_average"`
+	RatingCount       int32              `bson:"rating_count"`
+	CreatedAt         time.Time          `bson:"created_at"`
+	UpdatedAt         time.Time          `bson:"updated_at"`
+}
+
+type Variant struct {
+	Id       string  `bson:"id"`
+	SKU      string  `bson:"sku"`
+	Name     string  `bson:"name"`
+	Price    float32 `bson:"price"`
+	Quantity int32   `bson:"quantity"`
+}
+
+type Option struct {
+	Id         string  `bson:"id"`
+	Name       string  `bson:"name"`
+	PriceDelta float32 `bson:"price_delta"`
+}
+
+type OptionGroup struct {
+	Id            string   `bson:"id"`
+	Name          string   `bson:"name"`
+	MinSelections int32    `bson:"min_selections"`
+	MaxSelections int32    `bson:"max_selections"`
+	Options       []Option `bson:"options"`
+}
+
+type Image struct {
+	Id           string    `bson:"id"`
+	Key          string    `bson:"key"`
+	ThumbnailKey string    `bson:"thumbnail_key"`
+	URL          string    `bson:"url"`
+	ThumbnailURL string    `bson:"thumbnail_url"`
+	ContentType  string    `bson:"content_type"`
+	Width        int32     `bson:"width"`
+	Height       int32     `bson:"height"`
+	Size         int64     `bson:"size"`
+	CreatedAt    time.Time `bson:"created_at"`
+}
+
+func (i *Image) ToProto() *pb.ProductImage {
+	return &pb.ProductImage{
+		Id:           i.Id,
+		Key:          i.Key,
+		ThumbnailKey: i.ThumbnailKey,
+		Url:          i.URL,
+		ThumbnailUrl: i.ThumbnailURL,
+		ContentType:  i.ContentType,
+		Width:        i.Width,
+		Height:       i.Height,
+		Size:         i.Size,
+		CreatedAt:    i.CreatedAt.Unix(),
+	}
+}
+
+func ImageFromProto(i *pb.ProductImage) Image {
+	return Image{
+		Id:           i.Id,
+		Key:          i.Key,
+		ThumbnailKey: i.ThumbnailKey,
+		URL:          i.Url,
+		ThumbnailURL: i.ThumbnailUrl,
+		ContentType:  i.ContentType,
+		Width:        i.Width,
+		Height:       i.Height,
+		Size:         i.Size,
+		CreatedAt:    time.Unix(i.CreatedAt, 0),
+	}
+}
+
+func ImagesToProto(images []Image) []*pb.ProductImage {
+	var items []*pb.ProductImage
+	for index := range images {
+		items = append(items, images[index].ToProto())
+	}
+	return items
 }
 
 func (p *Product) ToProto() *pb.Product {
-	return &pb.Product{
-		Id:           p.Id.Hex(),
-		SellerId:     p.SellerId,
-		Name:         p.Name,
-		Price:        p.Price,
-		DeliveryCost: p.DeliveryCost,
-		Quantity:     p.Quantity,
-		CreatedAt:    p.CreatedAt.Unix(),
-		UpdatedAt:    p.UpdatedAt.Unix(),
+	product := &pb.Product{
+		Id:                p.Id.Hex(),
+		SellerId:          p.SellerId,
+		Sku:               p.SKU,
+		Name:              p.Name,
+		Description:       p.Description,
+		Category:          p.Category,
+		Tags:              p.Tags,
+		Variants:          VariantsToProto(p.Variants),
+		OptionGroups:      OptionGroupsToProto(p.OptionGroups),
+		Images:            ImagesToProto(p.Images),
+		Price:             p.Price,
+		DeliveryCost:      p.DeliveryCost,
+		Quantity:          p.Quantity,
+		Version:           p.Version,
+		LowStockThreshold: p.LowStockThreshold,
+		OutOfStock:        p.OutOfStock,
+		RatingAverage:     p.RatingAverage,
+		RatingCount:       p.RatingCount,
+		CreatedAt:         p.CreatedAt.Unix(),
+		UpdatedAt:         p.UpdatedAt.Unix(),
+	}
+
+	if p.ArchivedAt != nil {
+		product.Archived = true
+		product.ArchivedAt = p.ArchivedAt.Unix()
 	}
+
+	return product
 }
 
 func FromProto(p *pb.Product) (*Product, error) {
@@ -41,13 +147,405 @@ func FromProto(p *pb.Product) (*Product, error) {
 	}
 
 	return &Product{
-		Id:           id,
-		SellerId:     sellerId.Hex(),
-		Name:         p.Name,
-		Price:        p.Price,
-		DeliveryCost: p.DeliveryCost,
-		Quantity:     p.Quantity,
-		CreatedAt:    time.Unix(p.CreatedAt, 0),
-		UpdatedAt:    time.Unix(p.UpdatedAt, 0),
+		Id:                id,
+		SellerId:          sellerId.Hex(),
+		SKU:               strings.TrimSpace(p.Sku),
+		Name:              p.Name,
+		Description:       p.Description,
+		Category:          NormalizeCategory(p.Category),
+		Tags:              NormalizeTags(p.Tags),
+		Variants:          VariantsFromProto(p.Variants),
+		OptionGroups:      OptionGroupsFromProto(p.OptionGroups),
+		Price:             p.Price,
+		DeliveryCost:      p.DeliveryCost,
+		Quantity:          p.Quantity,
+		LowStockThreshold: p.LowStockThreshold,
+		CreatedAt:         time.Unix(p.CreatedAt, 0),
+		UpdatedAt:         time.Unix(p.UpdatedAt, 0),
 	}, nil
 }
+
+func VariantsToProto(variants []Variant) []*pb.ProductVariant {
+	var items []*pb.ProductVariant
+	for _, variant := range variants {
+		items = append(items, &pb.ProductVariant{
+			Id:       variant.Id,
+			Sku:      variant.SKU,
+			Name:     variant.Name,
+			Price:    variant.Price,
+			Quantity: variant.Quantity,
+		})
+	}
+	return items
+}
+
+func VariantsFromProto(variants []*pb.ProductVariant) []Variant {
+	var items []Variant
+	for _, variant := range variants {
+		items = append(items, Variant{
+			Id:       variant.Id,
+			SKU:      strings.TrimSpace(variant.Sku),
+			Name:     strings.TrimSpace(variant.Name),
+			Price:    variant.Price,
+			Quantity: variant.Quantity,
+		})
+	}
+	return items
+}
+
+func OptionGroupsToProto(groups []OptionGroup) []*pb.OptionGroup {
+	var items []*pb.OptionGroup
+	for _, group := range groups {
+		item := &pb.OptionGroup{
+			Id:            group.Id,
+			Name:          group.Name,
+			MinSelections: group.MinSelections,
+			MaxSelections: group.MaxSelections,
+		}
+		for _, option := range group.Options {
+			item.Options = append(item.Options, &pb.ProductOption{
+				Id:         option.Id,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func OptionGroupsFromProto(groups []*pb.OptionGroup) []OptionGroup {
+	var items []OptionGroup
+	for _, group := range groups {
+		item := OptionGroup{
+			Id:            group.Id,
+			Name:          strings.TrimSpace(group.Name),
+			MinSelections: group.MinSelections,
+			MaxSelections: group.MaxSelections,
+		}
+		for _, option := range group.Options {
+			item.Options = append(item.Options, Option{
+				Id:         option.Id,
+				Name:       strings.TrimSpace(option.Name),
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// NormalizeCategory lowercases the category so filters are case insensitive.
+func NormalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// NormalizeTags lowercases the tags and drops empty or repeated ones.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+const (
+	DateLayout  = "2006-01-02"
+	ClockLayout = "15:04"
+
+	ClosedPaused  = "paused"
+	ClosedHoliday = "holiday"
+	ClosedHours   = "outside opening hours"
+)
+
+type OpeningHours struct {
+	Weekday int32  `bson:"weekday"`
+	Opens   string `bson:"opens"`
+	Closes  string `bson:"closes"`
+}
+
+type Holiday struct {
+	Date string `bson:"date"`
+	Name string `bson:"name"`
+}
+
+// Store is the seller storefront, its id is the seller id. A store without
+// opening hours is open every day at any time.
+type Store struct {
+	SellerId      string         `bson:"_id"`
+	Name          string         `bson:"name"`
+	Description   string         `bson:"description"`
+	Cuisine       string         `bson:"cuisine"`
+	LogoURL       string         `bson:"logo_url"`
+	Timezone      string         `bson:"timezone"`
+	OpeningHours  []OpeningHours `bson:"opening_hours"`
+	Holidays      []Holiday      `bson:"holidays"`
+	PausedUntil   time.Time      `bson:"paused_until"`
+	PauseReason   string         `bson:"pause_reason"`
+	RatingAverage float32        `bson:"rating_average"`
+	RatingCount   int32          `bson:"rating_count"`
+	CreatedAt     time.Time      `bson:"created_at"`
+	UpdatedAt     time.Time      `bson:"updated_at"`
+}
+
+// IsOpen reports whether orders are accepted at the given time, the reason
+// is set when the store is closed.
+func (s *Store) IsOpen(at time.Time) (bool, string) {
+	return s.IsOpenDuring(at, at)
+}
+
+// IsOpenDuring reports whether the store stays open over the whole
+// [from, until) range, the range must lie inside a single opening interval.
+func (s *Store) IsOpenDuring(from, until time.Time) (bool, string) {
+	if from.Before(s.PausedUntil) {
+		return false, ClosedPaused
+	}
+
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := from.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	for day := today; day.Equal(today) || day.Before(until); day = day.AddDate(0, 0, 1) {
+		date := day.Format(DateLayout)
+		for _, holiday := range s.Holidays {
+			if holiday.Date == date {
+				return false, ClosedHoliday
+			}
+		}
+	}
+
+	if len(s.OpeningHours) == 0 {
+		return true, ""
+	}
+
+	// overnight hours of yesterday may still be open today.
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		for _, hours := range s.OpeningHours {
+			if hours.Weekday != int32(day.Weekday()) {
+				continue
+			}
+
+			opens, closes, ok := hours.interval(day)
+			if ok && !from.Before(opens) && from.Before(closes) && !until.After(closes) {
+				return true, ""
+			}
+		}
+	}
+
+	return false, ClosedHours
+}
+
+// interval returns when the hours open and close on the given local day,
+// hours closing before they open close on the next day.
+func (h OpeningHours) interval(day time.Time) (time.Time, time.Time, bool) {
+	opens, err := ParseClock(h.Opens)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	closes, err := ParseClock(h.Closes)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	closesDay := day
+	if closes <= opens {
+		closesDay = day.AddDate(0, 0, 1)
+	}
+
+	return clockOn(day, opens), clockOn(closesDay, closes), true
+}
+
+// clockOn returns the time of the day at the given minutes since midnight,
+// in wall clock time so daylight saving changes don't shift the hours.
+func clockOn(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, minutes, 0, 0, day.Location())
+}
+
+func (s *Store) ToProto(at time.Time) *pb.Store {
+	open, reason := s.IsOpen(at)
+
+	store := &pb.Store{
+		SellerId:      s.SellerId,
+		Name:          s.Name,
+		Description:   s.Description,
+		Cuisine:       s.Cuisine,
+		LogoUrl:       s.LogoURL,
+		Timezone:      s.Timezone,
+		PauseReason:   s.PauseReason,
+		Open:          open,
+		ClosedReason:  reason,
+		RatingAverage: s.RatingAverage,
+		RatingCount:   s.RatingCount,
+		CreatedAt:     s.CreatedAt.Unix(),
+		UpdatedAt:     s.UpdatedAt.Unix(),
+	}
+
+	if !s.PausedUntil.IsZero() {
+		store.PausedUntil = s.PausedUntil.Unix()
+	}
+
+	for _, hours := range s.OpeningHours {
+		store.OpeningHours = append(store.OpeningHours, &pb.OpeningHours{
+			Weekday: hours.Weekday,
+			Opens:   hours.Opens,
+			Closes:  hours.Closes,
+		})
+	}
+
+	for _, holiday := range s.Holidays {
+		store.Holidays = append(store.Holidays, &pb.Holiday{Date: holiday.Date, Name: holiday.Name})
+	}
+
+	return store
+}
+
+// ParseClock returns the minutes since midnight of a "HH:MM" time.
+func ParseClock(value string) (int, error) {
+	clock, err := time.Parse(ClockLayout, value)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+type ImportRowError struct {
+	Row     int32  `bson:"row"`
+	SKU     string `bson:"sku"`
+	Message string `bson:"message"`
+}
+
+type ImportJob struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	SellerId  string             `bson:"seller_id"`
+	Format    string             `bson:"format"`
+	Status    int32              `bson:"status"`
+	Total     int32              `bson:"total"`
+	Processed int32              `bson:"processed"`
+	Created   int32              `bson:"created"`
+	Updated   int32              `bson:"updated"`
+	Failed    int32              `bson:"failed"`
+	Errors    []ImportRowError   `bson:"errors"`
+	Message   string             `bson:"message"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+func (j *ImportJob) ToProto() *pb.ImportJob {
+	job := &pb.ImportJob{
+		Id:        j.Id.Hex(),
+		SellerId:  j.SellerId,
+		Format:    j.Format,
+		Status:    pb.ImportStatus(j.Status),
+		Total:     j.Total,
+		Processed: j.Processed,
+		Created:   j.Created,
+		Updated:   j.Updated,
+		Failed:    j.Failed,
+		Message:   j.Message,
+		CreatedAt: j.CreatedAt.Unix(),
+		UpdatedAt: j.UpdatedAt.Unix(),
+	}
+
+	for _, rowError := range j.Errors {
+		job.Errors = append(job.Errors, &pb.ImportRowError{
+			Row:     rowError.Row,
+			Sku:     rowError.SKU,
+			Message: rowError.Message,
+		})
+	}
+
+	return job
+}
+
+// PriceChange is a price version of a product, pending until applied.
+type PriceChange struct {
+	Id            primitive.ObjectID `bson:"_id"`
+	ProductId     string             `bson:"product_id"`
+	Version       int32              `bson:"version"`
+	Price         float32            `bson:"price"`
+	DeliveryCost  float32            `bson:"delivery_cost"`
+	EffectiveFrom time.Time          `bson:"effective_from"`
+	Applied       bool               `bson:"applied"`
+	CreatedAt     time.Time          `bson:"created_at"`
+}
+
+func (c *PriceChange) ToProto() *pb.PriceChange {
+	return &pb.PriceChange{
+		Id:            c.Id.Hex(),
+		ProductId:     c.ProductId,
+		Version:       c.Version,
+		Price:         c.Price,
+		DeliveryCost:  c.DeliveryCost,
+		EffectiveFrom: c.EffectiveFrom.Unix(),
+		Applied:       c.Applied,
+		CreatedAt:     c.CreatedAt.Unix(),
+	}
+}
+
+type InventoryAdjustment struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	ProductId string             `bson:"product_id"`
+	SellerId  string             `bson:"seller_id"`
+	VariantId string             `bson:"variant_id"`
+	Reason    int32              `bson:"reason"`
+	Delta     int32              `bson:"delta"`
+	Quantity  int32              `bson:"quantity"`
+	OrderId   string             `bson:"order_id"`
+	Note      string             `bson:"note"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (a *InventoryAdjustment) ToProto() *pb.InventoryAdjustment {
+	return &pb.InventoryAdjustment{
+		Id:        a.Id.Hex(),
+		ProductId: a.ProductId,
+		SellerId:  a.SellerId,
+		VariantId: a.VariantId,
+		Reason:    pb.InventoryReason(a.Reason),
+		Delta:     a.Delta,
+		Quantity:  a.Quantity,
+		OrderId:   a.OrderId,
+		Note:      a.Note,
+		CreatedAt: a.CreatedAt.Unix(),
+	}
+}
+
+type StockAlert struct {
+	Id          primitive.ObjectID `bson:"_id"`
+	SellerId    string             `bson:"seller_id"`
+	ProductId   string             `bson:"product_id"`
+	ProductName string             `bson:"product_name"`
+	Kind        int32              `bson:"kind"`
+	Quantity    int32              `bson:"quantity"`
+	Threshold   int32              `bson:"threshold"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+func (a *StockAlert) ToProto() *pb.StockAlert {
+	return &pb.StockAlert{
+		Id:          a.Id.Hex(),
+		SellerId:    a.SellerId,
+		ProductId:   a.ProductId,
+		ProductName: a.ProductName,
+		Kind:        pb.StockAlertKind(a.Kind),
+		Quantity:    a.Quantity,
+		Threshold:   a.Threshold,
+		CreatedAt:   a.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsOpenDuring(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2024, time.January, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	s := &Store{
+		Timezone: "UTC",
+		OpeningHours: []OpeningHours{
+			{Weekday: int32(time.Monday), Opens: "09:00", Closes: "12:00"},
+			{Weekday: int32(time.Monday), Opens: "12:00", Closes: "14:00"},
+			{Weekday: int32(time.Monday), Opens: "18:00", Closes: "02:00"},
+			{Weekday: int32(time.Sunday), Opens: "20:00", Closes: "01:00"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		from   time.Time
+		until  time.Time
+		open   bool
+		reason string
+	}{
+		{"inside the hours", monday(9, 30), monday(10, 0), true, ""},
+		{"ends when the store closes", monday(11, 30), monday(12, 0), true, ""},
+		{"starts before the store opens", monday(8, 45), monday(9, 15), false, ClosedHours},
+		{"ends after the store closes", monday(13, 45), monday(14, 15), false, ClosedHours},
+		{"spans two intervals", monday(11, 45), monday(12, 15), false, ClosedHours},
+		{"closed in between", monday(13, 0), monday(19, 0), false, ClosedHours},
+		{"overnight hours", monday(23, 30), monday(24, 30), true, ""},
+		{"overnight hours from yesterday", monday(0, 15), monday(0, 45), true, ""},
+		{"after the overnight hours", monday(0, 45), monday(1, 15), false, ClosedHours},
+		{"single time", monday(9, 0), monday(9, 0), true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			open, reason := s.IsOpenDuring(test.from, test.until)
+			if open != test.open || reason != test.reason {
+				t.Fatalf("IsOpenDuring() = %v, %q, want %v, %q", open, reason, test.open, test.reason)
+			}
+		})
+	}
+}
+
+func TestIsOpenDuringHolidaysAndPauses(t *testing.T) {
+	from := time.Date(2024, time.January, 1, 23, 30, 0, 0, time.UTC)
+	until := from.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		store  *Store
+		open   bool
+		reason string
+	}{
+		{"always open", &Store{}, true, ""},
+		{"holiday at the end of the range", &Store{Holidays: []Holiday{{Date: "2024-01-02"}}}, false, ClosedHoliday},
+		{"holiday before the range", &Store{Holidays: []Holiday{{Date: "2023-12-31"}}}, true, ""},
+		{"paused", &Store{PausedUntil: from.Add(time.Minute)}, false, ClosedPaused},
+		{"pause over", &Store{PausedUntil: from}, true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			open, reason := test.store.IsOpenDuring(from, until)
+			if open != test.open || reason != test.reason {
+				t.Fatalf("IsOpenDuring() = %v, %q, want %v, %q", open, reason, test.open, test.reason)
+			}
+		})
+	}
+}
+
+func TestIsOpenKeepsWallClockHours(t *testing.T) {
+	// daylight saving starts on 2024-03-31 at 02:00 in Paris.
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	s := &Store{
+		Timezone:     "Europe/Paris",
+		OpeningHours: []OpeningHours{{Weekday: int32(time.Sunday), Opens: "09:00", Closes: "12:00"}},
+	}
+
+	if open, _ := s.IsOpen(time.Date(2024, time.March, 31, 9, 0, 0, 0, paris)); !open {
+		t.Fatal("IsOpen() = false at opening time")
+	}
+	if open, _ := s.IsOpen(time.Date(2024, time.March, 31, 12, 0, 0, 0, paris)); open {
+		t.Fatal("IsOpen() = true at closing time")
+	}
+}
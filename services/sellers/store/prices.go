@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const PriceChangesCollection = "price_changes"
+
+type PriceChangesStore interface {
+	Create(ctx context.Context, change *PriceChange) error
+	Get(ctx context.Context, id primitive.ObjectID) (*PriceChange, error)
+	GetByProduct(ctx context.Context, productId string) ([]*PriceChange, error)
+	GetDue(ctx context.Context, at time.Time) ([]*PriceChange, error)
+	MarkApplied(ctx context.Context, id primitive.ObjectID, version int32) error
+	DeletePending(ctx context.Context, id primitive.ObjectID) error
+	CreateIndexes(ctx context.Context) error
+}
+
+type priceChangesStore struct {
+	conn *mongo.Collection
+}
+
+func NewPriceChangesStore(dbConn *mongo.Database) PriceChangesStore {
+	return &priceChangesStore{conn: dbConn.Collection(PriceChangesCollection)}
+}
+
+func (s *priceChangesStore) Create(ctx context.Context, change *PriceChange) error {
+	result, err := s.conn.InsertOne(ctx, change)
+	if err != nil {
+		return err
+	}
+	log.Printf("price change created: id=%v\n", result.InsertedID)
+	return nil
+}
+
+func (s *priceChangesStore) Get(ctx context.Context, id primitive.ObjectID) (*PriceChange, error) {
+	var change PriceChange
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(&change)
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// GetByProduct returns the history and the pending changes of the product,
+// the most recent effective date first.
+func (s *priceChangesStore) GetByProduct(ctx context.Context, productId string) ([]*PriceChange, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "effective_from", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, bson.M{"product_id": productId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []*PriceChange
+
+	err = cursor.All(ctx, &changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetDue returns the pending changes effective at the given time, oldest
+// first so the latest one of a product is applied last.
+func (s *priceChangesStore) GetDue(ctx context.Context, at time.Time) ([]*PriceChange, error) {
+	filter := bson.M{"applied": false, "effective_from": bson.M{"$lte": at}}
+	opts := options.Find().SetSort(bson.D{{Key: "effective_from", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := s.conn.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []*PriceChange
+
+	err = cursor.All(ctx, &changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (s *priceChangesStore) MarkApplied(ctx context.Context, id primitive.ObjectID, version int32) error {
+	update := bson.M{
+		"$set": bson.M{"applied": true, "version": version},
+	}
+
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("price change applied: id=%v, version=%v\n", id.Hex(), version)
+	return nil
+}
+
+func (s *priceChangesStore) DeletePending(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.conn.DeleteOne(ctx, bson.M{"_id": id, "applied": false})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("scheduled price not found or already applied: id=%v", id.Hex())
+	}
+
+	return nil
+}
+
+func (s *priceChangesStore) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "effective_from", Value: -1}},
+			Options: options.Index().SetName("price_changes_product"),
+		},
+		{
+			Keys:    bson.D{{Key: "applied", Value: 1}, {Key: "effective_from", Value: 1}},
+			Options: options.Index().SetName("price_changes_due"),
+		},
+	}
+
+	names, err := s.conn.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("price change indexes created: names=%v\n", names)
+
+	return nil
+}
@@ -6,19 +6,30 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"time"
 )
 
 const ProductsCollection = "products"
 
 type ProductsStore interface {
 	Create(ctx context.Context, product *Product) error
-	Update(ctx context.Context, product *Product) error
+	Update(ctx context.Context, product *Product, readAt time.Time) error
 	Get(ctx context.Context, id primitive.ObjectID) (*Product, error)
-	GetByName(ctx context.Context, name string) (*Product, error)
-	GetBySeller(ctx context.Context, id primitive.ObjectID) ([]*Product, error)
+	GetByName(ctx context.Context, sellerId, name string) (*Product, error)
+	GetBySKU(ctx context.Context, sellerId, sku string) (*Product, error)
+	GetBySeller(ctx context.Context, id primitive.ObjectID, includeOutOfStock bool) ([]*Product, error)
+	GetArchivedBySeller(ctx context.Context, id primitive.ObjectID) ([]*Product, error)
 	GetAll(ctx context.Context) ([]*Product, error)
-	Delete(ctx context.Context, id primitive.ObjectID) error
+	Search(ctx context.Context, filter ProductFilter) ([]*Product, error)
+	CreateIndexes(ctx context.Context) error
+	AdjustQuantity(ctx context.Context, id primitive.ObjectID, variantId string, delta int32) (*Product, error)
+	SetRating(ctx context.Context, id primitive.ObjectID, average float32, count int32) error
+	Archive(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	AddImage(ctx context.Context, id primitive.ObjectID, image Image, max int) error
+	RemoveImage(ctx context.Context, id primitive.ObjectID, imageId string) error
 }
 
 type store struct {
@@ -38,25 +49,39 @@ func (s *store) Create(ctx context.Context, product *Product) error {
 	return nil
 }
 
-func (s *store) Update(ctx context.Context, product *Product) error {
+// Update saves the product without its stock, which only changes through
+// AdjustQuantity. readAt is the updated_at of the product when it was read,
+// the update fails when the product changed since, stock included.
+func (s *store) Update(ctx context.Context, product *Product, readAt time.Time) error {
 
 	update := bson.M{
 		"$set": bson.M{
-			"name":          product.Name,
-			"price":         product.Price,
-			"delivery_cost": product.DeliveryCost,
-			"quantity":      product.Quantity,
-			"updated_at":    product.UpdatedAt,
+			"sku":                 product.SKU,
+			"name":                product.Name,
+			"description":         product.Description,
+			"category":            product.Category,
+			"tags":                product.Tags,
+			"variants":            product.Variants,
+			"option_groups":       product.OptionGroups,
+			"price":               product.Price,
+			"delivery_cost":       product.DeliveryCost,
+			"version":             product.Version,
+			"low_stock_threshold": product.LowStockThreshold,
+			"updated_at":          product.UpdatedAt,
 		},
 	}
 
-	filter := bson.M{"_id": bson.M{"$eq": product.Id}}
+	filter := bson.M{"_id": bson.M{"$eq": product.Id}, "updated_at": readAt}
 
 	result, err := s.conn.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("product changed since it was read, try again: id=%v", product.Id.Hex())
+	}
+
 	log.Printf("product added: total=%v\n", result.ModifiedCount)
 
 	return nil
@@ -72,9 +97,9 @@ func (s *store) Get(ctx context.Context, id primitive.ObjectID) (*Product, error
 	return &product, nil
 }
 
-func (s *store) GetByName(ctx context.Context, name string) (*Product, error) {
+func (s *store) GetByName(ctx context.Context, sellerId, name string) (*Product, error) {
 	var product Product
-	err := s.conn.FindOne(ctx, bson.M{"name": name}).Decode(&product)
+	err := s.conn.FindOne(ctx, bson.M{"seller_id": sellerId, "name": name}).Decode(&product)
 	if err != nil {
 		return nil, err
 	}
@@ -82,8 +107,23 @@ func (s *store) GetByName(ctx context.Context, name string) (*Product, error) {
 	return &product, nil
 }
 
-func (s *store) GetBySeller(ctx context.Context, id primitive.ObjectID) ([]*Product, error) {
-	cursor, err := s.conn.Find(ctx, bson.M{"seller_id": id.Hex()})
+func (s *store) GetBySKU(ctx context.Context, sellerId, sku string) (*Product, error) {
+	var product Product
+	err := s.conn.FindOne(ctx, bson.M{"seller_id": sellerId, "sku": sku}).Decode(&product)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("product found: sku=%v\n", product.SKU)
+	return &product, nil
+}
+
+func (s *store) GetBySeller(ctx context.Context, id primitive.ObjectID, includeOutOfStock bool) ([]*Product, error) {
+	filter := bson.M{"seller_id": id.Hex(), "archived_at": nil}
+	if !includeOutOfStock {
+		filter["out_of_stock"] = bson.M{"$ne": true}
+	}
+
+	cursor, err := s.conn.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -101,8 +141,29 @@ func (s *store) GetBySeller(ctx context.Context, id primitive.ObjectID) ([]*Prod
 	return products, nil
 }
 
+func (s *store) GetArchivedBySeller(ctx context.Context, id primitive.ObjectID) ([]*Product, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "archived_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, bson.M{"seller_id": id.Hex(), "archived_at": bson.M{"$ne": nil}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []*Product
+
+	err = cursor.All(ctx, &products)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("list archived products: total=%v\n", len(products))
+
+	return products, nil
+}
+
 func (s *store) GetAll(ctx context.Context) ([]*Product, error) {
-	cursor, err := s.conn.Find(ctx, bson.M{})
+	cursor, err := s.conn.Find(ctx, bson.M{"archived_at": nil, "out_of_stock": bson.M{"$ne": true}})
 	if err != nil {
 		return nil, err
 	}
@@ -120,11 +181,266 @@ func (s *store) GetAll(ctx context.Context) ([]*Product, error) {
 	return products, nil
 }
 
-func (s *store) Delete(ctx context.Context, id primitive.ObjectID) error {
-	result, err := s.conn.DeleteOne(ctx, bson.M{"_id": id})
+// AdjustQuantity increments the product stock, and the variant one when a
+// variant is given, the update is rejected when the stock would go negative.
+// out_of_stock is derived from the new stock in the same update.
+func (s *store) AdjustQuantity(ctx context.Context, id primitive.ObjectID, variantId string, delta int32) (*Product, error) {
+	filter := bson.M{"_id": id, "quantity": bson.M{"$gte": -delta}}
+	set := bson.D{
+		{Key: "quantity", Value: bson.M{"$add": bson.A{"$quantity", delta}}},
+		{Key: "updated_at", Value: time.Now()},
+	}
+
+	if variantId != "" {
+		filter["variants"] = bson.M{"$elemMatch": bson.M{"id": variantId, "quantity": bson.M{"$gte": -delta}}}
+		set = append(set, bson.E{Key: "variants", Value: bson.M{"$map": bson.M{
+			"input": "$variants",
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$$this.id", variantId}},
+				bson.M{"$mergeObjects": bson.A{"$$this", bson.M{"quantity": bson.M{"$add": bson.A{"$$this.quantity", delta}}}}},
+				"$$this",
+			}},
+		}}})
+	}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: set}},
+		{{Key: "$set", Value: bson.M{"out_of_stock": bson.M{"$lte": bson.A{"$quantity", 0}}}}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product Product
+	err := s.conn.FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("product not found or stock insufficient: id=%v", id.Hex())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("product stock adjusted: id=%v, delta=%d\n", id.Hex(), delta)
+	return &product, nil
+}
+
+// SetRating replaces the rating aggregate, the product version is kept since
+// ratings are not part of what the customer ordered.
+func (s *store) SetRating(ctx context.Context, id primitive.ObjectID, average float32, count int32) error {
+	update := bson.M{
+		"$set": bson.M{"rating_average": average, "rating_count": count},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("product not found: id=%v", id.Hex())
+	}
+
+	fmt.Printf("product rating updated: id=%v, average=%v, count=%v\n", id.Hex(), average, count)
+	return nil
+}
+
+// Archive hides the product from listings, the document is kept since
+// orders reference it.
+func (s *store) Archive(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"archived_at": at, "updated_at": at},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, bson.M{"_id": id, "archived_at": nil}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("product not found or already archived: id=%v", id.Hex())
+	}
+
+	fmt.Printf("product archived: id=%v\n", id.Hex())
+	return nil
+}
+
+func (s *store) Restore(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set": bson.M{"archived_at": nil, "updated_at": time.Now()},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, bson.M{"_id": id, "archived_at": bson.M{"$ne": nil}}, update)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("product deleted: total=%d\n", result.DeletedCount)
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("product not found or not archived: id=%v", id.Hex())
+	}
+
+	fmt.Printf("product restored: id=%v\n", id.Hex())
+	return nil
+}
+
+const (
+	SortRecent = "recent"
+	SortRating = "rating"
+)
+
+type ProductFilter struct {
+	Query    string
+	Category string
+	Tag      string
+	SellerId string
+	MinPrice float32
+	MaxPrice float32
+	InStock  bool
+	Sort     string
+	Limit    int64
+	Offset   int64
+}
+
+func (s *store) Search(ctx context.Context, filter ProductFilter) ([]*Product, error) {
+	query := bson.M{"archived_at": nil, "out_of_stock": bson.M{"$ne": true}}
+	opts := options.Find().SetLimit(filter.Limit).SetSkip(filter.Offset)
+
+	if filter.Query != "" {
+		query["$text"] = bson.M{"$search": filter.Query}
+		opts.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}})
+	}
+
+	switch {
+	case filter.Sort == SortRating:
+		opts.SetSort(bson.D{{Key: "rating_average", Value: -1}, {Key: "rating_count", Value: -1}, {Key: "_id", Value: 1}})
+	case filter.Query != "" && filter.Sort != SortRecent:
+		opts.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}})
+	default:
+		opts.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}})
+	}
+
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
+	if filter.SellerId != "" {
+		query["seller_id"] = filter.SellerId
+	}
+	if filter.InStock {
+		query["quantity"] = bson.M{"$gt": 0}
+	}
+
+	price := bson.M{}
+	if filter.MinPrice > 0 {
+		price["$gte"] = filter.MinPrice
+	}
+	if filter.MaxPrice > 0 {
+		price["$lte"] = filter.MaxPrice
+	}
+	if len(price) > 0 {
+		query["price"] = price
+	}
+
+	cursor, err := s.conn.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []*Product
+
+	err = cursor.All(ctx, &products)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("search products: total=%v\n", len(products))
+
+	return products, nil
+}
+
+// CreateIndexes creates the text index used by Search, name matches weigh
+// more than description ones.
+func (s *store) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("products_text").
+				SetWeights(bson.M{"name": 10, "description": 1}),
+		},
+		{
+			Keys:    bson.D{{Key: "category", Value: 1}, {Key: "price", Value: 1}},
+			Options: options.Index().SetName("products_category_price"),
+		},
+		{
+			Keys:    bson.D{{Key: "tags", Value: 1}},
+			Options: options.Index().SetName("products_tags"),
+		},
+		{
+			Keys: bson.D{{Key: "seller_id", Value: 1}, {Key: "sku", Value: 1}},
+			Options: options.Index().
+				SetName("products_seller_sku").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"sku": bson.M{"$gt": ""}}),
+		},
+		{
+			Keys:    bson.D{{Key: "seller_id", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("products_seller_name"),
+		},
+		{
+			Keys:    bson.D{{Key: "rating_average", Value: -1}, {Key: "rating_count", Value: -1}},
+			Options: options.Index().SetName("products_rating"),
+		},
+	}
+
+	names, err := s.conn.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("product indexes created: names=%v\n", names)
+
+	return nil
+}
+
+// AddImage appends the image unless the product already has max images.
+func (s *store) AddImage(ctx context.Context, id primitive.ObjectID, image Image, max int) error {
+	filter := bson.M{
+		"_id":                           id,
+		fmt.Sprintf("images.%d", max-1): bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$push": bson.M{"images": image},
+		"$set":  bson.M{"updated_at": image.CreatedAt},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("product images limit reached: id=%v, max=%d", id.Hex(), max)
+	}
+
+	fmt.Printf("product image added: id=%v, imageId=%v\n", id.Hex(), image.Id)
+
+	return nil
+}
+
+func (s *store) RemoveImage(ctx context.Context, id primitive.ObjectID, imageId string) error {
+	update := bson.M{
+		"$pull": bson.M{"images": bson.M{"id": imageId}},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("product image removed: id=%v, total=%v\n", id.Hex(), result.ModifiedCount)
+
 	return nil
 }
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const StoresCollection = "stores"
+
+type StoresStore interface {
+	Save(ctx context.Context, store *Store) error
+	Get(ctx context.Context, sellerId string) (*Store, error)
+	SetRating(ctx context.Context, sellerId string, average float32, count int32) error
+}
+
+type storesStore struct {
+	conn *mongo.Collection
+}
+
+func NewStoresStore(dbConn *mongo.Database) StoresStore {
+	return &storesStore{conn: dbConn.Collection(StoresCollection)}
+}
+
+func (s *storesStore) Save(ctx context.Context, store *Store) error {
+	update := bson.M{
+		"$set": bson.M{
+			"name":          store.Name,
+			"description":   store.Description,
+			"cuisine":       store.Cuisine,
+			"logo_url":      store.LogoURL,
+			"timezone":      store.Timezone,
+			"opening_hours": store.OpeningHours,
+			"holidays":      store.Holidays,
+			"paused_until":  store.PausedUntil,
+			"pause_reason":  store.PauseReason,
+			"updated_at":    store.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"created_at": store.CreatedAt,
+		},
+	}
+
+	filter := bson.M{"_id": store.SellerId}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	log.Printf("store saved: sellerId=%v, total=%v\n", store.SellerId, result.ModifiedCount+result.UpsertedCount)
+	return nil
+}
+
+func (s *storesStore) Get(ctx context.Context, sellerId string) (*Store, error) {
+	var store Store
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": sellerId}).Decode(&store)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("found store: sellerId=%v\n", sellerId)
+
+	return &store, nil
+}
+
+// SetRating keeps the rest of the store untouched, the store is created when
+// the seller never configured it.
+func (s *storesStore) SetRating(ctx context.Context, sellerId string, average float32, count int32) error {
+	update := bson.M{
+		"$set": bson.M{
+			"rating_average": average,
+			"rating_count":   count,
+		},
+		"$setOnInsert": bson.M{
+			"created_at": time.Now(),
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": sellerId}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("store rating updated: sellerId=%v, average=%v, count=%v\n", sellerId, average, count)
+	return nil
+}
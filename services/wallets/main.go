@@ -6,7 +6,11 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"go-delivery/db"
+	"go-delivery/payments"
 	"go-delivery/pb"
+	"go-delivery/security/mtls"
+	"go-delivery/security/permissions"
+	"go-delivery/security/tokens"
 	"go-delivery/services/wallets/service"
 	"go-delivery/services/wallets/store"
 	"go-delivery/util"
@@ -16,7 +20,12 @@ import (
 	"time"
 )
 
-var port int
+var (
+	port           int
+	tlsConfig      mtls.Config
+	paymentsConfig payments.Config
+	payoutInterval time.Duration
+)
 
 func init() {
 	err := godotenv.Load(util.GetEnvFile())
@@ -25,11 +34,20 @@ func init() {
 	}
 
 	flag.IntVar(&port, "port", 7501, "grpc port")
+	flag.DurationVar(&payoutInterval, "payout_batch_interval", time.Hour, "interval between payout batches")
+
+	tlsConfig.RegisterFlags()
+	paymentsConfig.RegisterFlags()
 
 	flag.Parse()
 }
 
 func main() {
+	err := tokens.CheckSecrets()
+	if err != nil {
+		log.Panicln(err)
+	}
+
 	cfg := db.NewConfig()
 
 	log.Println("loading db configs: ", cfg.URI())
@@ -40,21 +58,77 @@ func main() {
 	dbConn := db.New(ctx, cfg)
 	defer dbConn.Close(ctx)
 
-	err := dbConn.Ping(ctx)
+	err = dbConn.Ping(ctx)
 	if err != nil {
 		log.Panicln(err)
 	}
 	log.Println("database connected successfully")
 
 	walletsStore := store.NewWalletsStore(dbConn.DB())
-	walletsService := service.NewService(walletsStore)
+	topUpsStore := store.NewTopUpsStore(dbConn.DB())
+	bankAccountsStore := store.NewBankAccountsStore(dbConn.DB())
+	payoutsStore := store.NewPayoutsStore(dbConn.DB())
+	ledgerStore := store.NewLedgerStore(dbConn.DB())
+
+	err = walletsStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	err = topUpsStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	err = bankAccountsStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	err = payoutsStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	err = ledgerStore.CreateIndexes(ctx)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	provider, err := paymentsConfig.NewProvider()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	cipher, err := paymentsConfig.NewCipher()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	walletsService := service.NewService(walletsStore, topUpsStore, bankAccountsStore, payoutsStore, ledgerStore, provider, cipher)
+
+	go service.NewPayoutScheduler(walletsStore, bankAccountsStore, payoutsStore, ledgerStore, cipher, paymentsConfig.SettlementDir).
+		Run(context.Background(), payoutInterval)
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		log.Panicln(err)
 	}
 
-	grpcServer := grpc.NewServer()
+	permissionsConfig := permissions.NewConfig()
+	rules := service.NewRules(walletsStore, topUpsStore)
+
+	serverOptions, err := tlsConfig.ServerOptions()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	serverOptions = append(serverOptions,
+		grpc.UnaryInterceptor(permissions.UnaryServerInterceptor(permissionsConfig, rules)),
+		grpc.StreamInterceptor(permissions.StreamServerInterceptor(permissionsConfig, rules)),
+	)
+
+	grpcServer := grpc.NewServer(serverOptions...)
 	pb.RegisterWalletsServiceServer(grpcServer, walletsService)
 
 	defer grpcServer.Stop()
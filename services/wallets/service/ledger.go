@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go-delivery/services/wallets/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+const minPayout = 20
+
+// payoutLedger moves the wallet cash of the payouts for the service and the
+// payout batch, each movement is recorded as a ledger entry.
+type payoutLedger struct {
+	walletsStore store.WalletsStore
+	payoutsStore store.PayoutsStore
+	ledgerStore  store.LedgerStore
+}
+
+func newPayoutLedger(walletsStore store.WalletsStore, payoutsStore store.PayoutsStore, ledgerStore store.LedgerStore) *payoutLedger {
+	return &payoutLedger{walletsStore: walletsStore, payoutsStore: payoutsStore, ledgerStore: ledgerStore}
+}
+
+// request creates a payout for the next batch. The wallet cash must cover
+// it, but the wallet is only debited once the payout is executed.
+func (l *payoutLedger) request(ctx context.Context, wallet *store.Wallet, account *store.BankAccount, amount float32, scheduled bool) (*store.Payout, error) {
+	if amount < minPayout {
+		return nil, fmt.Errorf("invalid payout, amount must be at least %d: amount=%v", minPayout, amount)
+	}
+
+	if amount > wallet.Cash {
+		return nil, fmt.Errorf("invalid payout, insufficient wallet cash: amount=%v, cash=%v", amount, wallet.Cash)
+	}
+
+	now := time.Now()
+
+	payout := &store.Payout{
+		Id:        primitive.NewObjectID(),
+		WalletId:  wallet.Id.Hex(),
+		UserId:    wallet.UserId,
+		Amount:    amount,
+		IbanLast4: account.IbanLast4,
+		Status:    int32(pb.PayoutStatus_PayoutRequested),
+		Scheduled: scheduled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := l.payoutsStore.Create(ctx, payout)
+	if err != nil {
+		return nil, err
+	}
+
+	return payout, nil
+}
+
+// execute takes the payout in the batch and debits the wallet, a payout the
+// wallet doesn't cover anymore fails without being debited.
+func (l *payoutLedger) execute(ctx context.Context, payout *store.Payout, batchId, ibanLast4 string, at time.Time) error {
+	walletId, err := primitive.ObjectIDFromHex(payout.WalletId)
+	if err != nil {
+		return err
+	}
+
+	err = l.payoutsStore.Execute(ctx, payout.Id, batchId, ibanLast4, at)
+	if err != nil {
+		return err
+	}
+
+	wallet, err := l.walletsStore.Withdraw(ctx, walletId, payout.Amount, "payout:"+payout.Id.Hex(), at)
+	if err != nil {
+		completeErr := l.payoutsStore.Complete(ctx, payout.Id, int32(pb.PayoutStatus_PayoutProcessing), int32(pb.PayoutStatus_PayoutFailed), err.Error(), at)
+		if completeErr != nil {
+			log.Printf("failed to fail uncovered payout: id=%v, err=%v", payout.Id.Hex(), completeErr)
+		}
+		return err
+	}
+
+	payout.Status = int32(pb.PayoutStatus_PayoutProcessing)
+	payout.BatchId = batchId
+	payout.IbanLast4 = ibanLast4
+	payout.ExecutedAt = &at
+	payout.UpdatedAt = at
+
+	l.record(ctx, payout, wallet, pb.LedgerEntryKind_LedgerPayout, -payout.Amount, at)
+
+	return nil
+}
+
+// fail completes a requested or processing payout as failed, an executed
+// payout is credited back to the wallet with a reversal entry.
+func (l *payoutLedger) fail(ctx context.Context, payout *store.Payout, reason string, at time.Time) error {
+	err := l.payoutsStore.Complete(ctx, payout.Id, payout.Status, int32(pb.PayoutStatus_PayoutFailed), reason, at)
+	if err != nil {
+		return err
+	}
+
+	executed := payout.Status == int32(pb.PayoutStatus_PayoutProcessing)
+
+	payout.Status = int32(pb.PayoutStatus_PayoutFailed)
+	payout.FailureReason = reason
+	payout.CompletedAt = &at
+	payout.UpdatedAt = at
+
+	if !executed {
+		return nil
+	}
+
+	walletId, err := primitive.ObjectIDFromHex(payout.WalletId)
+	if err != nil {
+		return err
+	}
+
+	wallet, err := l.walletsStore.Deposit(ctx, walletId, payout.Amount, "payout-reversal:"+payout.Id.Hex(), at)
+	if err != nil {
+		log.Printf("failed to credit back failed payout: id=%v, err=%v", payout.Id.Hex(), err)
+		return err
+	}
+
+	l.record(ctx, payout, wallet, pb.LedgerEntryKind_LedgerPayoutReversal, payout.Amount, at)
+
+	return nil
+}
+
+// record adds the ledger entry of a wallet movement already applied, so a
+// failure is logged rather than returned.
+func (l *payoutLedger) record(ctx context.Context, payout *store.Payout, wallet *store.Wallet, kind pb.LedgerEntryKind, amount float32, at time.Time) {
+	err := l.ledgerStore.Create(ctx, &store.LedgerEntry{
+		Id:        primitive.NewObjectID(),
+		WalletId:  payout.WalletId,
+		UserId:    payout.UserId,
+		Kind:      int32(kind),
+		Amount:    amount,
+		Balance:   wallet.Cash,
+		Reference: payout.Id.Hex(),
+		CreatedAt: at,
+	})
+	if err != nil {
+		log.Printf("failed to record ledger entry: payoutId=%v, kind=%v, err=%v", payout.Id.Hex(), kind, err)
+	}
+}
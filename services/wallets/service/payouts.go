@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-delivery/payments"
+	"go-delivery/pb"
+	"go-delivery/services/wallets/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+const (
+	maxHolderName    = 100
+	maxFailureReason = 500
+)
+
+// SetBankAccount saves the bank account receiving the payouts of the user,
+// the details are encrypted before being stored.
+func (s *serviceImpl) SetBankAccount(ctx context.Context, req *pb.BankAccount) (*pb.BankAccount, error) {
+	_, err := primitive.ObjectIDFromHex(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	holderName := strings.TrimSpace(req.HolderName)
+	if holderName == "" || len(holderName) > maxHolderName {
+		return nil, fmt.Errorf("invalid bank account, holder name must have 1 to %d characters", maxHolderName)
+	}
+
+	iban, err := payments.NormalizeIban(req.Iban)
+	if err != nil {
+		return nil, err
+	}
+
+	bic, err := payments.NormalizeBic(req.Bic)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make([]string, 3)
+	for index, value := range []string{holderName, iban, bic} {
+		sealed[index], err = s.cipher.Encrypt(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+
+	account, err := s.bankAccountsStore.Save(ctx, &store.BankAccount{
+		Id:         primitive.NewObjectID(),
+		UserId:     req.UserId,
+		HolderName: sealed[0],
+		Iban:       sealed[1],
+		Bic:        sealed[2],
+		IbanLast4:  payments.Last4(iban),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.describeAccount(account)
+}
+
+func (s *serviceImpl) GetBankAccount(ctx context.Context, req *pb.GetBankAccountRequest) (*pb.BankAccount, error) {
+	account, err := s.bankAccountsStore.GetByUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.describeAccount(account)
+}
+
+// SetPayoutSchedule pays the wallet out daily or weekly once its cash
+// reaches the threshold, manual payouts are requested by the user.
+func (s *serviceImpl) SetPayoutSchedule(ctx context.Context, req *pb.PayoutScheduleRequest) (*pb.BankAccount, error) {
+	if _, ok := pb.PayoutSchedule_name[int32(req.Schedule)]; !ok {
+		return nil, fmt.Errorf("invalid payout schedule: schedule=%v", req.Schedule)
+	}
+
+	threshold := req.Threshold
+	if threshold == 0 {
+		threshold = minPayout
+	}
+
+	if threshold < minPayout {
+		return nil, fmt.Errorf("invalid payout threshold, must be at least %d: threshold=%v", minPayout, threshold)
+	}
+
+	now := time.Now()
+
+	err := s.bankAccountsStore.UpdateSchedule(ctx, req.UserId, int32(req.Schedule), threshold, nextPayout(req.Schedule, now), now)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBankAccount(ctx, &pb.GetBankAccountRequest{UserId: req.UserId})
+}
+
+// RequestPayout asks for a payout in the next batch, a user has at most one
+// payout waiting for it.
+func (s *serviceImpl) RequestPayout(ctx context.Context, req *pb.Payout) (*pb.Payout, error) {
+	userId, err := primitive.ObjectIDFromHex(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.bankAccountsStore.GetByUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.walletsStore.GetByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	payout, err := s.payouts.request(ctx, wallet, account, req.Amount, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return payout.ToProto(), nil
+}
+
+func (s *serviceImpl) ListPayouts(req *pb.ListPayoutsRequest, stream pb.WalletsService_ListPayoutsServer) error {
+	var payouts []*store.Payout
+	var err error
+
+	if req.UserId != "" {
+		payouts, err = s.payoutsStore.GetByUser(stream.Context(), req.UserId)
+	} else {
+		payouts, err = s.payoutsStore.GetByStatus(stream.Context(), int32(req.Status))
+	}
+	if err != nil {
+		return err
+	}
+
+	for index := range payouts {
+		err = stream.Send(payouts[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdatePayoutStatus records the outcome of a transfer sent to the bank.
+// Failing a processing payout credits its amount back to the wallet, a
+// requested payout can also be failed before its batch.
+func (s *serviceImpl) UpdatePayoutStatus(ctx context.Context, req *pb.UpdatePayoutStatusRequest) (*pb.Payout, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	payout, err := s.payoutsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	switch req.Status {
+	case pb.PayoutStatus_PayoutPaid:
+		if payout.Status != int32(pb.PayoutStatus_PayoutProcessing) {
+			return nil, fmt.Errorf("invalid payout status, only processing payouts can be paid: id=%v", req.Id)
+		}
+
+		err = s.payoutsStore.Complete(ctx, id, payout.Status, int32(pb.PayoutStatus_PayoutPaid), "", now)
+		if err != nil {
+			return nil, err
+		}
+
+		payout.Status = int32(pb.PayoutStatus_PayoutPaid)
+		payout.CompletedAt = &now
+		payout.UpdatedAt = now
+	case pb.PayoutStatus_PayoutFailed:
+		if payout.Status != int32(pb.PayoutStatus_PayoutRequested) && payout.Status != int32(pb.PayoutStatus_PayoutProcessing) {
+			return nil, fmt.Errorf("invalid payout status, payout already completed: id=%v", req.Id)
+		}
+
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" || len(reason) > maxFailureReason {
+			return nil, fmt.Errorf("invalid payout failure, reason must have 1 to %d characters", maxFailureReason)
+		}
+
+		err = s.payouts.fail(ctx, payout, reason, now)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("invalid payout status, must be paid or failed")
+	}
+
+	return payout.ToProto(), nil
+}
+
+func (s *serviceImpl) ListLedgerEntries(req *pb.ListLedgerEntriesRequest, stream pb.WalletsService_ListLedgerEntriesServer) error {
+	_, err := primitive.ObjectIDFromHex(req.WalletId)
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.ledgerStore.GetByWallet(stream.Context(), req.WalletId)
+	if err != nil {
+		return err
+	}
+
+	for index := range entries {
+		err = stream.Send(entries[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// describeAccount shows the account back to its owner with the holder name,
+// the account number stays masked.
+func (s *serviceImpl) describeAccount(account *store.BankAccount) (*pb.BankAccount, error) {
+	result := account.ToProto()
+
+	holderName, err := s.cipher.Decrypt(account.HolderName)
+	if err != nil {
+		return nil, err
+	}
+
+	result.HolderName = holderName
+
+	return result, nil
+}
+
+// nextPayout returns the next run of an automatic schedule, payouts run at
+// midnight UTC and weekly ones on Mondays.
+func nextPayout(schedule pb.PayoutSchedule, from time.Time) *time.Time {
+	day := from.UTC().Truncate(24 * time.Hour)
+
+	var next time.Time
+
+	switch schedule {
+	case pb.PayoutSchedule_PayoutDaily:
+		next = day.AddDate(0, 0, 1)
+	case pb.PayoutSchedule_PayoutWeekly:
+		days := (8 - int(day.Weekday())) % 7
+		if days == 0 {
+			days = 7
+		}
+		next = day.AddDate(0, 0, days)
+	default:
+		return nil
+	}
+
+	return &next
+}
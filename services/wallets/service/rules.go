@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"go-delivery/pb"
+	"go-delivery/security/credentials"
+	"go-delivery/security/permissions"
+	"go-delivery/services/wallets/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewRules(walletsStore store.WalletsStore, topUpsStore store.TopUpsStore) permissions.Rules {
+	walletOwner := func(ctx context.Context, walletId string) (string, error) {
+		id, err := primitive.ObjectIDFromHex(walletId)
+		if err != nil {
+			return "", err
+		}
+
+		wallet, err := walletsStore.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		return wallet.UserId, nil
+	}
+
+	topUpOwner := func(ctx context.Context, topUpId string) (string, error) {
+		id, err := primitive.ObjectIDFromHex(topUpId)
+		if err != nil {
+			return "", err
+		}
+
+		topUp, err := topUpsStore.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		return topUp.UserId, nil
+	}
+
+	return permissions.Rules{
+		"/pb.WalletsService/CreateWallet": {
+			Permissions: []permissions.Permission{permissions.WalletsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Wallet).UserId, nil
+			},
+		},
+		"/pb.WalletsService/GetUserWallet": {
+			Permissions: []permissions.Permission{permissions.WalletsReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.GetUserWalletRequest).UserId, nil
+			},
+			Services: []string{credentials.ServiceOrders},
+		},
+		"/pb.WalletsService/GetWallet": {
+			Permissions: []permissions.Permission{permissions.WalletsReadOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return walletOwner(ctx, req.(*pb.GetWalletRequest).Id)
+			},
+		},
+		// users fund their wallet with top-ups, direct credits are internal.
+		"/pb.WalletsService/Credit": {
+			Services: []string{credentials.ServiceOrders},
+		},
+		"/pb.WalletsService/Debit": {
+			Permissions: []permissions.Permission{permissions.WalletsWriteAny},
+			Services:    []string{credentials.ServiceOrders},
+		},
+		"/pb.WalletsService/ListWallets": {
+			Permissions: []permissions.Permission{permissions.WalletsReadAny},
+		},
+		"/pb.WalletsService/CreateTopUp": {
+			Permissions: []permissions.Permission{permissions.WalletsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return walletOwner(ctx, req.(*pb.TopUp).WalletId)
+			},
+		},
+		"/pb.WalletsService/ConfirmTopUp": {
+			Permissions: []permissions.Permission{permissions.WalletsWriteOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return topUpOwner(ctx, req.(*pb.ConfirmTopUpRequest).Id)
+			},
+		},
+		"/pb.WalletsService/ListTopUps": {
+			Permissions: []permissions.Permission{permissions.WalletsReadOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return walletOwner(ctx, req.(*pb.ListTopUpsRequest).WalletId)
+			},
+		},
+		"/pb.WalletsService/HandlePaymentWebhook": {
+			Services: []string{credentials.ServiceAPI},
+		},
+		"/pb.WalletsService/SetBankAccount": {
+			Permissions: []permissions.Permission{permissions.PayoutsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.BankAccount).UserId, nil
+			},
+		},
+		"/pb.WalletsService/GetBankAccount": {
+			Permissions: []permissions.Permission{permissions.PayoutsReadOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.GetBankAccountRequest).UserId, nil
+			},
+		},
+		"/pb.WalletsService/SetPayoutSchedule": {
+			Permissions: []permissions.Permission{permissions.PayoutsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.PayoutScheduleRequest).UserId, nil
+			},
+		},
+		"/pb.WalletsService/RequestPayout": {
+			Permissions: []permissions.Permission{permissions.PayoutsWriteOwn},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.Payout).UserId, nil
+			},
+		},
+		// the payouts of every user, listed without user_id, need the any scope.
+		"/pb.WalletsService/ListPayouts": {
+			Permissions: []permissions.Permission{permissions.PayoutsReadOwn, permissions.PayoutsReadAny},
+			Owner: func(_ context.Context, req interface{}) (string, error) {
+				return req.(*pb.ListPayoutsRequest).UserId, nil
+			},
+		},
+		"/pb.WalletsService/UpdatePayoutStatus": {
+			Permissions: []permissions.Permission{permissions.PayoutsWriteAny},
+		},
+		"/pb.WalletsService/ListLedgerEntries": {
+			Permissions: []permissions.Permission{permissions.WalletsReadOwn},
+			Owner: func(ctx context.Context, req interface{}) (string, error) {
+				return walletOwner(ctx, req.(*pb.ListLedgerEntriesRequest).WalletId)
+			},
+		},
+	}
+}
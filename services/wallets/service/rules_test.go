@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"go-delivery/pb"
+	"go-delivery/security/permissions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestListPayoutsRule(t *testing.T) {
+	rule := NewRules(nil, nil)["/pb.WalletsService/ListPayouts"]
+	cfg := permissions.NewConfig()
+
+	userId := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name    string
+		role    pb.Role
+		req     *pb.ListPayoutsRequest
+		allowed bool
+	}{
+		{"seller own payouts", pb.Role_Seller, &pb.ListPayoutsRequest{UserId: userId}, true},
+		{"deliverer own payouts", pb.Role_Delivery, &pb.ListPayoutsRequest{UserId: userId}, true},
+		{"seller payouts of another user", pb.Role_Seller, &pb.ListPayoutsRequest{UserId: primitive.NewObjectID().Hex()}, false},
+		{"seller payouts of every user", pb.Role_Seller, &pb.ListPayoutsRequest{Status: pb.PayoutStatus_PayoutProcessing}, false},
+		{"deliverer payouts of every user", pb.Role_Delivery, &pb.ListPayoutsRequest{}, false},
+		{"customer own payouts", pb.Role_Customer, &pb.ListPayoutsRequest{UserId: userId}, false},
+		{"admin payouts of every user", pb.Role_Admin, &pb.ListPayoutsRequest{Status: pb.PayoutStatus_PayoutProcessing}, true},
+		{"admin payouts of a user", pb.Role_Admin, &pb.ListPayoutsRequest{UserId: primitive.NewObjectID().Hex()}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := permissions.Authorize(context.Background(), cfg, rule, test.role.String(), userId, nil, test.req)
+			if allowed := err == nil; allowed != test.allowed {
+				t.Errorf("allowed = %v, want %v, err=%v", allowed, test.allowed, err)
+			}
+		})
+	}
+}
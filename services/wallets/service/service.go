@@ -2,21 +2,50 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go-delivery/payments"
 	"go-delivery/pb"
 	"go-delivery/services/wallets/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"time"
 )
 
 type serviceImpl struct {
-	walletsStore store.WalletsStore
+	walletsStore      store.WalletsStore
+	topUpsStore       store.TopUpsStore
+	bankAccountsStore store.BankAccountsStore
+	payoutsStore      store.PayoutsStore
+	ledgerStore       store.LedgerStore
+	provider          payments.PaymentProvider
+	cipher            payments.Cipher
+	payouts           *payoutLedger
 	pb.UnimplementedWalletsServiceServer
 }
 
-func NewService(walletsStore store.WalletsStore) pb.WalletsServiceServer {
-	return &serviceImpl{walletsStore: walletsStore}
+func NewService(
+	walletsStore store.WalletsStore,
+	topUpsStore store.TopUpsStore,
+	bankAccountsStore store.BankAccountsStore,
+	payoutsStore store.PayoutsStore,
+	ledgerStore store.LedgerStore,
+	provider payments.PaymentProvider,
+	cipher payments.Cipher,
+) pb.WalletsServiceServer {
+
+	return &serviceImpl{
+		walletsStore:      walletsStore,
+		topUpsStore:       topUpsStore,
+		bankAccountsStore: bankAccountsStore,
+		payoutsStore:      payoutsStore,
+		ledgerStore:       ledgerStore,
+		provider:          provider,
+		cipher:            cipher,
+		payouts:           newPayoutLedger(walletsStore, payoutsStore, ledgerStore),
+	}
 }
 
 func (s *serviceImpl) CreateWallet(ctx context.Context, req *pb.Wallet) (*pb.Wallet, error) {
@@ -85,21 +114,16 @@ func (s *serviceImpl) Credit(ctx context.Context, req *pb.CreditRequest) (*pb.Wa
 		return nil, err
 	}
 
-	wallet, err := s.walletsStore.Get(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-
-	wallet.Cash += req.Amount
-	wallet.UpdatedAt = time.Now()
-
-	err = s.walletsStore.Update(ctx, wallet)
+	wallet, err := s.walletsStore.Deposit(ctx, id, req.Amount, req.Reference, time.Now())
 	if err != nil {
 		return nil, err
 	}
 
 	return wallet.ToProto(), nil
 }
+
+// Debit fails when the wallet cash doesn't cover the amount, the balance
+// never goes negative.
 func (s *serviceImpl) Debit(ctx context.Context, req *pb.DebitRequest) (*pb.Wallet, error) {
 	if req.Amount < 0 {
 		return nil, fmt.Errorf("invalid amount for debit: %f", req.Amount)
@@ -110,21 +134,17 @@ func (s *serviceImpl) Debit(ctx context.Context, req *pb.DebitRequest) (*pb.Wall
 		return nil, err
 	}
 
-	wallet, err := s.walletsStore.Get(ctx, id)
-	if err != nil {
-		return nil, err
+	wallet, err := s.walletsStore.Withdraw(ctx, id, req.Amount, req.Reference, time.Now())
+	if errors.Is(err, store.ErrInsufficientCash) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
 	}
-
-	wallet.Cash -= req.Amount
-	wallet.UpdatedAt = time.Now()
-
-	err = s.walletsStore.Update(ctx, wallet)
 	if err != nil {
 		return nil, err
 	}
 
 	return wallet.ToProto(), nil
 }
+
 func (s *serviceImpl) ListWallets(_ *pb.ListWalletsRequest, stream pb.WalletsService_ListWalletsServer) error {
 	wallets, err := s.walletsStore.GetAll(context.Background())
 	if err != nil {
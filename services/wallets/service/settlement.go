@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"go-delivery/payments"
+	"go-delivery/pb"
+	"go-delivery/services/wallets/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+// PayoutScheduler requests the payouts of the automatic schedules, then
+// executes every requested payout in a batch whose transfers are written to
+// a settlement file for the bank.
+type PayoutScheduler struct {
+	walletsStore      store.WalletsStore
+	bankAccountsStore store.BankAccountsStore
+	payoutsStore      store.PayoutsStore
+	cipher            payments.Cipher
+	settlementDir     string
+	payouts           *payoutLedger
+}
+
+func NewPayoutScheduler(
+	walletsStore store.WalletsStore,
+	bankAccountsStore store.BankAccountsStore,
+	payoutsStore store.PayoutsStore,
+	ledgerStore store.LedgerStore,
+	cipher payments.Cipher,
+	settlementDir string,
+) *PayoutScheduler {
+
+	return &PayoutScheduler{
+		walletsStore:      walletsStore,
+		bankAccountsStore: bankAccountsStore,
+		payoutsStore:      payoutsStore,
+		cipher:            cipher,
+		settlementDir:     settlementDir,
+		payouts:           newPayoutLedger(walletsStore, payoutsStore, ledgerStore),
+	}
+}
+
+func (p *PayoutScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.ExecuteDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *PayoutScheduler) ExecuteDue(ctx context.Context) {
+	p.requestScheduled(ctx)
+	p.executeRequested(ctx)
+}
+
+func (p *PayoutScheduler) requestScheduled(ctx context.Context) {
+	now := time.Now()
+
+	accounts, err := p.bankAccountsStore.GetScheduledDue(ctx, now)
+	if err != nil {
+		log.Printf("failed to load payout schedules: err=%v", err)
+		return
+	}
+
+	for _, account := range accounts {
+		// the schedule moves on first, so a failing account is retried on
+		// its next run only.
+		next := nextPayout(pb.PayoutSchedule(account.Schedule), now)
+		if next != nil {
+			err = p.bankAccountsStore.SetNextPayout(ctx, account.Id, *next)
+			if err != nil {
+				log.Printf("failed to update payout schedule: userId=%v, err=%v", account.UserId, err)
+				continue
+			}
+		}
+
+		err = p.requestScheduledPayout(ctx, account)
+		if err != nil {
+			log.Printf("failed to request scheduled payout: userId=%v, err=%v", account.UserId, err)
+		}
+	}
+}
+
+// requestScheduledPayout pays out the whole wallet cash once it reaches the
+// threshold of the schedule.
+func (p *PayoutScheduler) requestScheduledPayout(ctx context.Context, account *store.BankAccount) error {
+	userId, err := primitive.ObjectIDFromHex(account.UserId)
+	if err != nil {
+		return err
+	}
+
+	wallet, err := p.walletsStore.GetByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	threshold := account.PayoutThreshold
+	if threshold < minPayout {
+		threshold = minPayout
+	}
+
+	if wallet.Cash < threshold {
+		return nil
+	}
+
+	_, err = p.payouts.request(ctx, wallet, account, wallet.Cash, true)
+	return err
+}
+
+// executeRequested debits the requested payouts and writes their settlement
+// file. When the file can't be written the batch fails and the wallets are
+// credited back.
+func (p *PayoutScheduler) executeRequested(ctx context.Context) {
+	payouts, err := p.payoutsStore.GetByStatus(ctx, int32(pb.PayoutStatus_PayoutRequested))
+	if err != nil {
+		log.Printf("failed to load requested payouts: err=%v", err)
+		return
+	}
+
+	if len(payouts) == 0 {
+		return
+	}
+
+	batchId := primitive.NewObjectID().Hex()
+	now := time.Now()
+
+	var executed []*store.Payout
+	var lines []payments.SettlementLine
+
+	for _, payout := range payouts {
+		line, err := p.execute(ctx, payout, batchId, now)
+		if err != nil {
+			log.Printf("failed to execute payout: id=%v, err=%v", payout.Id.Hex(), err)
+			continue
+		}
+
+		executed = append(executed, payout)
+		lines = append(lines, *line)
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	path, err := payments.WriteSettlement(p.settlementDir, batchId, now, lines)
+	if err != nil {
+		log.Printf("failed to write settlement file: batchId=%v, err=%v", batchId, err)
+
+		for _, payout := range executed {
+			err = p.payouts.fail(ctx, payout, "settlement file not written", time.Now())
+			if err != nil {
+				log.Printf("failed to fail payout: id=%v, err=%v", payout.Id.Hex(), err)
+			}
+		}
+		return
+	}
+
+	log.Printf("payout batch settled: batchId=%v, total=%d, file=%v", batchId, len(lines), path)
+}
+
+// execute reads the bank details of the payout before debiting the wallet,
+// a payout without a readable bank account fails.
+func (p *PayoutScheduler) execute(ctx context.Context, payout *store.Payout, batchId string, at time.Time) (*payments.SettlementLine, error) {
+	account, err := p.bankAccountsStore.GetByUser(ctx, payout.UserId)
+
+	details := make([]string, 3)
+	if err == nil {
+		for index, sealed := range []string{account.HolderName, account.Iban, account.Bic} {
+			details[index], err = p.cipher.Decrypt(sealed)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		failErr := p.payouts.fail(ctx, payout, "bank account unavailable", at)
+		if failErr != nil {
+			log.Printf("failed to fail payout: id=%v, err=%v", payout.Id.Hex(), failErr)
+		}
+		return nil, err
+	}
+
+	err = p.payouts.execute(ctx, payout, batchId, account.IbanLast4, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payments.SettlementLine{
+		PayoutId:   payout.Id.Hex(),
+		HolderName: details[0],
+		Iban:       details[1],
+		Bic:        details[2],
+		Amount:     payout.Amount,
+	}, nil
+}
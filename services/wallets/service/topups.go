@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/protobuf/ptypes/empty"
+	"go-delivery/payments"
+	"go-delivery/pb"
+	"go-delivery/services/wallets/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+const (
+	minTopUp = 1
+	maxTopUp = 1000
+)
+
+var topUpStatuses = map[string]pb.TopUpStatus{
+	payments.StatusPending:   pb.TopUpStatus_TopUpPending,
+	payments.StatusSucceeded: pb.TopUpStatus_TopUpSucceeded,
+	payments.StatusFailed:    pb.TopUpStatus_TopUpFailed,
+}
+
+// CreateTopUp starts a payment with the provider, nothing is credited until
+// the payment is confirmed.
+func (s *serviceImpl) CreateTopUp(ctx context.Context, req *pb.TopUp) (*pb.TopUp, error) {
+	if req.Amount < minTopUp || req.Amount > maxTopUp {
+		return nil, fmt.Errorf("invalid top-up, amount must be between %d and %d: amount=%v", minTopUp, maxTopUp, req.Amount)
+	}
+
+	walletId, err := primitive.ObjectIDFromHex(req.WalletId)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.walletsStore.Get(ctx, walletId)
+	if err != nil {
+		return nil, err
+	}
+
+	id := primitive.NewObjectID()
+
+	intent, err := s.provider.CreateIntent(ctx, req.Amount, id.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	topUp := &store.TopUp{
+		Id:           id,
+		WalletId:     wallet.Id.Hex(),
+		UserId:       wallet.UserId,
+		Amount:       req.Amount,
+		Provider:     s.provider.Name(),
+		IntentId:     intent.Id,
+		ClientSecret: intent.ClientSecret,
+		Status:       int32(pb.TopUpStatus_TopUpPending),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	err = s.topUpsStore.Create(ctx, topUp)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp.ToProto(), nil
+}
+
+// ConfirmTopUp asks the provider for the payment status, the wallet is
+// credited when it succeeded.
+func (s *serviceImpl) ConfirmTopUp(ctx context.Context, req *pb.ConfirmTopUpRequest) (*pb.TopUp, error) {
+	id, err := primitive.ObjectIDFromHex(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	topUp, err := s.topUpsStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.WalletId != "" && topUp.WalletId != req.WalletId {
+		return nil, fmt.Errorf("top-up not found: id=%v", req.Id)
+	}
+
+	if topUp.Status != int32(pb.TopUpStatus_TopUpPending) {
+		return topUp.ToProto(), nil
+	}
+
+	if topUp.Provider != s.provider.Name() {
+		return nil, fmt.Errorf("top-up provider not available: provider=%v", topUp.Provider)
+	}
+
+	intent, err := s.provider.ConfirmIntent(ctx, topUp.IntentId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.settleTopUp(ctx, topUp, intent.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp.ToProto(), nil
+}
+
+func (s *serviceImpl) ListTopUps(req *pb.ListTopUpsRequest, stream pb.WalletsService_ListTopUpsServer) error {
+	_, err := primitive.ObjectIDFromHex(req.WalletId)
+	if err != nil {
+		return err
+	}
+
+	topUps, err := s.topUpsStore.GetByWallet(stream.Context(), req.WalletId)
+	if err != nil {
+		return err
+	}
+
+	for index := range topUps {
+		err = stream.Send(topUps[index].ToProto())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HandlePaymentWebhook settles the top-up of a provider notification, the
+// provider verifies the payload signature first.
+func (s *serviceImpl) HandlePaymentWebhook(ctx context.Context, req *pb.PaymentWebhookRequest) (*empty.Empty, error) {
+	if req.Provider != s.provider.Name() {
+		return nil, fmt.Errorf("unknown payment provider: provider=%v", req.Provider)
+	}
+
+	event, err := s.provider.ParseWebhook(req.Payload, req.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	topUp, err := s.topUpsStore.GetByIntent(ctx, req.Provider, event.IntentId)
+	if err != nil {
+		return nil, err
+	}
+
+	if topUp.Status == int32(pb.TopUpStatus_TopUpPending) {
+		err = s.settleTopUp(ctx, topUp, event.Status)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// settleTopUp completes the top-up with the intent status, pending intents
+// are left as they are. A succeeded top-up is credited before it is
+// completed, the credit is applied once, so a failed settlement is retried
+// by the next confirmation or webhook.
+func (s *serviceImpl) settleTopUp(ctx context.Context, topUp *store.TopUp, intentStatus string) error {
+	status, ok := topUpStatuses[intentStatus]
+	if !ok {
+		return fmt.Errorf("invalid payment status: status=%v", intentStatus)
+	}
+
+	if status == pb.TopUpStatus_TopUpPending {
+		return nil
+	}
+
+	now := time.Now()
+
+	if status == pb.TopUpStatus_TopUpSucceeded {
+		walletId, err := primitive.ObjectIDFromHex(topUp.WalletId)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.walletsStore.Deposit(ctx, walletId, topUp.Amount, "top-up:"+topUp.Id.Hex(), now)
+		if err != nil {
+			log.Printf("failed to credit succeeded top-up: id=%v, err=%v", topUp.Id.Hex(), err)
+			return err
+		}
+	}
+
+	err := s.topUpsStore.Complete(ctx, topUp.Id, int32(status), now)
+	if err != nil {
+		return err
+	}
+
+	topUp.Status = int32(status)
+	topUp.CompletedAt = &now
+	topUp.UpdatedAt = now
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const BankAccountsCollection = "bank_accounts"
+
+type BankAccountsStore interface {
+	Save(ctx context.Context, account *BankAccount) (*BankAccount, error)
+	GetByUser(ctx context.Context, userId string) (*BankAccount, error)
+	UpdateSchedule(ctx context.Context, userId string, schedule int32, threshold float32, next *time.Time, at time.Time) error
+	GetScheduledDue(ctx context.Context, at time.Time) ([]*BankAccount, error)
+	SetNextPayout(ctx context.Context, id primitive.ObjectID, next time.Time) error
+	CreateIndexes(ctx context.Context) error
+}
+
+type bankAccountsStore struct {
+	conn *mongo.Collection
+}
+
+func NewBankAccountsStore(dbConn *mongo.Database) BankAccountsStore {
+	return &bankAccountsStore{conn: dbConn.Collection(BankAccountsCollection)}
+}
+
+// Save creates the bank account of the user or replaces its details, the
+// payout schedule is kept. The saved account is returned.
+func (s *bankAccountsStore) Save(ctx context.Context, account *BankAccount) (*BankAccount, error) {
+	filter := bson.M{"user_id": account.UserId}
+	update := bson.M{
+		"$set": bson.M{
+			"holder_name": account.HolderName,
+			"iban":        account.Iban,
+			"bic":         account.Bic,
+			"iban_last4":  account.IbanLast4,
+			"updated_at":  account.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id":              account.Id,
+			"schedule":         int32(pb.PayoutSchedule_PayoutManual),
+			"payout_threshold": account.PayoutThreshold,
+			"next_payout_at":   nil,
+			"created_at":       account.CreatedAt,
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	saved := new(BankAccount)
+
+	err := s.conn.FindOneAndUpdate(ctx, filter, update, opts).Decode(saved)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("bank account saved: id=%v", saved.Id.Hex())
+
+	return saved, nil
+}
+
+func (s *bankAccountsStore) GetByUser(ctx context.Context, userId string) (*BankAccount, error) {
+	account := new(BankAccount)
+
+	err := s.conn.FindOne(ctx, bson.M{"user_id": userId}).Decode(account)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("bank account not found: userId=%v", userId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (s *bankAccountsStore) UpdateSchedule(ctx context.Context, userId string, schedule int32, threshold float32, next *time.Time, at time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"schedule":         schedule,
+			"payout_threshold": threshold,
+			"next_payout_at":   next,
+			"updated_at":       at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, bson.M{"user_id": userId}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("bank account not found: userId=%v", userId)
+	}
+
+	log.Printf("payout schedule updated: userId=%v, schedule=%v", userId, schedule)
+
+	return nil
+}
+
+// GetScheduledDue returns the accounts paid out automatically whose next
+// payout is due at the given time.
+func (s *bankAccountsStore) GetScheduledDue(ctx context.Context, at time.Time) ([]*BankAccount, error) {
+	filter := bson.M{
+		"schedule":       bson.M{"$ne": int32(pb.PayoutSchedule_PayoutManual)},
+		"next_payout_at": bson.M{"$lte": at},
+	}
+
+	cursor, err := s.conn.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []*BankAccount
+
+	err = cursor.All(ctx, &accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (s *bankAccountsStore) SetNextPayout(ctx context.Context, id primitive.ObjectID, next time.Time) error {
+	_, err := s.conn.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"next_payout_at": next}})
+	return err
+}
+
+func (s *bankAccountsStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("bank_accounts_user").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "schedule", Value: 1}, {Key: "next_payout_at", Value: 1}},
+			Options: options.Index().SetName("bank_accounts_schedule"),
+		},
+	})
+	return err
+}
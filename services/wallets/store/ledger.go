@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+const LedgerCollection = "ledger"
+
+type LedgerStore interface {
+	Create(ctx context.Context, entry *LedgerEntry) error
+	GetByWallet(ctx context.Context, walletId string) ([]*LedgerEntry, error)
+	CreateIndexes(ctx context.Context) error
+}
+
+type ledgerStore struct {
+	conn *mongo.Collection
+}
+
+func NewLedgerStore(dbConn *mongo.Database) LedgerStore {
+	return &ledgerStore{conn: dbConn.Collection(LedgerCollection)}
+}
+
+func (s *ledgerStore) Create(ctx context.Context, entry *LedgerEntry) error {
+	result, err := s.conn.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("ledger entry already recorded: reference=%v, kind=%v", entry.Reference, entry.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("ledger entry created: id=%v", result.InsertedID)
+
+	return nil
+}
+
+func (s *ledgerStore) GetByWallet(ctx context.Context, walletId string) ([]*LedgerEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, bson.M{"wallet_id": walletId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*LedgerEntry
+
+	err = cursor.All(ctx, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("list ledger entries: total=%d", len(entries))
+
+	return entries, nil
+}
+
+func (s *ledgerStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// a payout is debited, and reversed, at most once.
+			Keys:    bson.D{{Key: "reference", Value: 1}, {Key: "kind", Value: 1}},
+			Options: options.Index().SetName("ledger_reference").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "wallet_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("ledger_wallet"),
+		},
+	})
+	return err
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 )
 
+// Wallet documents also hold the latest references of the movements applied
+// with a reference, they are not loaded.
 type Wallet struct {
 	Id        primitive.ObjectID `bson:"_id"`
 	UserId    string             `bson:"user_id"`
@@ -39,4 +41,155 @@ func FromProto(w *pb.Wallet) (*Wallet, error) {
 	wallet.UpdatedAt = time.Unix(w.UpdatedAt, 0)
 
 	return &wallet, nil
-}
\ No newline at end of file
+}
+
+// Movement is a wallet movement applied with a reference, a reference is
+// applied once per wallet.
+type Movement struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	WalletId  string             `bson:"wallet_id"`
+	Reference string             `bson:"reference"`
+	Amount    float32            `bson:"amount"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// TopUp is a wallet funding through the payment provider, CompletedAt is set
+// once the intent succeeded or failed.
+type TopUp struct {
+	Id           primitive.ObjectID `bson:"_id"`
+	WalletId     string             `bson:"wallet_id"`
+	UserId       string             `bson:"user_id"`
+	Amount       float32            `bson:"amount"`
+	Provider     string             `bson:"provider"`
+	IntentId     string             `bson:"intent_id"`
+	ClientSecret string             `bson:"client_secret"`
+	Status       int32              `bson:"status"`
+	CompletedAt  *time.Time         `bson:"completed_at"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+}
+
+func (t *TopUp) ToProto() *pb.TopUp {
+	topUp := &pb.TopUp{
+		Id:           t.Id.Hex(),
+		WalletId:     t.WalletId,
+		UserId:       t.UserId,
+		Amount:       t.Amount,
+		Provider:     t.Provider,
+		IntentId:     t.IntentId,
+		ClientSecret: t.ClientSecret,
+		Status:       pb.TopUpStatus(t.Status),
+		CreatedAt:    t.CreatedAt.Unix(),
+		UpdatedAt:    t.UpdatedAt.Unix(),
+	}
+
+	if t.CompletedAt != nil {
+		topUp.CompletedAt = t.CompletedAt.Unix()
+	}
+
+	return topUp
+}
+
+// BankAccount holds the payout bank details of a user, HolderName, Iban and
+// Bic are sealed by the payouts cipher.
+type BankAccount struct {
+	Id              primitive.ObjectID `bson:"_id"`
+	UserId          string             `bson:"user_id"`
+	HolderName      string             `bson:"holder_name"`
+	Iban            string             `bson:"iban"`
+	Bic             string             `bson:"bic"`
+	IbanLast4       string             `bson:"iban_last4"`
+	Schedule        int32              `bson:"schedule"`
+	PayoutThreshold float32            `bson:"payout_threshold"`
+	NextPayoutAt    *time.Time         `bson:"next_payout_at"`
+	CreatedAt       time.Time          `bson:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at"`
+}
+
+// ToProto leaves the sealed details out, only the last digits of the IBAN
+// are shown.
+func (b *BankAccount) ToProto() *pb.BankAccount {
+	account := &pb.BankAccount{
+		Id:              b.Id.Hex(),
+		UserId:          b.UserId,
+		IbanLast4:       b.IbanLast4,
+		Schedule:        pb.PayoutSchedule(b.Schedule),
+		PayoutThreshold: b.PayoutThreshold,
+		CreatedAt:       b.CreatedAt.Unix(),
+		UpdatedAt:       b.UpdatedAt.Unix(),
+	}
+
+	if b.NextPayoutAt != nil {
+		account.NextPayoutAt = b.NextPayoutAt.Unix()
+	}
+
+	return account
+}
+
+type Payout struct {
+	Id            primitive.ObjectID `bson:"_id"`
+	WalletId      string             `bson:"wallet_id"`
+	UserId        string             `bson:"user_id"`
+	Amount        float32            `bson:"amount"`
+	IbanLast4     string             `bson:"iban_last4"`
+	Status        int32              `bson:"status"`
+	Scheduled     bool               `bson:"scheduled"`
+	BatchId       string             `bson:"batch_id,omitempty"`
+	FailureReason string             `bson:"failure_reason,omitempty"`
+	ExecutedAt    *time.Time         `bson:"executed_at"`
+	CompletedAt   *time.Time         `bson:"completed_at"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at"`
+}
+
+func (p *Payout) ToProto() *pb.Payout {
+	payout := &pb.Payout{
+		Id:            p.Id.Hex(),
+		WalletId:      p.WalletId,
+		UserId:        p.UserId,
+		Amount:        p.Amount,
+		IbanLast4:     p.IbanLast4,
+		Status:        pb.PayoutStatus(p.Status),
+		Scheduled:     p.Scheduled,
+		BatchId:       p.BatchId,
+		FailureReason: p.FailureReason,
+		CreatedAt:     p.CreatedAt.Unix(),
+		UpdatedAt:     p.UpdatedAt.Unix(),
+	}
+
+	if p.ExecutedAt != nil {
+		payout.ExecutedAt = p.ExecutedAt.Unix()
+	}
+
+	if p.CompletedAt != nil {
+		payout.CompletedAt = p.CompletedAt.Unix()
+	}
+
+	return payout
+}
+
+// LedgerEntry is a wallet movement, Amount is negative for debits and
+// Reference is the id of the payout it belongs to.
+type LedgerEntry struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	WalletId  string             `bson:"wallet_id"`
+	UserId    string             `bson:"user_id"`
+	Kind      int32              `bson:"kind"`
+	Amount    float32            `bson:"amount"`
+	Balance   float32            `bson:"balance"`
+	Reference string             `bson:"reference"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func (l *LedgerEntry) ToProto() *pb.LedgerEntry {
+	return &pb.LedgerEntry{
+		Id:        l.Id.Hex(),
+		WalletId:  l.WalletId,
+		UserId:    l.UserId,
+		Kind:      pb.LedgerEntryKind(l.Kind),
+		Amount:    l.Amount,
+		Balance:   l.Balance,
+		Reference: l.Reference,
+		CreatedAt: l.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const PayoutsCollection = "payouts"
+
+type PayoutsStore interface {
+	Create(ctx context.Context, payout *Payout) error
+	Get(ctx context.Context, id primitive.ObjectID) (*Payout, error)
+	GetByUser(ctx context.Context, userId string) ([]*Payout, error)
+	GetByStatus(ctx context.Context, status int32) ([]*Payout, error)
+	Execute(ctx context.Context, id primitive.ObjectID, batchId, ibanLast4 string, at time.Time) error
+	Complete(ctx context.Context, id primitive.ObjectID, from, status int32, reason string, at time.Time) error
+	CreateIndexes(ctx context.Context) error
+}
+
+type payoutsStore struct {
+	conn *mongo.Collection
+}
+
+func NewPayoutsStore(dbConn *mongo.Database) PayoutsStore {
+	return &payoutsStore{conn: dbConn.Collection(PayoutsCollection)}
+}
+
+func (s *payoutsStore) Create(ctx context.Context, payout *Payout) error {
+	result, err := s.conn.InsertOne(ctx, payout)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("payout already requested: userId=%v", payout.UserId)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("payout created: id=%v", result.InsertedID)
+
+	return nil
+}
+
+func (s *payoutsStore) Get(ctx context.Context, id primitive.ObjectID) (*Payout, error) {
+	payout := new(Payout)
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(payout)
+	if err != nil {
+		return nil, err
+	}
+
+	return payout, nil
+}
+
+func (s *payoutsStore) GetByUser(ctx context.Context, userId string) ([]*Payout, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	return s.find(ctx, bson.M{"user_id": userId}, opts)
+}
+
+// GetByStatus returns the payouts with the status, oldest first.
+func (s *payoutsStore) GetByStatus(ctx context.Context, status int32) ([]*Payout, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	return s.find(ctx, bson.M{"status": status}, opts)
+}
+
+func (s *payoutsStore) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*Payout, error) {
+	cursor, err := s.conn.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var payouts []*Payout
+
+	err = cursor.All(ctx, &payouts)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("list payouts: total=%d", len(payouts))
+
+	return payouts, nil
+}
+
+// Execute moves a requested payout to its batch, a payout is only taken by
+// one batch.
+func (s *payoutsStore) Execute(ctx context.Context, id primitive.ObjectID, batchId, ibanLast4 string, at time.Time) error {
+	filter := bson.M{"_id": id, "status": int32(pb.PayoutStatus_PayoutRequested)}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      int32(pb.PayoutStatus_PayoutProcessing),
+			"batch_id":    batchId,
+			"iban_last4":  ibanLast4,
+			"executed_at": at,
+			"updated_at":  at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("payout not requested anymore: id=%v", id.Hex())
+	}
+
+	log.Printf("payout executed: id=%v, batchId=%v", id.Hex(), batchId)
+
+	return nil
+}
+
+// Complete moves the payout from the status to its final one, so the wallet
+// of a failed payout is only credited back once.
+func (s *payoutsStore) Complete(ctx context.Context, id primitive.ObjectID, from, status int32, reason string, at time.Time) error {
+	filter := bson.M{"_id": id, "status": from}
+	update := bson.M{
+		"$set": bson.M{
+			"status":         status,
+			"failure_reason": reason,
+			"completed_at":   at,
+			"updated_at":     at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("payout status changed: id=%v", id.Hex())
+	}
+
+	log.Printf("payout completed: id=%v, status=%v", id.Hex(), status)
+
+	return nil
+}
+
+func (s *payoutsStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// a user has at most one payout waiting for the next batch.
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("payouts_requested").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": int32(pb.PayoutStatus_PayoutRequested)}),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("payouts_user"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("payouts_status"),
+		},
+	})
+	return err
+}
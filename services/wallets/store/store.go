@@ -2,28 +2,49 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
+	"time"
 )
 
-const WalletsCollection = "wallets"
+const (
+	WalletsCollection   = "wallets"
+	MovementsCollection = "movements"
+
+	// recentReferences is how many references a wallet document keeps, they
+	// only cover the movements not recorded in the movements collection yet.
+	recentReferences = 100
+)
+
+// ErrInsufficientCash is returned by a withdrawal the wallet cash doesn't
+// cover, retrying it fails the same way until the wallet is topped up.
+var ErrInsufficientCash = errors.New("insufficient wallet cash")
 
 type WalletsStore interface {
 	Create(ctx context.Context, wallet *Wallet) error
-	Update(ctx context.Context, wallet *Wallet) error
 	Get(ctx context.Context, id primitive.ObjectID) (*Wallet, error)
 	GetByUser(ctx context.Context, id primitive.ObjectID) (*Wallet, error)
 	GetAll(ctx context.Context) ([]*Wallet, error)
+	Withdraw(ctx context.Context, id primitive.ObjectID, amount float32, reference string, at time.Time) (*Wallet, error)
+	Deposit(ctx context.Context, id primitive.ObjectID, amount float32, reference string, at time.Time) (*Wallet, error)
+	CreateIndexes(ctx context.Context) error
 }
 
 type store struct {
-	conn *mongo.Collection
+	conn      *mongo.Collection
+	movements *mongo.Collection
 }
 
 func NewWalletsStore(dbConn *mongo.Database) WalletsStore {
-	return &store{conn: dbConn.Collection(WalletsCollection)}
+	return &store{
+		conn:      dbConn.Collection(WalletsCollection),
+		movements: dbConn.Collection(MovementsCollection),
+	}
 }
 
 func (s *store) Create(ctx context.Context, wallet *Wallet) error {
@@ -37,26 +58,6 @@ func (s *store) Create(ctx context.Context, wallet *Wallet) error {
 	return nil
 }
 
-func (s *store) Update(ctx context.Context, wallet *Wallet) error {
-	update := bson.M{
-		"$set": bson.M{
-			"cash":       wallet.Cash,
-			"updated_at": wallet.UpdatedAt,
-		},
-	}
-
-	filter := bson.M{"_id": bson.M{"$eq": wallet.Id}}
-
-	result, err := s.conn.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
-
-	log.Printf("wallet updated: total=%v", result.ModifiedCount)
-
-	return nil
-}
-
 func (s *store) Get(ctx context.Context, id primitive.ObjectID) (*Wallet, error) {
 	wallet := new(Wallet)
 
@@ -101,3 +102,123 @@ func (s *store) GetAll(ctx context.Context) ([]*Wallet, error) {
 
 	return wallets, nil
 }
+
+// Withdraw takes the amount from the wallet in a single update, it fails
+// when the wallet cash doesn't cover it. The updated wallet is returned.
+// A withdrawal with a reference is applied once, repeating it returns the
+// wallet unchanged.
+func (s *store) Withdraw(ctx context.Context, id primitive.ObjectID, amount float32, reference string, at time.Time) (*Wallet, error) {
+	filter := bson.M{"_id": id, "cash": bson.M{"$gte": amount}}
+
+	wallet, err := s.increment(ctx, filter, -amount, reference, at)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("%w: id=%v, amount=%v", ErrInsufficientCash, id.Hex(), amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("wallet withdrawn: id=%v, amount=%v, reference=%v", id.Hex(), amount, reference)
+
+	return wallet, nil
+}
+
+// Deposit adds the amount to the wallet, a deposit with a reference is
+// applied once.
+func (s *store) Deposit(ctx context.Context, id primitive.ObjectID, amount float32, reference string, at time.Time) (*Wallet, error) {
+	wallet, err := s.increment(ctx, bson.M{"_id": id}, amount, reference, at)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("wallet deposited: id=%v, amount=%v, reference=%v", id.Hex(), amount, reference)
+
+	return wallet, nil
+}
+
+// increment applies the amount and records its reference in the same
+// update, so a retried movement can't be applied twice. The wallet only
+// keeps its latest references, a movement is recorded for good in the
+// movements collection once applied.
+func (s *store) increment(ctx context.Context, filter bson.M, amount float32, reference string, at time.Time) (*Wallet, error) {
+	id := filter["_id"].(primitive.ObjectID)
+
+	update := bson.M{
+		"$inc": bson.M{"cash": amount},
+		"$set": bson.M{"updated_at": at},
+	}
+
+	if reference != "" {
+		applied, err := s.movements.CountDocuments(ctx, bson.M{"wallet_id": id.Hex(), "reference": reference})
+		if err != nil {
+			return nil, err
+		}
+		if applied > 0 {
+			return s.applied(ctx, id, reference)
+		}
+
+		filter["references"] = bson.M{"$ne": reference}
+		update["$push"] = bson.M{"references": bson.M{"$each": bson.A{reference}, "$slice": -recentReferences}}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	wallet := new(Wallet)
+
+	err := s.conn.FindOneAndUpdate(ctx, filter, update, opts).Decode(wallet)
+	if err != mongo.ErrNoDocuments || reference == "" {
+		if err != nil {
+			return nil, err
+		}
+		return wallet, s.record(ctx, id, amount, reference, at)
+	}
+
+	err = s.conn.FindOne(ctx, bson.M{"_id": id, "references": reference}).Decode(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("wallet movement already applied: id=%v, reference=%v", id.Hex(), reference)
+
+	return wallet, s.record(ctx, id, amount, reference, at)
+}
+
+func (s *store) applied(ctx context.Context, id primitive.ObjectID, reference string) (*Wallet, error) {
+	wallet, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("wallet movement already applied: id=%v, reference=%v", id.Hex(), reference)
+
+	return wallet, nil
+}
+
+// record saves the movement applied with a reference, a movement recorded
+// by a concurrent retry is left as is.
+func (s *store) record(ctx context.Context, id primitive.ObjectID, amount float32, reference string, at time.Time) error {
+	if reference == "" {
+		return nil
+	}
+
+	_, err := s.movements.InsertOne(ctx, &Movement{
+		Id:        primitive.NewObjectID(),
+		WalletId:  id.Hex(),
+		Reference: reference,
+		Amount:    amount,
+		CreatedAt: at,
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) CreateIndexes(ctx context.Context) error {
+	_, err := s.movements.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "wallet_id", Value: 1}, {Key: "reference", Value: 1}},
+		Options: options.Index().SetName("movements_reference").SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"go-delivery/pb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const TopUpsCollection = "top_ups"
+
+type TopUpsStore interface {
+	Create(ctx context.Context, topUp *TopUp) error
+	Get(ctx context.Context, id primitive.ObjectID) (*TopUp, error)
+	GetByIntent(ctx context.Context, provider, intentId string) (*TopUp, error)
+	GetByWallet(ctx context.Context, walletId string) ([]*TopUp, error)
+	Complete(ctx context.Context, id primitive.ObjectID, status int32, at time.Time) error
+	CreateIndexes(ctx context.Context) error
+}
+
+type topUpsStore struct {
+	conn *mongo.Collection
+}
+
+func NewTopUpsStore(dbConn *mongo.Database) TopUpsStore {
+	return &topUpsStore{conn: dbConn.Collection(TopUpsCollection)}
+}
+
+func (s *topUpsStore) Create(ctx context.Context, topUp *TopUp) error {
+	result, err := s.conn.InsertOne(ctx, topUp)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("top-up created: id=%v", result.InsertedID)
+
+	return nil
+}
+
+func (s *topUpsStore) Get(ctx context.Context, id primitive.ObjectID) (*TopUp, error) {
+	topUp := new(TopUp)
+
+	err := s.conn.FindOne(ctx, bson.M{"_id": id}).Decode(topUp)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp, nil
+}
+
+func (s *topUpsStore) GetByIntent(ctx context.Context, provider, intentId string) (*TopUp, error) {
+	topUp := new(TopUp)
+
+	err := s.conn.FindOne(ctx, bson.M{"provider": provider, "intent_id": intentId}).Decode(topUp)
+	if err != nil {
+		return nil, err
+	}
+
+	return topUp, nil
+}
+
+func (s *topUpsStore) GetByWallet(ctx context.Context, walletId string) ([]*TopUp, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := s.conn.Find(ctx, bson.M{"wallet_id": walletId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var topUps []*TopUp
+
+	err = cursor.All(ctx, &topUps)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("list top-ups: total=%d", len(topUps))
+
+	return topUps, nil
+}
+
+// Complete settles a pending top-up, so a top-up confirmed and notified by
+// the webhook at once is only settled, and credited, once.
+func (s *topUpsStore) Complete(ctx context.Context, id primitive.ObjectID, status int32, at time.Time) error {
+	filter := bson.M{"_id": id, "status": int32(pb.TopUpStatus_TopUpPending)}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       status,
+			"completed_at": at,
+			"updated_at":   at,
+		},
+	}
+
+	result, err := s.conn.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("top-up already completed: id=%v", id.Hex())
+	}
+
+	log.Printf("top-up completed: id=%v, status=%v", id.Hex(), status)
+
+	return nil
+}
+
+func (s *topUpsStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.conn.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "intent_id", Value: 1}},
+			Options: options.Index().SetName("top_ups_intent").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "wallet_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("top_ups_wallet"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	out      string
+	services string
+	hosts    string
+	validity time.Duration
+)
+
+func init() {
+	flag.StringVar(&out, "out", "certs", "output directory")
+	flag.StringVar(&services, "services", "accounts,wallets,sellers,orders,api", "comma separated service names")
+	flag.StringVar(&hosts, "hosts", "localhost,127.0.0.1", "comma separated hosts added to every certificate")
+	flag.DurationVar(&validity, "validity", time.Hour*24*365, "certificates validity")
+
+	flag.Parse()
+}
+
+// generates a local development CA and one certificate per service, the
+// service name is used as common name and identifies the peer in mTLS.
+func main() {
+	err := os.MkdirAll(out, 0700)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          serial(),
+		Subject:               pkix.Name{CommonName: "go-delivery dev ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	write("ca", caDER, caKey)
+
+	for _, name := range strings.Split(services, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			log.Panicln(err)
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: serial(),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(validity),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			DNSNames:     []string{name},
+		}
+
+		for _, host := range strings.Split(hosts, ",") {
+			host = strings.TrimSpace(host)
+			if ip := net.ParseIP(host); ip != nil {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			} else if host != "" {
+				template.DNSNames = append(template.DNSNames, host)
+			}
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			log.Panicln(err)
+		}
+
+		write(name, der, key)
+	}
+
+	log.Printf("certificates written to: %s\n", out)
+}
+
+func write(name string, der []byte, key *ecdsa.PrivateKey) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	err = os.WriteFile(path.Join(out, fmt.Sprintf("%s.pem", name)), certPEM, 0644)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	err = os.WriteFile(path.Join(out, fmt.Sprintf("%s-key.pem", name)), keyPEM, 0600)
+	if err != nil {
+		log.Panicln(err)
+	}
+}
+
+func serial() *big.Int {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		log.Panicln(err)
+	}
+	return n
+}
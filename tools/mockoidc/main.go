@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-delivery/tools/mockoidc/provider"
+	"log"
+	"net/http"
+)
+
+var (
+	port    int
+	options provider.Options
+)
+
+func init() {
+	flag.IntVar(&port, "port", 9000, "http port")
+	flag.StringVar(&options.ClientId, "client_id", "go-delivery", "accepted client id")
+	flag.StringVar(&options.ClientSecret, "client_secret", "secret", "accepted client secret")
+	flag.StringVar(&options.Email, "email", "customer@example.com", "email of the signed in user, overridden by login_hint")
+	flag.BoolVar(&options.EmailVerified, "email_verified", true, "email_verified claim")
+
+	flag.Parse()
+}
+
+// serves a local OpenID Connect provider that approves every authorization
+// request, the subject is derived from the email so links are stable.
+func main() {
+	p, err := provider.New(options)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	p.Issuer = fmt.Sprintf("http://localhost:%d", port)
+
+	log.Printf("Mock OIDC provider running on: %s\n", p.Issuer)
+
+	log.Fatalln(http.ListenAndServe(fmt.Sprintf(":%d", port), p))
+}